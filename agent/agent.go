@@ -6,7 +6,9 @@ import (
 	"errors"
 	"log"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
 	"malten.ai/command"
@@ -98,7 +100,7 @@ var tools = []openai.Tool{
 				"properties": {
 					"query": {
 						"type": "string",
-						"description": "Optional search query. Leave empty for latest headlines."
+						"description": "Optional search query. Leave empty for latest headlines, or 'local' for news near the user."
 					}
 				}
 			}`),
@@ -160,7 +162,7 @@ var tools = []openai.Tool{
 				"properties": {
 					"type": {
 						"type": "string",
-						"description": "What to search for (bowling, cinema, gym, hotel, arcade, spa, or any place type)"
+						"description": "What to search for (bowling, cinema, gym, hotel, arcade, spa, or any place type). Prefix with 'open' to only show places open now"
 					}
 				},
 				"required": ["type"]
@@ -171,19 +173,62 @@ var tools = []openai.Tool{
 		Type: openai.ToolTypeFunction,
 		Function: &openai.FunctionDefinition{
 			Name:        "directions",
-			Description: "Get walking directions to a destination. Use for 'how do I get to X', 'directions to X', 'walk to X' type questions.",
+			Description: "Get walking, cycling, driving or wheelchair directions to a destination. Use for 'how do I get to X', 'directions to X', 'walk to X', 'cycle to X', 'drive to X' type questions.",
 			Parameters: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"destination": {
 						"type": "string",
 						"description": "Where to go (e.g., 'the station', 'Tesco', 'Whitton Station')"
+					},
+					"mode": {
+						"type": "string",
+						"enum": ["walk", "cycle", "drive", "wheelchair"],
+						"description": "Travel mode, walk if not given"
+					}
+				},
+				"required": ["destination"]
+			}`),
+		},
+	},
+	{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        "journey",
+			Description: "Plan a journey by public transport (bus, tube, rail) with walking legs, leave-by time and changes. Use for 'bus to X', 'get to X by tube', 'public transport to X' type questions.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"destination": {
+						"type": "string",
+						"description": "Where to go (e.g., 'Kings Cross', 'Brixton', 'Heathrow')"
 					}
 				},
 				"required": ["destination"]
 			}`),
 		},
 	},
+	{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        "errands",
+			Description: "Plan one walking route through several errands (pharmacy, cash, bakery), picking a place for each and the best order, optionally finishing somewhere. Use for 'I need X and Y on the way home' type requests.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"errands": {
+						"type": "string",
+						"description": "Things to do, comma separated (e.g., 'pharmacy, cash, bakery')"
+					},
+					"end": {
+						"type": "string",
+						"description": "Where to finish, if anywhere (e.g., 'home', 'Kings Cross')"
+					}
+				},
+				"required": ["errands"]
+			}`),
+		},
+	},
 }
 
 // Message represents a conversation message
@@ -211,10 +256,11 @@ func executeTool(name string, args map[string]interface{}) (string, error) {
 
 	case "news":
 		query, _ := args["query"].(string)
-		if query == "" {
-			return command.Execute("news", nil)
+		var lat, lon float64
+		if loc := command.GetLocation(CurrentStream); loc != nil {
+			lat, lon = loc.Lat, loc.Lon
 		}
-		return command.Execute("news", []string{query})
+		return command.News(query, lat, lon)
 
 	case "video":
 		query, _ := args["query"].(string)
@@ -256,10 +302,36 @@ func executeTool(name string, args map[string]interface{}) (string, error) {
 		if loc == nil {
 			return "📍 Need your location for directions. Enable location?", nil
 		}
-		result, err := command.Directions(dest, loc.Lat, loc.Lon)
+		mode, _ := args["mode"].(string)
+		result, err := command.DirectionsBy(mode, dest, loc.Lat, loc.Lon)
 		log.Printf("[directions] result=%d chars, err=%v", len(result), err)
 		return result, err
 
+	case "journey":
+		dest, _ := args["destination"].(string)
+		if dest == "" {
+			return "Where do you want to go?", nil
+		}
+		loc := command.GetLocation(CurrentStream)
+		if loc == nil {
+			return "📍 Need your location for directions. Enable location?", nil
+		}
+		return command.Journey(CurrentStream, dest, loc.Lat, loc.Lon)
+
+	case "errands":
+		errands, _ := args["errands"].(string)
+		if errands == "" {
+			return "What do you need to get?", nil
+		}
+		loc := command.GetLocation(CurrentStream)
+		if loc == nil {
+			return "📍 Need your location to plan errands. Enable location?", nil
+		}
+		if end, _ := args["end"].(string); end != "" {
+			errands += " on the way to " + end
+		}
+		return command.Errands(CurrentStream, errands, loc.Lat, loc.Lon)
+
 	default:
 		return "", errors.New("unknown tool: " + name)
 	}
@@ -276,7 +348,9 @@ Available tools:
 - blog: Blog posts. Use for: blog, posts, articles
 - chat: Real-time AI with current context. Use for: questions needing current info, analysis with real-time data
 - nearby: Find nearby places. Use for: X near me, find X, where's the nearest X
-- directions: Walking directions. Use for: how do I get to X, directions to X, walk to X, way to X
+- directions: Walking, cycling, driving or wheelchair directions. Use for: how do I get to X, directions to X, walk to X, way to X, cycle to X, drive to X
+- journey: Public transport journey. Use for: bus to X, get to X by tube, train to X, public transport to X
+- errands: Walking route through several errands. Use for: I need X and Y on the way home, errands: X, Y, Z
 - none: Direct answer without tools. Use for: general questions, math, definitions, coding help
 
 Respond with ONLY a JSON object, nothing else:
@@ -288,11 +362,16 @@ Examples:
 - "what is 2+2" -> {"tool": "none", "args": {}}
 - "latest news" -> {"tool": "news", "args": {}}
 - "news about gaza" -> {"tool": "news", "args": {"query": "gaza"}}
+- "local news" -> {"tool": "news", "args": {"query": "local"}}
 - "daily reminder" -> {"tool": "reminder", "args": {}}
 - "what does quran say about patience" -> {"tool": "reminder", "args": {"query": "patience"}}
 - "how do I get to the station" -> {"tool": "directions", "args": {"destination": "station"}}
 - "directions to tesco" -> {"tool": "directions", "args": {"destination": "tesco"}}
-- "cafes near me" -> {"tool": "nearby", "args": {"type": "cafe"}}`
+- "cycle to richmond park" -> {"tool": "directions", "args": {"destination": "richmond park", "mode": "cycle"}}
+- "how do I get to kings cross by tube" -> {"tool": "journey", "args": {"destination": "kings cross"}}
+- "I need a pharmacy and cash on the way to the station" -> {"tool": "errands", "args": {"errands": "pharmacy, cash", "end": "station"}}
+- "cafes near me" -> {"tool": "nearby", "args": {"type": "cafe"}}
+- "pharmacy open now" -> {"tool": "nearby", "args": {"type": "open pharmacy"}}`
 
 // CurrentStream holds the stream context for the current request
 var CurrentStream string
@@ -305,26 +384,49 @@ func Prompt(systemPrompt string, messages []Message, userPrompt string) (string,
 
 	hasContext := strings.Contains(systemPrompt, "📍")
 
+	// Several stops in one go - checked before directions, "on the way to X" isn't a route to X
+	if isErrandRun(userPrompt) {
+		log.Printf("[AI] Errand run: %q", userPrompt)
+		loc := command.GetLocation(CurrentStream)
+		if loc != nil {
+			result, err := command.Errands(CurrentStream, userPrompt, loc.Lat, loc.Lon)
+			if err == nil && result != "" {
+				return result, nil
+			}
+		}
+	}
+
+	// Public transport journeys - checked before walking directions
+	if dest := journeyDestination(userPrompt); dest != "" {
+		log.Printf("[AI] Journey to: %q", dest)
+		result, err := executeTool("journey", map[string]interface{}{"destination": dest})
+		if err == nil && result != "" {
+			return result, nil
+		}
+	}
+
 	// Check for directions - still need tool for routing even with context
 	lower := strings.ToLower(userPrompt)
 	needsDirections := strings.Contains(lower, "how do i get") || strings.Contains(lower, "directions to") ||
-		strings.Contains(lower, "walk to") || strings.Contains(lower, "route to")
+		strings.Contains(lower, "walk to") || strings.Contains(lower, "route to") ||
+		strings.Contains(lower, "cycle to") || strings.Contains(lower, "bike to") || strings.Contains(lower, "drive to")
 
 	if needsDirections {
 		log.Printf("[AI] Directions query, extracting destination")
 		// Extract destination directly without going through selectTool
 		dest := userPrompt
-		for _, prefix := range []string{"how do i get to ", "how do I get to ", "directions to ", "walk to ", "way to ", "route to "} {
+		for _, prefix := range []string{"how do i get to ", "how do I get to ", "directions to ", "walk to ", "way to ", "route to ", "cycle to ", "bike to ", "drive to "} {
 			if idx := strings.Index(lower, prefix); idx >= 0 {
 				dest = userPrompt[idx+len(prefix):]
 				break
 			}
 		}
 		dest = strings.TrimSuffix(dest, "?")
-		dest = strings.TrimSpace(dest)
+		dest = trimTravelMode(strings.TrimSpace(dest))
 		if dest != "" {
-			log.Printf("[AI] Directions to: %q", dest)
-			result, err := executeTool("directions", map[string]interface{}{"destination": dest})
+			mode := travelMode(lower)
+			log.Printf("[AI] Directions to: %q (%s)", dest, mode)
+			result, err := executeTool("directions", map[string]interface{}{"destination": dest, "mode": mode})
 			if err == nil && result != "" {
 				return result, nil
 			}
@@ -445,6 +547,94 @@ func isContextQuestion(prompt string) bool {
 	return false
 }
 
+// Travel mode phrases, anchored so "recycling centre", "bike shop" and
+// "car park" still walk
+var (
+	cycleModeRe      = regexp.MustCompile(`\b(?:(?:cycle|cycling|bike)(?: route)? to|by (?:bike|bicycle)|on (?:my|a) bike|cycling (?:route|directions))\b`)
+	driveModeRe      = regexp.MustCompile(`\b(?:(?:drive|driving)(?: route)? to|by car|driving (?:route|directions))\b`)
+	wheelchairModeRe = regexp.MustCompile(`\b(?:(?:wheelchair|step[- ]free)(?: accessible)?(?: route)? to|by wheelchair|in a wheelchair|step[- ]free (?:route|directions))\b`)
+)
+
+// travelMode picks the directions mode from a question, "" for walking
+func travelMode(lower string) string {
+	switch {
+	case cycleModeRe.MatchString(lower):
+		return "cycle"
+	case driveModeRe.MatchString(lower):
+		return "drive"
+	case wheelchairModeRe.MatchString(lower):
+		return "wheelchair"
+	}
+	return ""
+}
+
+// trimTravelMode drops a trailing "by bike" or "by car" from a destination
+func trimTravelMode(dest string) string {
+	lower := strings.ToLower(dest)
+	for _, suffix := range []string{" by bike", " by bicycle", " by car", " by wheelchair", " on foot", " on my bike"} {
+		if strings.HasSuffix(lower, suffix) {
+			return strings.TrimSpace(dest[:len(dest)-len(suffix)])
+		}
+	}
+	return dest
+}
+
+// isErrandRun reports whether a prompt asks for several stops on one walk
+// "I need a pharmacy and cash on the way home", "errands: bakery, post office"
+func isErrandRun(userPrompt string) bool {
+	lower := strings.ToLower(strings.TrimSpace(userPrompt))
+	if strings.HasPrefix(lower, "errands") {
+		return true
+	}
+	return strings.HasPrefix(lower, "i need ") &&
+		(strings.Contains(lower, " on the way") || strings.Contains(lower, " on my way"))
+}
+
+// journeyDestination returns the destination of a public transport question,
+// or "" if the question isn't one: "bus to X", "how do I get to X by tube"
+func journeyDestination(userPrompt string) string {
+	userPrompt = strings.TrimSpace(userPrompt)
+	lower := strings.ToLower(userPrompt)
+
+	// "step-free tube to bank" - kept on the destination for command.Journey
+	stepFree := ""
+	for _, prefix := range []string{"step-free ", "step free "} {
+		if strings.HasPrefix(lower, prefix) {
+			stepFree = "step-free "
+			userPrompt, lower = userPrompt[len(prefix):], lower[len(prefix):]
+			break
+		}
+	}
+
+	dest := ""
+	for _, prefix := range []string{"bus to ", "tube to ", "train to ", "transit to ", "public transport to "} {
+		if strings.HasPrefix(lower, prefix) {
+			dest = userPrompt[len(prefix):]
+			break
+		}
+	}
+	if dest == "" {
+		for _, suffix := range []string{" by bus", " by tube", " by train", " by rail", " on the bus", " on the tube", " by public transport", " on public transport", " by transit"} {
+			idx := strings.LastIndex(lower, suffix)
+			if idx < 0 {
+				continue
+			}
+			start := strings.LastIndex(lower[:idx], " to ")
+			if start < 0 {
+				continue
+			}
+			dest = userPrompt[start+len(" to ") : idx]
+			break
+		}
+	}
+	dest = strings.TrimSpace(strings.TrimRight(dest, "?!. "))
+	dest = strings.TrimPrefix(dest, "the ")
+	if dest == "" {
+		return ""
+	}
+	return stepFree + dest
+}
+
 func selectTool(userPrompt string) (*ToolDecision, error) {
 	// If it's a location/context question, use direct response (none tool)
 	if isContextQuestion(userPrompt) {
@@ -453,23 +643,36 @@ func selectTool(userPrompt string) (*ToolDecision, error) {
 	}
 	log.Printf("[tool] isContextQuestion=false for %q - will ask LLM", userPrompt)
 
+	if isErrandRun(userPrompt) {
+		log.Printf("[tool] Detected errand run")
+		return &ToolDecision{Tool: "errands", Args: map[string]interface{}{"errands": userPrompt}}, nil
+	}
+
+	// Public transport before walking - "how do I get to X by bus" is a journey
+	if dest := journeyDestination(userPrompt); dest != "" {
+		log.Printf("[tool] Detected journey question, dest=%q", dest)
+		return &ToolDecision{Tool: "journey", Args: map[string]interface{}{"destination": dest}}, nil
+	}
+
 	// Check for directions keywords first - LLM often gets this wrong
 	lower := strings.ToLower(userPrompt)
 	if strings.Contains(lower, "how do i get to") || strings.Contains(lower, "directions to") ||
 		strings.Contains(lower, "walk to") || strings.Contains(lower, "way to the") ||
-		strings.Contains(lower, "route to") || (strings.Contains(lower, "get to") && strings.Contains(lower, "how")) {
+		strings.Contains(lower, "route to") || (strings.Contains(lower, "get to") && strings.Contains(lower, "how")) ||
+		strings.Contains(lower, "cycle to") || strings.Contains(lower, "bike to") || strings.Contains(lower, "drive to") {
 		// Extract destination
 		dest := userPrompt
-		for _, prefix := range []string{"how do i get to ", "how do I get to ", "directions to ", "walk to ", "way to ", "route to ", "get to "} {
+		for _, prefix := range []string{"how do i get to ", "how do I get to ", "directions to ", "walk to ", "way to ", "route to ", "cycle to ", "bike to ", "drive to ", "get to "} {
 			if idx := strings.Index(lower, prefix); idx >= 0 {
 				dest = userPrompt[idx+len(prefix):]
 				break
 			}
 		}
 		dest = strings.TrimSuffix(dest, "?")
-		dest = strings.TrimSpace(dest)
-		log.Printf("[tool] Detected directions question, dest=%q", dest)
-		return &ToolDecision{Tool: "directions", Args: map[string]interface{}{"destination": dest}}, nil
+		dest = trimTravelMode(strings.TrimSpace(dest))
+		mode := travelMode(lower)
+		log.Printf("[tool] Detected directions question, dest=%q mode=%q", dest, mode)
+		return &ToolDecision{Tool: "directions", Args: map[string]interface{}{"destination": dest, "mode": mode}}, nil
 	}
 
 	// Build tool selection prompt as user message (Fanar ignores system prompts for this)
@@ -481,7 +684,9 @@ Available tools:
 - news: news headlines or search news
 - video: search for videos (tutorials, music, documentaries, etc)
 - nearby: find places near user (bowling, cinema, gym, hotel, any place type)
-- directions: walking directions to a place (how do I get to X, directions to X)
+- directions: walking, cycling or driving directions to a place (how do I get to X, directions to X, cycle to X)
+- journey: bus, tube or train journey to a place (bus to X, get to X by tube)
+- errands: one walk through several stops (I need X and Y on the way home)
 - none: general questions, math, coding, conversation
 
 Respond ONLY with JSON: {"tool": "name", "args": {"key": "value"}}
@@ -494,6 +699,8 @@ Examples:
 - find a cinema -> {"tool": "nearby", "args": {"type": "cinema"}}
 - gyms nearby -> {"tool": "nearby", "args": {"type": "gym"}}
 - how do I get to the station -> {"tool": "directions", "args": {"destination": "station"}}
+- train to brighton -> {"tool": "journey", "args": {"destination": "brighton"}}
+- I need cash and a bakery on the way home -> {"tool": "errands", "args": {"errands": "cash, bakery", "end": "home"}}
 - hello -> {"tool": "none", "args": {}}
 - what is 2+2 -> {"tool": "none", "args": {}}`
 
@@ -648,3 +855,64 @@ func recordLLMCall(err error) {
 		stats.RecordSuccess("fanar")
 	}
 }
+
+// PlanInstruction asks the LLM to turn a standing instruction into tool
+// calls for an agent, falling back to keyword planning
+func PlanInstruction(prompt string) ([]spatial.InstructionCall, time.Duration) {
+	if Client == nil {
+		return spatial.KeywordPlan(prompt)
+	}
+
+	planPrompt := `An agent at a fixed place keeps checking this for its area: "` + prompt + `"
+
+Pick the searches it should repeat. Tools:
+- news: search headlines (query: a short phrase likely to appear in a headline)
+- web: search the web (query)
+- nearby: places near the agent (query: place type or name)
+- disruptions: road closures and roadworks near the agent (query: road like A316, or empty for all)
+
+Respond ONLY with JSON: {"calls": [{"tool": "name", "query": "..."}], "every_mins": 60}
+Examples:
+- track the Hampton Court car boot sale -> {"calls": [{"tool": "news", "query": "car boot"}, {"tool": "web", "query": "Hampton Court car boot sale"}], "every_mins": 360}
+- watch for road closures on the A316 -> {"calls": [{"tool": "disruptions", "query": "A316"}], "every_mins": 30}`
+
+	resp, err := Client.CreateChatCompletion(
+		context.Background(),
+		openai.ChatCompletionRequest{
+			Model: ModelName,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleUser, Content: planPrompt},
+			},
+			MaxTokens: 150,
+		},
+	)
+	if err != nil || len(resp.Choices) == 0 {
+		log.Printf("[agent] Planning %q: %v, using keywords", prompt, err)
+		return spatial.KeywordPlan(prompt)
+	}
+
+	content := resp.Choices[0].Message.Content
+	var plan struct {
+		Calls     []spatial.InstructionCall `json:"calls"`
+		EveryMins int                       `json:"every_mins"`
+	}
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start >= 0 && end > start {
+		json.Unmarshal([]byte(content[start:end+1]), &plan)
+	}
+
+	var calls []spatial.InstructionCall
+	for _, c := range plan.Calls {
+		switch c.Tool {
+		case "news", "web", "nearby", "disruptions":
+			calls = append(calls, c)
+		}
+	}
+	if len(calls) == 0 {
+		log.Printf("[agent] Planning %q: no usable calls in %q, using keywords", prompt, content)
+		return spatial.KeywordPlan(prompt)
+	}
+	log.Printf("[agent] Planned %q: %v every %dm", prompt, calls, plan.EveryMins)
+	return calls, time.Duration(plan.EveryMins) * time.Minute
+}
@@ -0,0 +1,28 @@
+package agent
+
+import "testing"
+
+// TestTravelMode checks only travel phrases pick a mode, so places named
+// after bikes or cars still get walking directions
+func TestTravelMode(t *testing.T) {
+	testCases := []struct {
+		question string
+		mode     string
+	}{
+		{"how do i get to the recycling centre", ""},
+		{"directions to the bike shop", ""},
+		{"how do i get to the car park", ""},
+		{"walk to the cycle hire", ""},
+		{"cycle to richmond park", "cycle"},
+		{"how do i get to kingston by bike", "cycle"},
+		{"drive to the car park", "drive"},
+		{"how do i get to heathrow by car", "drive"},
+		{"step-free route to waterloo", "wheelchair"},
+		{"wheelchair route to the library", "wheelchair"},
+	}
+	for _, tc := range testCases {
+		if got := travelMode(tc.question); got != tc.mode {
+			t.Errorf("travelMode(%q) = %q, want %q", tc.question, got, tc.mode)
+		}
+	}
+}
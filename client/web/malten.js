@@ -1546,7 +1546,20 @@ function submitCommand() {
         data.lat = state.lat;
         data.lon = state.lon;
     }
-    
+
+    // Errands "on the way home" - saved places only live here, so send where they are
+    var errandEnd = prompt.match(/(?:on (?:the|my) way(?: back)?(?: to)?|then(?: to)?|ending at)\s+(.+?)[?!. ]*$/i);
+    if (errandEnd && prompt.match(/^\/?errands|^i need /i)) {
+        var endName = errandEnd[1].replace(/^the /i, '').toLowerCase();
+        for (var name in state.savedPlaces || {}) {
+            if (name.toLowerCase() === endName) {
+                data.toLat = state.savedPlaces[name].lat;
+                data.toLon = state.savedPlaces[name].lon;
+                break;
+            }
+        }
+    }
+
     // Show user's message and loading indicator
     displayUserMessage(prompt);
     showLoading();
@@ -32,6 +32,9 @@ func Agents() string {
 	lines = append(lines, fmt.Sprintf("🤖 %d agents", len(agents)))
 	lines = append(lines, "")
 
+	sched := spatial.AgentScheduler()
+	var saved int
+
 	for _, agent := range agents {
 		status := "idle"
 		if agentData := agent.GetAgentData(); agentData != nil && agentData.Status != "" {
@@ -49,12 +52,28 @@ func Agents() string {
 		arrivals := countEntitiesByAgent(db, agentID, spatial.EntityArrival)
 
 		line := fmt.Sprintf("%s - %s %s", agent.Name, status, agoStr)
+		if mode := spatial.AgentMode(agent); mode != status {
+			line += " · " + mode
+		}
+		if health := spatial.GetAgentHealth(agent); health.Status != spatial.HealthOK && health.Reason != "" {
+			line += fmt.Sprintf("\n  ⚠️ %s: %s", health.Status, health.Reason)
+		}
 		if places > 0 || arrivals > 0 {
 			line += fmt.Sprintf("\n  📍 %d places, 🚏 %d stops", places, arrivals)
 		}
+		for _, in := range spatial.AgentInstructions(agent) {
+			line += fmt.Sprintf("\n  📝 %s (every %dm)", in.Prompt, in.EveryMins)
+		}
+		if n := sched.CallsSaved(agent.ID); n > 0 {
+			line += fmt.Sprintf("\n  💤 %d API calls saved", n)
+			saved += n
+		}
 		lines = append(lines, line)
 	}
 
+	if saved > 0 {
+		lines = append(lines, "", fmt.Sprintf("💤 %d API calls saved by idle agents", saved))
+	}
 	return strings.Join(lines, "\n")
 }
 
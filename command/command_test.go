@@ -0,0 +1,87 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"malten.ai/spatial"
+)
+
+// TestParseWithin checks travel time limits come off nearby queries, with a
+// bare m read as metres rather than minutes
+func TestParseWithin(t *testing.T) {
+	testCases := []struct {
+		input   string
+		rest    string
+		minutes int
+		profile *spatial.Profile
+	}{
+		{"cafe within 10 min walk", "cafe", 10, spatial.ProfileFoot},
+		{"pharmacy within 15 minutes by bike", "pharmacy", 15, spatial.ProfileCycle},
+		{"cafe within 500m", "cafe", 6, spatial.ProfileFoot},
+		{"cafe within 2 km cycle", "cafe", 8, spatial.ProfileCycle},
+		{"cafe within 90 mins", "cafe", 90, spatial.ProfileFoot},
+		{"cafe near the station", "cafe near the station", 0, nil},
+	}
+	for _, tc := range testCases {
+		rest, minutes, profile := parseWithin(tc.input)
+		if rest != tc.rest || minutes != tc.minutes || profile != tc.profile {
+			t.Errorf("parseWithin(%q) = %q, %d, %v", tc.input, rest, minutes, profile)
+		}
+	}
+
+	// Too long a budget gets the usage, not a 42 km search
+	if out, _ := handleNearby(&Context{}, []string{"cafe", "within", "90", "mins"}); out[:6] != "Usage:" {
+		t.Errorf("90 min nearby = %q", out)
+	}
+}
+
+// TestMatchReach checks reach questions need minutes, not metres
+func TestMatchReach(t *testing.T) {
+	if ok, args := matchReach("What can I walk to in 10 minutes?"); !ok || !reflect.DeepEqual(args, []string{"10", "walk"}) {
+		t.Errorf("minutes: %v %v", ok, args)
+	}
+	if ok, _ := matchReach("what can I walk to in 500m"); ok {
+		t.Error("500m matched as minutes")
+	}
+}
+
+// TestSplitTravelMode checks a leading travel mode comes off a destination
+func TestSplitTravelMode(t *testing.T) {
+	testCases := []struct {
+		input   string
+		profile *spatial.Profile
+		dest    string
+	}{
+		{"cycle to Camden Market", spatial.ProfileCycle, "Camden Market"},
+		{"step-free route to King's Cross", spatial.ProfileWheelchair, "King's Cross"},
+		{"drive to Heathrow", spatial.ProfileDrive, "Heathrow"},
+		{"Cycle To İstanbul Café", spatial.ProfileCycle, "İstanbul Café"},
+		{"Bike Shop", spatial.ProfileFoot, "Bike Shop"},
+	}
+	for _, tc := range testCases {
+		profile, dest := splitTravelMode(tc.input)
+		if profile != tc.profile || dest != tc.dest {
+			t.Errorf("splitTravelMode(%q) = %s, %q", tc.input, profile.Name, dest)
+		}
+	}
+}
+
+// TestSplitErrands checks errands and the end point come out of a request
+func TestSplitErrands(t *testing.T) {
+	testCases := []struct {
+		input   string
+		errands []string
+		end     string
+	}{
+		{"pharmacy, cash and a coffee on the way home", []string{"pharmacy", "cash", "coffee"}, "home"},
+		{"errands: post office & supermarket then the station", []string{"post office", "supermarket"}, "station"},
+		{"I need to get some bread", []string{"bread"}, ""},
+	}
+	for _, tc := range testCases {
+		errands, end := splitErrands(tc.input)
+		if !reflect.DeepEqual(errands, tc.errands) || end != tc.end {
+			t.Errorf("splitErrands(%q) = %q, %q", tc.input, errands, end)
+		}
+	}
+}
@@ -64,6 +64,9 @@ func courierStatus() string {
 	sb.WriteString(fmt.Sprintf("\n📊 Stats:\n"))
 	sb.WriteString(fmt.Sprintf("• Trips complete: %v\n", stats["trips_complete"]))
 	sb.WriteString(fmt.Sprintf("• Distance walked: %.1f km\n", stats["km_walked"]))
+	if cov, ok := stats["coverage"].(map[string]interface{}); ok && cov["osrm_calls"].(int) > 0 {
+		sb.WriteString(fmt.Sprintf("• New streets: %.1f km (%.0fm per route)\n", cov["new_km"], cov["new_m_per_call"]))
+	}
 
 	return sb.String()
 }
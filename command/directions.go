@@ -3,39 +3,128 @@ package command
 import (
 	"fmt"
 	"log"
+	"regexp"
 	"strings"
 
 	"malten.ai/spatial"
 )
 
-// Directions handles "how do I get to X" type questions
 // DirectionsTo returns walking directions to a known destination
 func DirectionsTo(destName string, fromLat, fromLon, toLat, toLon float64) (string, error) {
+	return routeTo("", spatial.ProfileFoot, destName, fromLat, fromLon, toLat, toLon, false)
+}
+
+// routeTo routes to a destination and, for a session, watches the route for
+// disruptions. Navigating follows along too, pushing turns as pings near them
+func routeTo(session string, profile *spatial.Profile, destName string, fromLat, fromLon, toLat, toLon float64, navigate bool) (string, error) {
 	if fromLat == 0 && fromLon == 0 {
 		return "📍 Need your location for directions. Enable location?", nil
 	}
 
-	route, err := spatial.GetWalkingDirections(fromLat, fromLon, toLat, toLon)
+	route, err := spatial.GetDirections(fromLat, fromLon, toLat, toLon, profile)
 	if err != nil {
-		return fmt.Sprintf("🚶 Couldn't get directions to %s: %v", destName, err), nil
+		return fmt.Sprintf("%s Couldn't get directions to %s: %v", profile.Icon, destName, err), nil
+	}
+
+	result := fmt.Sprintf("%s %s to %s\n\n%s", profile.Icon, strings.Title(profile.Verb), destName,
+		spatial.FormatDirectionsWithMap(route, fromLat, fromLon, toLat, toLon, destName)) +
+		routeWarnings(session, route) + exportLinks(session, route, destName)
+	switch {
+	case navigate && spatial.StartNavigation(session, route, destName, toLat, toLon) != nil:
+		result += "\n\n" + navigatingNote
+	case session != "":
+		result += "\n\n🧭 /navigate to follow along"
+	}
+	return result, nil
+}
+
+// "cycle to X", "drive to X", "wheelchair route to X", "step-free route to X"
+var travelModeRe = regexp.MustCompile(`(?i)^(walk|walking|cycle|cycling|bike|drive|driving|wheelchair|step[- ]free|pram|buggy|pushchair)(?: route)? to (.+)$`)
+
+// splitTravelMode takes a leading travel mode off a destination
+// "cycle to camden" -> cycling, "camden"; anything else walks
+func splitTravelMode(destination string) (*spatial.Profile, string) {
+	destination = strings.TrimSpace(destination)
+	if m := travelModeRe.FindStringSubmatch(destination); m != nil {
+		return spatial.ProfileFor(m[1]), m[2]
+	}
+	return spatial.ProfileFoot, destination
+}
+
+func matchTravelMode(input string) (bool, []string) {
+	lower := strings.TrimRight(strings.ToLower(strings.TrimSpace(input)), "?!. ")
+	for _, prefix := range []string{"cycle to ", "bike to ", "drive to ", "wheelchair route to ", "step-free route to ", "step free route to ", "pram route to ", "buggy route to "} {
+		if strings.HasPrefix(lower, prefix) && len(lower) > len(prefix) {
+			return true, strings.Fields(lower)
+		}
+	}
+	return false, nil
+}
+
+// routeWarnings lists road disruptions along the route, if any
+// The route is remembered for the session so later disruptions get pushed
+func routeWarnings(session string, route *spatial.Route) string {
+	disruptions := spatial.Get().DisruptionsAlongRoute(route.Geometry, spatial.RouteDisruptionBuffer)
+	spatial.SetSessionRoute(session, route.Geometry, disruptions)
+	lines := spatial.FormatRouteDisruptions(disruptions)
+	if len(lines) == 0 {
+		return ""
+	}
+	if len(lines) > 3 {
+		lines = lines[:3]
+	}
+	return "\n\n" + strings.Join(lines, "\n")
+}
+
+// exportLinks keeps the route for the session and links to it as GPX and GeoJSON
+func exportLinks(session string, route *spatial.Route, destName string) string {
+	if session == "" {
+		return ""
 	}
+	spatial.RememberRoute(session, route, destName)
+	return "\n\n📤 Export route: " + exportAnchors("/routes/me", "")
+}
 
-	return fmt.Sprintf("🚶 Walking to %s\n\n%s", destName,
-		spatial.FormatDirectionsWithMap(route, fromLat, fromLon, toLat, toLon, destName)), nil
+// exportAnchors links to both download formats of an export path
+func exportAnchors(base, query string) string {
+	return fmt.Sprintf(`<a href="%s.gpx%s" target="_blank" class="map-link">GPX</a> · <a href="%s.geojson%s" target="_blank" class="map-link">GeoJSON</a>`,
+		base, query, base, query)
 }
 
+// Directions handles "how do I get to X" type questions
 func Directions(destination string, fromLat, fromLon float64) (string, error) {
+	return directionsFor("", spatial.ProfileFoot, destination, fromLat, fromLon, false)
+}
+
+// DirectionsBy returns directions for a travel mode: walk, cycle, drive or wheelchair
+func DirectionsBy(mode, destination string, fromLat, fromLon float64) (string, error) {
+	profile := spatial.ProfileFor(mode)
+	if profile == nil {
+		return fmt.Sprintf("Unknown travel mode %q. Try walk, cycle, drive or wheelchair", mode), nil
+	}
+	return directionsFor("", profile, destination, fromLat, fromLon, false)
+}
+
+func directionsFor(session string, profile *spatial.Profile, destination string, fromLat, fromLon float64, navigate bool) (string, error) {
 	if fromLat == 0 && fromLon == 0 {
 		return "📍 Need your location for directions. Enable location?", nil
 	}
 
+	destName, destLat, destLon, reply := resolveDestination(destination, fromLat, fromLon)
+	if reply != "" {
+		return reply, nil
+	}
+	return routeTo(session, profile, destName, fromLat, fromLon, destLat, destLon, navigate)
+}
+
+// resolveDestination finds where a named destination is
+// If it can't pick one, reply says why (not found, or which one did you mean)
+func resolveDestination(destination string, fromLat, fromLon float64) (destName string, destLat, destLon float64, reply string) {
 	// Clean up destination
 	destination = strings.TrimSpace(destination)
 	destination = strings.TrimPrefix(destination, "to ")
 	destination = strings.TrimPrefix(destination, "the ")
 
-	var destLat, destLon float64
-	var destName string
 	db := spatial.Get()
 
 	// Handle generic terms - find nearest from spatial DB
@@ -76,19 +165,33 @@ func Directions(destination string, fromLat, fromLon float64) (string, error) {
 		}
 	}
 
-	// Try our quadtree first - we may have this place indexed
-	if destLat == 0 {
-		log.Printf("[directions] searching quadtree for %q", destination)
-		results := db.QueryByNameContains(fromLat, fromLon, 5000, destination)
-		for _, r := range results {
-			// Skip arrival entities (bus times) - they may have user's location not stop's
-			if r.Type == spatial.EntityArrival {
-				continue
+	// A reply to did-you-mean names where: "Tesco Express, Hampton Hill"
+	if i := strings.LastIndex(destination, ", "); destLat == 0 && i > 0 {
+		name, where := destination[:i], destination[i+2:]
+		for _, c := range spatial.GeocodeLocal(name, fromLat, fromLon, 5) {
+			if c.Confidence < spatial.GeocodeConfident {
+				break
 			}
-			log.Printf("[directions] found in quadtree: %s (type=%s) at %.4f,%.4f", r.Name, r.Type, r.Lat, r.Lon)
-			destLat, destLon = r.Lat, r.Lon
-			destName = r.Name
-			break
+			if addr := spatial.ReverseGeocodeLocal(c.Lat, c.Lon); addr != nil && addressMatches(addr, where) {
+				log.Printf("[directions] chose %s in %s at %.4f,%.4f", c.Name, where, c.Lat, c.Lon)
+				destName, destLat, destLon = c.Name, c.Lat, c.Lon
+				break
+			}
+		}
+	}
+
+	// Try our index first - places, stations, streets and postcodes we know about
+	if destLat == 0 {
+		candidates := spatial.GeocodeLocal(destination, fromLat, fromLon, 3)
+		log.Printf("[directions] %d local candidates for %q", len(candidates), destination)
+		if spatial.IsAmbiguous(candidates) {
+			return "", 0, 0, didYouMean(destination, candidates)
+		}
+		if len(candidates) > 0 && candidates[0].Confidence >= spatial.GeocodeConfident {
+			c := candidates[0]
+			log.Printf("[directions] local match: %s (%s, %.2f) at %.4f,%.4f", c.Name, c.Kind, c.Confidence, c.Lat, c.Lon)
+			destLat, destLon = c.Lat, c.Lon
+			destName = c.Name
 		}
 	}
 
@@ -113,39 +216,162 @@ func Directions(destination string, fromLat, fromLon float64) (string, error) {
 	}
 
 	if destLat == 0 {
-		return fmt.Sprintf("📍 Couldn't find '%s'. Try being more specific?", destination), nil
+		return "", 0, 0, fmt.Sprintf("📍 Couldn't find '%s'. Try being more specific?", destination)
 	}
+	return destName, destLat, destLon, ""
+}
 
-	// Get walking directions
-	route, err := spatial.GetWalkingDirections(fromLat, fromLon, destLat, destLon)
-	if err != nil {
-		return fmt.Sprintf("🚶 Couldn't get directions to %s: %v", destName, err), nil
+// didYouMean lists the good candidates so the user can pick one, each with
+// where it is so same-named places can be told apart
+func didYouMean(destination string, candidates []spatial.GeocodeCandidate) string {
+	var good []spatial.GeocodeCandidate
+	var addrs []*spatial.Address
+	for _, c := range candidates {
+		if c.Confidence >= spatial.GeocodeConfident {
+			good = append(good, c)
+			addrs = append(addrs, spatial.ReverseGeocodeLocal(c.Lat, c.Lon))
+		}
 	}
 
-	result := fmt.Sprintf("🚶 Walking to %s\n\n%s", destName,
-		spatial.FormatDirectionsWithMap(route, fromLat, fromLon, destLat, destLon, destName))
-	return result, nil
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🤔 Which %s did you mean?\n", destination))
+	example := ""
+	for i, c := range good {
+		name := c.Name
+		if where := distinguishingPlace(i, good, addrs); where != "" {
+			name += ", " + where
+		}
+		if example == "" {
+			example = name
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. %s (%s, %s)", i+1, name, c.Kind, spatial.FormatDistance(c.Distance)))
+	}
+	sb.WriteString(fmt.Sprintf("\n\nReply /directions %s to choose", example))
+	return sb.String()
+}
+
+// distinguishingPlace names where candidate i is - its area, street or
+// postcode, whichever no other candidate of the same name shares
+func distinguishingPlace(i int, candidates []spatial.GeocodeCandidate, addrs []*spatial.Address) string {
+	if addrs[i] == nil {
+		return ""
+	}
+	fields := func(a *spatial.Address) []string {
+		if a == nil {
+			return []string{"", "", ""}
+		}
+		return []string{a.Area, a.Street, a.Postcode}
+	}
+	mine := fields(addrs[i])
+	for f, where := range mine {
+		if where == "" {
+			continue
+		}
+		unique := true
+		for j, c := range candidates {
+			if j != i && strings.EqualFold(c.Name, candidates[i].Name) && strings.EqualFold(fields(addrs[j])[f], where) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return where
+		}
+	}
+	return ""
+}
+
+// addressMatches reports whether where names the address's area, street or postcode
+func addressMatches(addr *spatial.Address, where string) bool {
+	for _, name := range []string{addr.Area, addr.Street, addr.Postcode} {
+		if name != "" && strings.EqualFold(name, strings.TrimSpace(where)) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleNavigate starts, shows or stops the session's navigation
+// "/navigate to X" routes there and follows along; a bare /navigate follows
+// the last route asked for, or shows progress when already navigating
+func handleNavigate(ctx *Context, args []string) (string, error) {
+	if len(args) > 0 && (args[0] == "stop" || args[0] == "end" || args[0] == "cancel") {
+		if spatial.StopNavigation(ctx.Session) {
+			return "🧭 Navigation stopped", nil
+		}
+		return "🧭 Not navigating", nil
+	}
+	if !ctx.HasLocation() {
+		return "📍 Need your location to navigate. Enable location?", nil
+	}
+
+	if len(args) > 0 {
+		destination := strings.Join(args, " ")
+		if strings.HasPrefix(strings.ToLower(destination), "to ") {
+			destination = destination[len("to "):]
+		}
+		profile, destination := splitTravelMode(destination)
+		if ctx.ToLat != 0 && ctx.ToLon != 0 {
+			return routeTo(ctx.Session, profile, destination, ctx.Lat, ctx.Lon, ctx.ToLat, ctx.ToLon, true)
+		}
+		return directionsFor(ctx.Session, profile, destination, ctx.Lat, ctx.Lon, true)
+	}
+
+	if nav := spatial.GetNavigation(ctx.Session); nav != nil {
+		return spatial.FormatNavigation(nav, ctx.Lat, ctx.Lon), nil
+	}
+	route, destName := spatial.SessionRoute(ctx.Session)
+	if route == nil {
+		return "🧭 Not navigating. /navigate to <place> to start", nil
+	}
+	end := route.Geometry[len(route.Geometry)-1]
+	nav := spatial.StartNavigation(ctx.Session, route, destName, end[1], end[0])
+	return spatial.FormatNavigation(nav, ctx.Lat, ctx.Lon) + "\n\n" + navigatingNote, nil
+}
+
+// Follow along: turns are pushed as the user's pings approach them
+const navigatingNote = "🧭 I'll send each turn as you go. /navigate stop to end"
+
+// "navigate to X", "navigate me to X"
+func matchNavigate(input string) (bool, []string) {
+	lower := strings.TrimRight(strings.ToLower(strings.TrimSpace(input)), "?!. ")
+	for _, prefix := range []string{"navigate to ", "navigate me to "} {
+		if strings.HasPrefix(lower, prefix) && len(lower) > len(prefix) {
+			return true, strings.Fields(lower[len(prefix):])
+		}
+	}
+	return false, nil
 }
 
 func init() {
+	Register(&Command{
+		Name:        "navigate",
+		Description: "Turn-by-turn navigation: start, show progress or stop",
+		Usage:       "/navigate [to <place>|stop]",
+		Emoji:       "🧭",
+		Match:       matchNavigate,
+		Handler:     handleNavigate,
+	})
+
 	Register(&Command{
 		Name:        "directions",
-		Description: "Get walking directions to a place",
-		Usage:       "/directions <place name>",
+		Description: "Get walking, cycling, driving or wheelchair directions to a place",
+		Usage:       "/directions [cycle|drive|wheelchair|step-free to] <place name>",
 		Emoji:       "🚶",
 		LoadingText: "Getting directions to %s...",
+		Match:       matchTravelMode,
 		Handler: func(ctx *Context, args []string) (string, error) {
 			if len(args) == 0 {
-				return "Usage: /directions <place name>", nil
+				return "Usage: /directions [cycle|drive|wheelchair|step-free to] <place name>", nil
 			}
-			destination := strings.Join(args, " ")
+			profile, destination := splitTravelMode(strings.Join(args, " "))
 
 			// If destination coords provided, use them directly
 			if ctx.ToLat != 0 && ctx.ToLon != 0 {
-				return DirectionsTo(destination, ctx.Lat, ctx.Lon, ctx.ToLat, ctx.ToLon)
+				return routeTo(ctx.Session, profile, destination, ctx.Lat, ctx.Lon, ctx.ToLat, ctx.ToLon, false)
 			}
 
-			return Directions(destination, ctx.Lat, ctx.Lon)
+			return directionsFor(ctx.Session, profile, destination, ctx.Lat, ctx.Lon, false)
 		},
 	})
 }
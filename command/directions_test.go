@@ -0,0 +1,68 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"malten.ai/spatial"
+)
+
+// testNavStreet indexes a 1 km street running north from (56.2, -4.3)
+func testNavStreet(t *testing.T) {
+	id := "directions-test-street"
+	spatial.Get().Insert(&spatial.Entity{
+		ID: id, Type: spatial.EntityStreet, Name: "Loch Road",
+		Lat: 56.2045, Lon: -4.3,
+		Data: &spatial.StreetData{Points: [][]float64{{-4.3, 56.2}, {-4.3, 56.209}}, Length: 1000},
+	})
+	t.Cleanup(func() { spatial.Get().Delete(id) })
+}
+
+// TestDirectionsDoNotNavigate checks asking for directions only suggests following along
+func TestDirectionsDoNotNavigate(t *testing.T) {
+	testNavStreet(t)
+	session := "directions-test-directions"
+	t.Cleanup(func() { spatial.StopNavigation(session) })
+	out, _ := routeTo(session, spatial.ProfileFoot, "the pier", 56.2, -4.3, 56.209, -4.3, false)
+	if spatial.GetNavigation(session) != nil {
+		t.Errorf("directions started navigation:\n%s", out)
+	}
+}
+
+// TestNavigateTo checks /navigate to a place routes there and follows along
+func TestNavigateTo(t *testing.T) {
+	testNavStreet(t)
+	session := "directions-test-navigate"
+	t.Cleanup(func() { spatial.StopNavigation(session) })
+	ctx := &Context{Session: session, Lat: 56.2, Lon: -4.3, ToLat: 56.209, ToLon: -4.3}
+	out, _ := handleNavigate(ctx, []string{"to", "the", "pier"})
+	if nav := spatial.GetNavigation(session); nav == nil || nav.DestName != "the pier" {
+		t.Errorf("navigation not started:\n%s", out)
+	}
+}
+
+// TestNavigateLastRoute checks a bare /navigate follows the last route asked for
+func TestNavigateLastRoute(t *testing.T) {
+	testNavStreet(t)
+	session := "directions-test-last"
+	t.Cleanup(func() { spatial.StopNavigation(session) })
+	ctx := &Context{Session: session, Lat: 56.2, Lon: -4.3}
+	if out, _ := handleNavigate(ctx, nil); spatial.GetNavigation(session) != nil {
+		t.Fatalf("navigating with no route:\n%s", out)
+	}
+	routeTo(session, spatial.ProfileFoot, "the pier", 56.2, -4.3, 56.209, -4.3, false)
+	out, _ := handleNavigate(ctx, nil)
+	if nav := spatial.GetNavigation(session); nav == nil || nav.DestName != "the pier" {
+		t.Errorf("navigation not started:\n%s", out)
+	}
+}
+
+// TestMatchNavigate checks "navigate to X" asks for navigation
+func TestMatchNavigate(t *testing.T) {
+	if ok, args := matchNavigate("Navigate me to Camden Market?"); !ok || !reflect.DeepEqual(args, []string{"camden", "market"}) {
+		t.Errorf("navigate me to: %v, %v", ok, args)
+	}
+	if ok, _ := matchNavigate("navigate"); ok {
+		t.Error("bare navigate matched")
+	}
+}
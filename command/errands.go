@@ -0,0 +1,194 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"malten.ai/spatial"
+)
+
+// Errand words that aren't place types themselves
+var errandAliases = map[string]string{
+	"cash":      "atm",
+	"cashpoint": "atm",
+	"chemist":   "pharmacy",
+	"medicine":  "pharmacy",
+	"groceries": "supermarket",
+	"shopping":  "supermarket",
+	"coffee":    "cafe",
+	"petrol":    "fuel",
+}
+
+const errandRadius = 1500.0 // How far from the way to look for each errand
+
+// "... on the way home", "... on my way to the station", "... then king's cross"
+var errandEndRe = regexp.MustCompile(`\s+(?:on (?:the|my) way(?: back)?(?: to)?|then(?: to)?|ending at|and end at)\s+(.+)$`)
+
+// splitErrands pulls the errands and optional end point out of a request
+// "pharmacy, cash and a coffee on the way home" -> [pharmacy cash coffee], "home"
+func splitErrands(input string) ([]string, string) {
+	input = strings.TrimRight(strings.ToLower(strings.TrimSpace(input)), "?!. ")
+	for _, prefix := range []string{"errands:", "errands", "i need to", "i need", "need to", "get"} {
+		input = strings.TrimSpace(strings.TrimPrefix(input, prefix))
+	}
+
+	var end string
+	if m := errandEndRe.FindStringSubmatchIndex(input); m != nil {
+		end = strings.TrimPrefix(input[m[2]:m[3]], "the ")
+		input = input[:m[0]]
+	}
+
+	input = strings.NewReplacer(" and ", ",", "&", ",", "+", ",").Replace(input)
+	var errands []string
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		for _, article := range []string{"a ", "an ", "some ", "the ", "get ", "visit "} {
+			part = strings.TrimPrefix(part, article)
+		}
+		if part != "" {
+			errands = append(errands, part)
+		}
+	}
+	return errands, end
+}
+
+// errandCategory maps an errand to a place category, "" for a name search
+func errandCategory(errand string) string {
+	if cat, ok := errandAliases[errand]; ok {
+		return cat
+	}
+	if cat, ok := MultiWordTypes[errand]; ok {
+		return cat
+	}
+	if IsValidPlaceType(errand) {
+		return normalizeType(errand)
+	}
+	return ""
+}
+
+// errandCandidates finds places that could do an errand, favouring those on the way
+// endLat/endLon are 0 when the run ends at the last stop
+func errandCandidates(errand string, fromLat, fromLon, endLat, endLon float64) []*spatial.Entity {
+	db := spatial.Get()
+
+	// Search around the whole way, not just the start
+	lat, lon, radius := fromLat, fromLon, errandRadius
+	hasEnd := endLat != 0 || endLon != 0
+	if hasEnd {
+		lat, lon = (fromLat+endLat)/2, (fromLon+endLon)/2
+		radius += haversineMeters(fromLat, fromLon, endLat, endLon) / 2
+	}
+
+	var places []*spatial.Entity
+	if cat := errandCategory(errand); cat != "" {
+		places = db.QueryPlaces(lat, lon, radius, cat, 30)
+	} else {
+		places = db.FindByName(lat, lon, radius, errand, 30)
+	}
+
+	cost := func(e *spatial.Entity) float64 {
+		d := haversineMeters(fromLat, fromLon, e.Lat, e.Lon)
+		if hasEnd {
+			d += haversineMeters(e.Lat, e.Lon, endLat, endLon)
+		}
+		return d
+	}
+	sort.Slice(places, func(i, j int) bool {
+		return cost(places[i]) < cost(places[j])
+	})
+	if len(places) > spatial.ErrandCandidates {
+		places = places[:spatial.ErrandCandidates]
+	}
+	return places
+}
+
+// Errands plans a walking run for errands typed as text, optionally ending somewhere
+func Errands(session, input string, fromLat, fromLon float64) (string, error) {
+	return errandsTo(session, input, fromLat, fromLon, 0, 0)
+}
+
+// errandsTo plans a run; toLat/toLon are the end point if the client already knows it
+func errandsTo(session, input string, fromLat, fromLon, toLat, toLon float64) (string, error) {
+	if fromLat == 0 && fromLon == 0 {
+		return "📍 Need your location to plan errands. Enable location?", nil
+	}
+	names, endName := splitErrands(input)
+	if len(names) == 0 {
+		return "Usage: /errands <things to get>[ on the way to <place>]", nil
+	}
+	if len(names) > spatial.MaxErrands {
+		return fmt.Sprintf("🛒 That's a lot of errands. I can plan up to %d at a time", spatial.MaxErrands), nil
+	}
+
+	// Where the run ends, if anywhere
+	endLat, endLon := toLat, toLon
+	if endName != "" && endLat == 0 && endLon == 0 {
+		if endName == "home" || endName == "work" {
+			return fmt.Sprintf("🛒 I don't know where %s is. Save it as a place, or ask for errands on the way to an address", endName), nil
+		}
+		var reply string
+		endName, endLat, endLon, reply = resolveDestination(endName, fromLat, fromLon)
+		if reply != "" {
+			return reply, nil
+		}
+	}
+
+	spatial.Get().FindOrCreateAgent(fromLat, fromLon)
+	errands := make([]spatial.Errand, len(names))
+	for i, name := range names {
+		errands[i] = spatial.Errand{Name: name, Candidates: errandCandidates(name, fromLat, fromLon, endLat, endLon)}
+	}
+
+	plan, err := spatial.PlanErrands(fromLat, fromLon, errands, endName, endLat, endLon)
+	if plan == nil || len(plan.Stops) == 0 {
+		return fmt.Sprintf("🛒 %v", err), nil
+	}
+	if err != nil {
+		return fmt.Sprintf("🛒 Couldn't route the run: %v\n\n%s", err, spatial.FormatErrands(plan, fromLat, fromLon)), nil
+	}
+
+	// /navigate follows the run to wherever it finishes
+	destName := plan.Stops[len(plan.Stops)-1].Place.Name
+	if plan.HasEnd() {
+		destName = plan.EndName
+	}
+
+	result := "🛒 Errand run\n\n" + spatial.FormatErrands(plan, fromLat, fromLon) +
+		routeWarnings(session, plan.Route) + exportLinks(session, plan.Route, destName)
+	if session != "" {
+		result += "\n\n🧭 /navigate to follow along"
+	}
+	return result, nil
+}
+
+// "i need a pharmacy and cash on the way home", "errands: post office, bakery"
+func matchErrands(input string) (bool, []string) {
+	lower := strings.ToLower(strings.TrimSpace(input))
+	if strings.HasPrefix(lower, "errands:") {
+		return true, strings.Fields(lower)
+	}
+	if strings.HasPrefix(lower, "i need ") && errandEndRe.MatchString(lower) &&
+		(strings.Contains(lower, " on the way") || strings.Contains(lower, " on my way")) {
+		return true, strings.Fields(lower)
+	}
+	return false, nil
+}
+
+func init() {
+	Register(&Command{
+		Name:        "errands",
+		Description: "Plan a walk through several errands, optionally on the way somewhere",
+		Usage:       "/errands <pharmacy, cash, bakery>[ on the way to <place>]",
+		Emoji:       "🛒",
+		LoadingText: "Planning your errands...",
+		Match:       matchErrands,
+		Handler: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return "Usage: /errands <pharmacy, cash, bakery>[ on the way to <place>]", nil
+			}
+			return errandsTo(ctx.Session, strings.Join(args, " "), ctx.Lat, ctx.Lon, ctx.ToLat, ctx.ToLon)
+		},
+	})
+}
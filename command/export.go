@@ -0,0 +1,51 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"malten.ai/spatial"
+)
+
+// handleExport links to downloads of the session's recorded track and latest route
+func handleExport(ctx *Context, args []string) (string, error) {
+	what := ""
+	if len(args) > 0 {
+		what = strings.ToLower(args[0])
+	}
+
+	var lines []string
+	if what == "" || what == "track" {
+		if segments := spatial.SessionTrack(ctx.Session, 0); len(segments) > 0 {
+			points := 0
+			for _, seg := range segments {
+				points += len(seg)
+			}
+			lines = append(lines, fmt.Sprintf("👣 Your track (%d points): %s", points, exportAnchors("/tracks/me", "")),
+				"   Simplified to 10 m: "+exportAnchors("/tracks/me", "?simplify=10"))
+		} else if what == "track" {
+			lines = append(lines, "👣 No track recorded yet. Turn on location and move about")
+		}
+	}
+	if what == "" || what == "route" {
+		if route, name := spatial.SessionRoute(ctx.Session); route != nil {
+			lines = append(lines, "🧭 Route to "+name+": "+exportAnchors("/routes/me", ""))
+		} else if what == "route" {
+			lines = append(lines, "🧭 No route yet. Ask for /directions first")
+		}
+	}
+	if len(lines) == 0 {
+		return "📤 Nothing to export yet. Your track records as you move, and routes come from /directions", nil
+	}
+	return "📤 Export\n\n" + strings.Join(lines, "\n"), nil
+}
+
+func init() {
+	Register(&Command{
+		Name:        "export",
+		Description: "Download your recorded track or latest route as GPX or GeoJSON",
+		Usage:       "/export [track|route]",
+		Emoji:       "📤",
+		Handler:     handleExport,
+	})
+}
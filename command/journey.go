@@ -0,0 +1,105 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"malten.ai/spatial"
+)
+
+// "bus to camden", "get to kings cross by tube", "how do I get to Brixton on public transport",
+// "step-free tube to bank"
+var (
+	journeyPrefixRe = regexp.MustCompile(`^((?:step[- ]free )?)(?:bus|tube|train|transit) to (.+)$`)
+	journeySuffixRe = regexp.MustCompile(`(?:get|go|travel|journey)(?: me)? to (.+?) (?:by|on|using|via) (?:bus|tube|train|rail|the underground|public transport|transit)$`)
+)
+
+func matchJourney(input string) (bool, []string) {
+	lower := strings.TrimRight(strings.ToLower(strings.TrimSpace(input)), "?!. ")
+	if m := journeyPrefixRe.FindStringSubmatch(lower); m != nil {
+		return true, strings.Fields(m[1] + m[2])
+	}
+	if m := journeySuffixRe.FindStringSubmatch(lower); m != nil {
+		return true, strings.Fields(m[1])
+	}
+	return false, nil
+}
+
+// splitStepFree takes a leading "step-free" off a destination
+func splitStepFree(destination string) (bool, string) {
+	lower := strings.ToLower(destination)
+	for _, prefix := range []string{"step-free ", "step free ", "stepfree ", "accessible "} {
+		if strings.HasPrefix(lower, prefix) {
+			return true, strings.TrimSpace(destination[len(prefix):])
+		}
+	}
+	return false, destination
+}
+
+// JourneyTo plans a public transport journey to a known destination
+// Step-free journeys avoid stations without step-free platform access
+func JourneyTo(session, destName string, fromLat, fromLon, toLat, toLon float64, stepFree bool) (string, error) {
+	if fromLat == 0 && fromLon == 0 {
+		return "📍 Need your location for directions. Enable location?", nil
+	}
+
+	routes, err := spatial.PlanJourney(fromLat, fromLon, toLat, toLon, time.Now(), stepFree)
+	if err != nil {
+		return fmt.Sprintf("🚌 Couldn't plan a journey to %s: %v", destName, err), nil
+	}
+
+	best := routes[0]
+	var sb strings.Builder
+	if stepFree {
+		sb.WriteString(fmt.Sprintf("♿ Step-free to %s\n\n", destName))
+	} else {
+		sb.WriteString(fmt.Sprintf("🧭 To %s\n\n", destName))
+	}
+	sb.WriteString(spatial.FormatDirectionsWithMap(best, fromLat, fromLon, toLat, toLon, destName))
+	if len(routes) > 1 {
+		sb.WriteString("\n\nOther options:")
+		for _, r := range routes[1:] {
+			sb.WriteString("\n• " + spatial.FormatJourneyOption(r))
+		}
+	}
+	return sb.String() + routeWarnings(session, best) + exportLinks(session, best, destName), nil
+}
+
+// Journey plans a public transport journey to a named destination
+// A leading "step-free" asks for a step-free journey
+func Journey(session, destination string, fromLat, fromLon float64) (string, error) {
+	if fromLat == 0 && fromLon == 0 {
+		return "📍 Need your location for directions. Enable location?", nil
+	}
+	stepFree, destination := splitStepFree(destination)
+	destName, destLat, destLon, reply := resolveDestination(destination, fromLat, fromLon)
+	if reply != "" {
+		return reply, nil
+	}
+	return JourneyTo(session, destName, fromLat, fromLon, destLat, destLon, stepFree)
+}
+
+func init() {
+	Register(&Command{
+		Name:        "journey",
+		Description: "Plan a journey by bus, tube and rail",
+		Usage:       "/journey [step-free] <place name>",
+		Emoji:       "🧭",
+		LoadingText: "Planning journey to %s...",
+		Match:       matchJourney,
+		Handler: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return "Usage: /journey [step-free] <place name>", nil
+			}
+			destination := strings.Join(args, " ")
+
+			if ctx.ToLat != 0 && ctx.ToLon != 0 {
+				stepFree, destination := splitStepFree(destination)
+				return JourneyTo(ctx.Session, destination, ctx.Lat, ctx.Lon, ctx.ToLat, ctx.ToLon, stepFree)
+			}
+			return Journey(ctx.Session, destination, ctx.Lat, ctx.Lon)
+		},
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,7 +30,14 @@ func Geocode(place string) (float64, float64, error) {
 }
 
 // GeocodeNear geocodes with location bias - prefers results near the given point
+// Indexed places, stations, streets and postcodes are tried before Nominatim
 func GeocodeNear(place string, nearLat, nearLon float64) (float64, float64, error) {
+	if nearLat != 0 || nearLon != 0 {
+		if local := spatial.GeocodeLocal(place, nearLat, nearLon, 1); len(local) > 0 && local[0].Confidence >= spatial.GeocodeConfident {
+			return local[0].Lat, local[0].Lon, nil
+		}
+	}
+
 	// Build URL with query params
 	req, _ := http.NewRequest("GET", nominatimURL, nil)
 	q := req.URL.Query()
@@ -565,6 +573,7 @@ var ValidTypes = map[string]bool{
 	"mosque":  true, "church": true, "temple": true,
 	"hotel": true, "hotels": true,
 	"cinema": true, "cinemas": true, "theatre": true, "theater": true,
+	"bike": true, "bikes": true, "docks": true,
 }
 
 // IsValidPlaceType checks if a string is a recognized place type
@@ -574,11 +583,19 @@ func IsValidPlaceType(s string) bool {
 
 // MultiWordTypes maps multi-word phrases to canonical types
 var MultiWordTypes = map[string]string{
-	"petrol station": "fuel",
-	"gas station":    "fuel",
-	"fuel station":   "fuel",
-	"coffee shop":    "cafe",
-	"coffee shops":   "cafe",
+	"petrol station":  "fuel",
+	"gas station":     "fuel",
+	"fuel station":    "fuel",
+	"coffee shop":     "cafe",
+	"coffee shops":    "cafe",
+	"santander bikes": "bikes",
+	"boris bikes":     "bikes",
+	"hire bikes":      "bikes",
+	"bike hire":       "bikes",
+	"cycle hire":      "bikes",
+	"bike share":      "bikes",
+	"cash machine":    "atm",
+	"cash point":      "atm",
 }
 
 // CheckMultiWordType checks if input contains a multi-word type, returns type and remaining words
@@ -658,6 +675,9 @@ var placeTypes = map[string]string{
 	"cinemas":        "amenity=cinema",
 	"theatre":        "amenity=theatre",
 	"theater":        "amenity=theatre",
+	"bike":           "amenity=bicycle_rental",
+	"bikes":          "amenity=bicycle_rental",
+	"docks":          "amenity=bicycle_rental",
 }
 
 const searchRadius = 2000.0 // 2km default radius
@@ -730,7 +750,7 @@ const agentRadius = 5000.0 // 5km agent territory
 
 func NearbyWithLocation(placeType string, lat, lon float64) (string, error) {
 	log.Printf("[nearby] NearbyWithLocation(%s, %f, %f)", placeType, lat, lon)
-	placeType = strings.ToLower(strings.TrimSpace(placeType))
+	placeType, openOnly := parseOpenFilter(strings.ToLower(strings.TrimSpace(placeType)))
 
 	// If not a valid type, search by name instead
 	if !ValidTypes[placeType] {
@@ -743,27 +763,25 @@ func NearbyWithLocation(placeType string, lat, lon float64) (string, error) {
 	db := spatial.Get()
 	db.FindOrCreateAgent(lat, lon)
 
+	// Bike-share docks are live data, fall back to OSM rental places
+	if category == "bikes" {
+		if result := nearbyBikeDocks(lat, lon); result != "" {
+			return result, nil
+		}
+	}
+
 	// Check spatial DB first (use appropriate radius for place type)
+	// Supplementary datasets (cinema chains etc) are merged in by QueryPlaces
 	radius := getSearchRadius(placeType)
 	cached := db.QueryPlaces(lat, lon, radius, category, 20)
 
-	// For cinemas, merge supplementary data with cache
-	if category == "cinema" {
-		supplementary := spatial.GetSupplementaryCinemas(lat, lon, radius)
-		if len(supplementary) > 0 {
-			existing := make(map[string]bool)
-			for _, e := range cached {
-				existing[strings.ToLower(e.Name)] = true
-			}
-			for _, s := range supplementary {
-				if !existing[strings.ToLower(s.Name)] {
-					cached = append(cached, s)
-				}
-			}
+	if openOnly {
+		cached = filterOpenEntities(cached)
+		if len(cached) > 0 {
+			return formatCachedEntities(cached, placeType), nil
 		}
-	}
-
-	if len(cached) > 0 {
+		// Cache may only hold closed places - check OSM for more
+	} else if len(cached) > 0 {
 		return formatCachedEntities(cached, placeType), nil
 	}
 
@@ -812,37 +830,97 @@ out center 10;
 	// Cache the results in background
 	go cacheOSMResults(data.Elements, category)
 
-	// For cinemas, merge with supplementary data before formatting
-	if category == "cinema" {
-		supplementary := spatial.GetSupplementaryCinemas(lat, lon, radius)
-		if len(supplementary) > 0 {
-			// Convert OSM elements to entities for merge
-			var allCinemas []*spatial.Entity
-			existing := make(map[string]bool)
-			for _, el := range data.Elements {
-				eLat, eLon := el.GetCoords()
-				e := &spatial.Entity{
-					Type: spatial.EntityPlace,
-					Name: el.Tags["name"],
-					Lat:  eLat,
-					Lon:  eLon,
-					Data: map[string]interface{}{"tags": el.Tags},
-				}
-				allCinemas = append(allCinemas, e)
-				existing[strings.ToLower(el.Tags["name"])] = true
-			}
-			for _, s := range supplementary {
-				if !existing[strings.ToLower(s.Name)] {
-					allCinemas = append(allCinemas, s)
-				}
-			}
-			return formatCachedEntities(allCinemas, placeType), nil
+	if openOnly {
+		data.Elements = filterOpenElements(data.Elements)
+		if len(data.Elements) == 0 {
+			return fmt.Sprintf("🔴 No %s known to be open nearby right now", placeType), nil
 		}
 	}
 
 	return formatOSMResults(data.Elements, placeType, lat, lon), nil
 }
 
+// nearbyBikeDocks lists docks with live availability, fetching if not cached
+func nearbyBikeDocks(lat, lon float64) string {
+	docks := spatial.GetNearbyBikeDocks(lat, lon, spatial.BikeNearbyRadius)
+	if len(docks) == 0 {
+		spatial.RefreshBikeDocks(lat, lon)
+		docks = spatial.GetNearbyBikeDocks(lat, lon, spatial.BikeNearbyRadius)
+	}
+	if len(docks) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🚲 %s nearby\n\n", docks[0].Data.Network))
+	for i, d := range docks {
+		if i >= 6 {
+			break
+		}
+		result.WriteString(spatial.FormatBikeDock(d) + "\n")
+		mapURL := fmt.Sprintf("/map?lat=%f&lon=%f&highlight=%s", d.Entity.Lat, d.Entity.Lon, urlEncode(d.Entity.Name))
+		escapedName := strings.ReplaceAll(d.Entity.Name, "\"", "&quot;")
+		result.WriteString(fmt.Sprintf(`   <a href="%s" target="_blank" class="map-link">Map</a> · <a href="javascript:void(0)" class="directions-link" data-name="%s" data-lat="%f" data-lon="%f">Directions</a>`,
+			mapURL, escapedName, d.Entity.Lat, d.Entity.Lon))
+		result.WriteString("\n\n")
+	}
+	return strings.TrimSpace(result.String())
+}
+
+// openFilterWords mark a nearby search as "open now"
+var openFilterWords = map[string]bool{"open": true, "now": true}
+
+// parseOpenFilter strips "open"/"open now" from a place type
+// "open cafes", "cafes open now" -> "cafes", true
+func parseOpenFilter(placeType string) (string, bool) {
+	var words []string
+	openOnly := false
+	for _, w := range strings.Fields(placeType) {
+		if openFilterWords[w] {
+			openOnly = openOnly || w == "open"
+			continue
+		}
+		words = append(words, w)
+	}
+	if !openOnly {
+		return placeType, false
+	}
+	return strings.Join(words, " "), true
+}
+
+// filterOpenEntities keeps places whose opening hours say they're open now
+func filterOpenEntities(entities []*spatial.Entity) []*spatial.Entity {
+	now := time.Now()
+	var open []*spatial.Entity
+	for _, e := range entities {
+		if state := spatial.PlaceOpenState(e, now); state.Known && state.Open {
+			open = append(open, e)
+		}
+	}
+	return open
+}
+
+// filterOpenElements keeps OSM results whose opening hours say they're open now
+func filterOpenElements(elements []OSMElement) []OSMElement {
+	now := time.Now()
+	var open []OSMElement
+	for _, el := range elements {
+		lat, lon := el.GetCoords()
+		if state := spatial.HoursOpenState(el.Tags["opening_hours"], lat, lon, now); state.Known && state.Open {
+			open = append(open, el)
+		}
+	}
+	return open
+}
+
+// formatHours shows open/closed state, or the raw hours if they can't be parsed
+func formatHours(hours string, lat, lon float64) string {
+	if label := spatial.HoursOpenState(hours, lat, lon, time.Now()).Label(); label != "" {
+		return label
+	}
+	return "🕒" + hours
+}
+
 // normalizeType converts plural/alias types to singular canonical form
 func normalizeType(placeType string) string {
 	aliases := map[string]string{
@@ -859,6 +937,7 @@ func normalizeType(placeType string) string {
 		"petrol": "fuel", "gas": "fuel", "station": "fuel",
 		"hotels":  "hotel",
 		"cinemas": "cinema", "theatres": "theatre", "theaters": "theatre",
+		"bike": "bikes", "docks": "bikes",
 	}
 	if canonical, ok := aliases[placeType]; ok {
 		return canonical
@@ -902,8 +981,14 @@ func cacheOSMResults(elements []OSMElement, category string) {
 // formatCachedEntities formats cached entities for display
 // Output format matches client place-link expansion
 func formatCachedEntities(entities []*spatial.Entity, placeType string) string {
+	return formatEntityList(fmt.Sprintf("📍 %s nearby", strings.Title(placeType)), entities, nil)
+}
+
+// formatEntityList formats entities under a header
+// notes, if given, lead each entity's info line (e.g. travel time)
+func formatEntityList(header string, entities []*spatial.Entity, notes []string) string {
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("📍 %s nearby\n\n", strings.Title(placeType)))
+	result.WriteString(header + "\n\n")
 
 	max := 6
 	if len(entities) < max {
@@ -936,18 +1021,24 @@ func formatCachedEntities(entities []*spatial.Entity, placeType string) string {
 
 		// Info line: address, postcode, hours, phone
 		var info []string
+		if i < len(notes) && notes[i] != "" {
+			info = append(info, notes[i])
+		}
 		if len(tags) > 0 {
 			if addr := formatAddress(tags); addr != "" {
 				info = append(info, addr)
 			}
 			if hours := tags["opening_hours"]; hours != "" {
-				info = append(info, "🕒"+hours)
+				info = append(info, formatHours(hours, e.Lat, e.Lon))
 			}
 			if phone := tags["phone"]; phone != "" {
 				info = append(info, "📞"+phone)
 			} else if phone := tags["contact:phone"]; phone != "" {
 				info = append(info, "📞"+phone)
 			}
+			if access := formatWheelchair(tags); access != "" {
+				info = append(info, access)
+			}
 		}
 		if len(info) > 0 {
 			result.WriteString(fmt.Sprintf("   %s\n", strings.Join(info, ", ")))
@@ -1000,11 +1091,14 @@ func formatOSMResults(elements []OSMElement, placeType string, lat, lon float64)
 			info = append(info, addr)
 		}
 		if hours := el.Tags["opening_hours"]; hours != "" {
-			info = append(info, "🕒"+hours)
+			info = append(info, formatHours(hours, elLat, elLon))
 		}
 		if phone := el.Tags["phone"]; phone != "" {
 			info = append(info, "📞"+phone)
 		}
+		if access := formatWheelchair(el.Tags); access != "" {
+			info = append(info, access)
+		}
 		if len(info) > 0 {
 			result.WriteString(fmt.Sprintf("   %s\n", strings.Join(info, ", ")))
 		}
@@ -1052,9 +1146,26 @@ func formatAddress(tags map[string]string) string {
 		parts = append(parts, postcode)
 	}
 
+	// Supplementary datasets often only have a one-line address
+	if len(parts) == 0 {
+		return tags["addr:full"]
+	}
 	return strings.Join(parts, ", ")
 }
 
+// formatWheelchair describes a place's OSM wheelchair tag, "" if untagged
+func formatWheelchair(tags map[string]string) string {
+	switch tags["wheelchair"] {
+	case "yes", "designated":
+		return "♿ Step-free"
+	case "limited":
+		return "♿ Partly step-free"
+	case "no":
+		return "♿ Not wheelchair accessible"
+	}
+	return ""
+}
+
 // matchNearby detects nearby queries in natural language
 func matchNearby(input string) (bool, []string) {
 	input = strings.TrimSpace(input)
@@ -1065,7 +1176,7 @@ func matchNearby(input string) (bool, []string) {
 	}
 
 	// Don't match question patterns - those go to AI or place command
-	questionPhrases := []string{"what time", "when does", "is .* open", "opening hours", "hours for", "what about", "tell me about", "info on", "information about", "how do i", "how to", "get to", "directions to", "way to"}
+	questionPhrases := []string{"what time", "when does", "is .* open", "opening hours", "hours for", "what about", "tell me about", "info on", "information about", "how do i", "how to", "get to", "directions to", "way to", "on the way", "on my way"}
 	for _, q := range questionPhrases {
 		if strings.Contains(lower, q) || matchWildcard(lower, q) {
 			return false, nil
@@ -1080,6 +1191,7 @@ func matchNearby(input string) (bool, []string) {
 	// Remove filler words
 	var cleaned []string
 	for _, p := range parts {
+		p = strings.Trim(p, "?!.,")
 		l := strings.ToLower(p)
 		if l == "near" || l == "nearby" || l == "me" || l == "in" || l == "around" ||
 			l == "nearest" || l == "closest" || l == "find" || l == "show" || l == "where" ||
@@ -1112,7 +1224,7 @@ func matchNearby(input string) (bool, []string) {
 
 	// Only match explicit nearby queries - not just any sentence with a place type
 	// "cafes near me" = yes, "I want coffee" = no (let AI handle it)
-	nearbyPatterns := []string{"near me", "around me", "nearby", "closest", "nearest", "find a", "find me", "show me", "where can i find"}
+	nearbyPatterns := []string{"near me", "around me", "nearby", "closest", "nearest", "find a", "find me", "show me", "where can i find", "open now", "within"}
 	isNearbyQuery := false
 	for _, p := range nearbyPatterns {
 		if strings.Contains(lower, p) {
@@ -1124,6 +1236,10 @@ func matchNearby(input string) (bool, []string) {
 	if !isNearbyQuery && len(cleaned) == 1 && IsValidPlaceType(strings.ToLower(cleaned[0])) {
 		isNearbyQuery = true
 	}
+	// "open cafes"
+	if !isNearbyQuery && len(cleaned) == 2 && strings.ToLower(cleaned[0]) == "open" && IsValidPlaceType(strings.ToLower(cleaned[1])) {
+		isNearbyQuery = true
+	}
 
 	if isNearbyQuery {
 		return true, cleaned
@@ -1132,19 +1248,98 @@ func matchNearby(input string) (bool, []string) {
 	return false, nil
 }
 
+// "within 10 min walk", "within 15 minutes by bike", "within 500m".
+// A bare m is metres, turned into the time it takes at the travel speed
+var withinRe = regexp.MustCompile(`(?i)\bwithin (\d+) ?(mins?|minutes?|m|metres|meters|km)\b(?: (?:by |on )?(walk|walking|foot|cycle|cycling|bike|ride|drive|driving|car|wheelchair))?`)
+
+// parseWithin takes a travel time limit off a nearby query
+// Returns the rest of the query, and 0 minutes if there's no limit
+func parseWithin(input string) (string, int, *spatial.Profile) {
+	m := withinRe.FindStringSubmatchIndex(input)
+	if m == nil {
+		return input, 0, nil
+	}
+	n, _ := strconv.Atoi(input[m[2]:m[3]])
+	profile := spatial.ProfileFoot
+	if m[6] >= 0 {
+		profile = spatial.ProfileFor(input[m[6]:m[7]])
+	}
+
+	minutes := n
+	switch unit := strings.ToLower(input[m[4]:m[5]]); unit {
+	case "m", "metres", "meters", "km":
+		metres := float64(n)
+		if unit == "km" {
+			metres *= 1000
+		}
+		minutes = int(math.Ceil(metres / profile.Speed / 60))
+	}
+	return strings.TrimSpace(input[:m[0]] + input[m[1]:]), minutes, profile
+}
+
+// NearbyWithin lists places of a type reachable within a travel time,
+// by street network rather than straight-line distance
+func NearbyWithin(placeType string, lat, lon float64, minutes int, profile *spatial.Profile) (string, error) {
+	placeType, openOnly := parseOpenFilter(strings.ToLower(strings.TrimSpace(placeType)))
+	if !ValidTypes[placeType] {
+		return searchByName(placeType, lat, lon)
+	}
+	category := normalizeType(placeType)
+
+	db := spatial.Get()
+	db.FindOrCreateAgent(lat, lon)
+
+	// Nothing farther than the budget as the crow flies can be reached in time
+	radius := profile.Speed * float64(minutes) * 60
+	places := db.QueryPlaces(lat, lon, radius, category, 100)
+	if openOnly {
+		places = filterOpenEntities(places)
+	}
+	reachable := spatial.ReachableWithin(lat, lon, minutes, profile, places)
+	if len(reachable) == 0 {
+		return fmt.Sprintf("%s No %s known within %d min %s", profile.Icon, placeType, minutes, profile.Noun), nil
+	}
+
+	entities := make([]*spatial.Entity, len(reachable))
+	notes := make([]string, len(reachable))
+	for i, r := range reachable {
+		entities[i] = r.Entity
+		notes[i] = profile.Icon + " " + spatial.FormatTravelTime(r.Seconds, profile)
+	}
+	header := fmt.Sprintf("%s %s within %d min %s", profile.Icon, strings.Title(placeType), minutes, profile.Noun)
+	return formatEntityList(header, entities, notes), nil
+}
+
 // handleNearby processes the nearby command
 func handleNearby(ctx *Context, args []string) (string, error) {
 	if len(args) == 0 {
-		return "Usage: /nearby <type> [location]\nExamples: /nearby cafes, /nearby Twickenham cafes", nil
+		return "Usage: /nearby <type> [location]\nExamples: /nearby cafes, /nearby Twickenham cafes, /nearby cafe within 10 min walk", nil
+	}
+
+	// Travel time limit: "cafe within 10 min walk"
+	rest, minutes, profile := parseWithin(strings.Join(args, " "))
+	if minutes > 0 {
+		if profile == nil {
+			profile = spatial.ProfileFoot
+		}
+		args = strings.Fields(rest)
+		if len(args) == 0 || minutes > spatial.IsochroneMaxMins {
+			return fmt.Sprintf("Usage: /nearby <type> within <minutes> min [walk|cycle], up to %d min", spatial.IsochroneMaxMins), nil
+		}
 	}
 
 	// Find the place type and location from args
 	var placeType string
 	var locationParts []string
 	var lat, lon float64
+	openOnly := false
 
 	for _, arg := range args {
 		lower := strings.ToLower(arg)
+		if openFilterWords[lower] {
+			openOnly = openOnly || lower == "open"
+			continue
+		}
 		if IsValidPlaceType(lower) {
 			placeType = lower
 		} else if coords := strings.Split(arg, ","); len(coords) == 2 {
@@ -1184,6 +1379,12 @@ func handleNearby(ctx *Context, args []string) (string, error) {
 		lat, lon = ctx.Lat, ctx.Lon
 	}
 
+	if openOnly {
+		placeType = "open " + placeType
+	}
+	if minutes > 0 {
+		return NearbyWithin(placeType, lat, lon, minutes, profile)
+	}
 	return NearbyWithLocation(placeType, lat, lon)
 }
 
@@ -1376,6 +1577,9 @@ func handlePlaceInfo(ctx *Context, args []string) (string, error) {
 		result.WriteString(fmt.Sprintf("%s\n", addr))
 	}
 	if hours != "" {
+		if label := spatial.HoursOpenState(hours, match.Lat, match.Lon, time.Now()).Label(); label != "" {
+			result.WriteString(label + "\n")
+		}
 		result.WriteString(fmt.Sprintf("🕐 %s\n", hours))
 	} else {
 		result.WriteString("Hours not available\n")
@@ -1465,6 +1669,9 @@ func handlePing(ctx *Context, args []string) (string, error) {
 	userContexts[ctx.Session] = contextData.HTML
 	userContextsMu.Unlock()
 
+	// Turn-by-turn navigation follows GPS, not a check-in
+	changes = append(changes, spatial.UpdateNavigation(ctx.Session, ctx.Lat, ctx.Lon)...)
+
 	// Push meaningful changes via websocket (handled by server)
 	if len(changes) > 0 {
 		ctx.PushMessages = changes
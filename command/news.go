@@ -0,0 +1,77 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"malten.ai/spatial"
+)
+
+func init() {
+	Register(&Command{
+		Name:        "news",
+		Description: "Latest headlines, or news near you with /news local",
+		Usage:       "/news [local|query]",
+		Emoji:       "📰",
+		LoadingText: "Getting news...",
+		Handler: func(ctx *Context, args []string) (string, error) {
+			return News(strings.Join(args, " "), ctx.Lat, ctx.Lon)
+		},
+		Match: func(input string) (bool, []string) {
+			lower := strings.ToLower(strings.TrimSpace(input))
+			switch lower {
+			case "local news", "news near me", "news nearby", "what's happening nearby":
+				return true, []string{"local"}
+			}
+			return false, nil
+		},
+	})
+}
+
+// News returns headlines: latest national with no query, local for "local",
+// otherwise headlines matching the query
+func News(query string, lat, lon float64) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return formatNews("📰 Latest headlines", spatial.GetNationalNews(5)), nil
+	}
+
+	if strings.ToLower(query) == "local" {
+		if lat == 0 && lon == 0 {
+			return "📍 Need your location for local news. Enable location?", nil
+		}
+		news := spatial.GetLocalNews(lat, lon, 8)
+		if len(news) == 0 {
+			return "📰 No local news for this area yet", nil
+		}
+		return formatNews("📰 Local news", news), nil
+	}
+
+	news := spatial.SearchNews(query, lat, lon, 5)
+	if len(news) == 0 {
+		return fmt.Sprintf("📰 No recent headlines about %s", query), nil
+	}
+	return formatNews(fmt.Sprintf("📰 News about %s", query), news), nil
+}
+
+func formatNews(title string, news []*spatial.Entity) string {
+	if len(news) == 0 {
+		return "📰 No headlines right now"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(title)
+	sb.WriteString("\n")
+	for _, e := range news {
+		headline := strings.TrimPrefix(e.Name, "📰 ")
+		nd := e.GetNewsData()
+		if nd != nil && nd.Place != "" {
+			headline += fmt.Sprintf(" (%s)", nd.Place)
+		}
+		sb.WriteString("\n• " + headline)
+		if nd != nil && nd.Link != "" {
+			sb.WriteString("\n  " + nd.Link)
+		}
+	}
+	return sb.String()
+}
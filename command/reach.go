@@ -0,0 +1,114 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"malten.ai/spatial"
+)
+
+// "what can I walk to in 10 minutes", "what can i cycle to in 15 min"
+var reachRe = regexp.MustCompile(`(walk|cycle|bike|ride|drive|get) to in (\d+) ?(?:mins?|minutes?)\b`)
+
+func matchReach(input string) (bool, []string) {
+	m := reachRe.FindStringSubmatch(strings.ToLower(input))
+	if m == nil {
+		return false, nil
+	}
+	return true, []string{m[2], m[1]}
+}
+
+// Reach summarises what's reachable within a travel time, by category
+func Reach(lat, lon float64, minutes int, profile *spatial.Profile) (string, error) {
+	iso, err := spatial.ComputeIsochrone(lat, lon, minutes, profile)
+	if err != nil {
+		return fmt.Sprintf("%s Can't work out what's within %d min %s here yet: %v", profile.Icon, minutes, profile.Noun, err), nil
+	}
+
+	radius := profile.Speed * float64(minutes) * 60
+	places := spatial.Get().Query(lat, lon, radius, spatial.EntityPlace, 500)
+	reachable := spatial.ReachableWithin(lat, lon, minutes, profile, places)
+
+	type group struct {
+		category string
+		count    int
+		nearest  spatial.ReachablePlace
+	}
+	groups := make(map[string]*group)
+	for _, r := range reachable {
+		pd := r.Entity.GetPlaceData()
+		if pd == nil || pd.Category == "" || r.Entity.Name == "" {
+			continue
+		}
+		g := groups[pd.Category]
+		if g == nil {
+			// Reachable places come quickest first, so the first seen is nearest
+			g = &group{category: pd.Category, nearest: r}
+			groups[pd.Category] = g
+		}
+		g.count++
+	}
+	var sorted []*group
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].category < sorted[j].category
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s Within %d min %s\n", profile.Icon, minutes, profile.Noun))
+	if len(sorted) == 0 {
+		sb.WriteString("\nNo places indexed in reach yet")
+	}
+	for i, g := range sorted {
+		if i == 8 {
+			sb.WriteString(fmt.Sprintf("\n+%d more categories", len(sorted)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("\n• %s: %d · nearest %s (%s)", g.category, g.count,
+			g.nearest.Entity.Name, spatial.FormatTravelTime(g.nearest.Seconds, profile)))
+	}
+	sb.WriteString(fmt.Sprintf("\n\n🗺️ /isochrone?lat=%.6f&lon=%.6f&minutes=%d&mode=%s", iso.Lat, iso.Lon, minutes, profile.Name))
+	return sb.String(), nil
+}
+
+func init() {
+	Register(&Command{
+		Name:        "reach",
+		Description: "What's reachable within a travel time",
+		Usage:       "/reach <minutes> [walk|cycle|drive|wheelchair]",
+		Emoji:       "🧭",
+		LoadingText: "Working out what's in reach...",
+		Match:       matchReach,
+		Handler: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return "Usage: /reach <minutes> [walk|cycle|drive|wheelchair]", nil
+			}
+			if !ctx.HasLocation() {
+				return "📍 Need your location. Enable location?", nil
+			}
+			minutes, err := strconv.Atoi(args[0])
+			if err != nil || minutes <= 0 || minutes > spatial.IsochroneMaxMins {
+				return fmt.Sprintf("Usage: /reach <minutes> [walk|cycle|drive|wheelchair], up to %d min", spatial.IsochroneMaxMins), nil
+			}
+			profile := spatial.ProfileFoot
+			if len(args) > 1 {
+				mode := args[1]
+				if mode == "get" {
+					mode = "walk"
+				}
+				if profile = spatial.ProfileFor(mode); profile == nil {
+					return fmt.Sprintf("Unknown travel mode %q. Try walk, cycle, drive or wheelchair", args[1]), nil
+				}
+			}
+			return Reach(ctx.Lat, ctx.Lon, minutes, profile)
+		},
+	})
+}
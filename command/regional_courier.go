@@ -42,7 +42,12 @@ func init() {
 				sb.WriteString("⏸️ Paused\n")
 			}
 
-			sb.WriteString(fmt.Sprintf("📊 %d regions · %d trips · %.1f km walked\n\n", count, totalTrips, totalWalked))
+			sb.WriteString(fmt.Sprintf("📊 %d regions · %d trips · %.1f km walked\n", count, totalTrips, totalWalked))
+			if cov, ok := status["coverage"].(map[string]interface{}); ok && cov["osrm_calls"].(int) > 0 {
+				sb.WriteString(fmt.Sprintf("🧭 %.1f km new streets · %d routes · %.0fm per route\n",
+					cov["new_km"], cov["osrm_calls"], cov["new_m_per_call"]))
+			}
+			sb.WriteString("\n")
 
 			// Show individual couriers
 			if couriers, ok := status["couriers"].([]map[string]interface{}); ok && len(couriers) > 0 {
@@ -31,7 +31,7 @@ func IndexStreets(lat, lon float64) string {
 	}
 
 	// Quick index - just 5 routes for immediate feedback
-	count := spatial.IndexStreetsAroundAgent(agent, 5)
+	count, _ := spatial.IndexStreetsAroundAgent(agent, 5)
 
 	// Start background indexing for the rest
 	spatial.IndexStreetsAsync(agent)
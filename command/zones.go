@@ -52,6 +52,21 @@ func handleZones(ctx *Context, args []string) (string, error) {
 	var lines []string
 	lines = append(lines, "📊 **Coverage Analysis**\n")
 
+	// Territory tiles by size: 4 is rural, 6 the default, 7 a split city block
+	tiles := spatial.TerritoryStats()
+	var sizes []string
+	total := 0
+	for p := 4; p <= 7; p++ {
+		if n := tiles[p]; n > 0 {
+			sizes = append(sizes, fmt.Sprintf("%d at precision %d", n, p))
+			total += n
+		}
+	}
+	if total > 0 {
+		lines = append(lines, fmt.Sprintf("**Territories:** %d tiles (%s)", total, strings.Join(sizes, ", ")))
+		lines = append(lines, "")
+	}
+
 	// Well connected (10+ streets)
 	var wellConnected []string
 	for _, c := range coverage {
@@ -141,6 +156,21 @@ func handleZones(ctx *Context, args []string) (string, error) {
 		}
 	}
 
+	// Where a courier here would head next
+	if ctx.Lat != 0 || ctx.Lon != 0 {
+		if frontier := spatial.CourierFrontier(ctx.Lat, ctx.Lon, 3); len(frontier) > 0 {
+			lines = append(lines, "")
+			lines = append(lines, "**Frontier** (least mapped nearby):")
+			for _, f := range frontier {
+				line := fmt.Sprintf("• %s (%.1fkm, %.0f%% unmapped)", f.Name, f.Distance/1000, f.Missing*100)
+				if f.Bridge {
+					line += " 🌉"
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+
 	return strings.Join(lines, "\n"), nil
 }
 
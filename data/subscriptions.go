@@ -53,11 +53,8 @@ func (s *SubscriptionsFile) Load() error {
 		return err
 	}
 
+	// Timezone is resolved from Lat/Lon by the push manager
 	for _, u := range users {
-		if u.Lon != 0 {
-			offsetHours := int(u.Lon / 15)
-			u.Timezone = time.FixedZone("local", offsetHours*3600)
-		}
 		s.Users[u.SessionID] = u
 	}
 	return nil
@@ -190,6 +190,17 @@ func buildMorningContext(lat, lon float64) *server.PushNotification {
 		body += ctx.Prayer.Display
 	}
 
+	// Nearby road closures are worth knowing before leaving
+	for _, d := range ctx.Disruptions {
+		if d.Closure {
+			if body != "" {
+				body += "\n"
+			}
+			body += d.Display
+			break
+		}
+	}
+
 	if body == "" {
 		return nil
 	}
@@ -235,6 +246,9 @@ func main() {
 	// Start background cleanup every 5 minutes (only cleans expired arrivals)
 	spatial.Get().StartBackgroundCleanup(5 * time.Minute)
 
+	// Split busy agent territories and merge quiet ones hourly
+	spatial.Get().StartTerritoryLoop(time.Hour)
+
 	// Start courier loops (local and regional)
 	spatial.StartCourierLoop()         // Original courier for backward compat
 	spatial.StartRegionalCourierLoop() // Regional couriers for global coverage
@@ -251,6 +265,12 @@ func main() {
 		return cleared
 	}
 
+	// Standing agent instructions: nearby search and publishing live outside spatial
+	spatial.InstructionNearby = command.NearbyWithLocation
+	spatial.PublishAgentFinding = func(a *spatial.Entity, stream, text string) {
+		server.Default.Events <- server.NewChannelMessage(text, stream, "")
+	}
+
 	// Initialize AI agent
 	if err := agent.Init(); err != nil {
 		log.Printf("AI not available: %v", err)
@@ -258,6 +278,8 @@ func main() {
 		log.Println("AI initialized")
 		// Share client with dedupe system
 		server.SetDedupeClient(agent.Client)
+		// Plan agent instructions with the LLM rather than keywords
+		spatial.PlanInstruction = agent.PlanInstruction
 	}
 
 	// Get JS version from malten.js VERSION constant
@@ -362,6 +384,9 @@ func main() {
 	http.HandleFunc("/push/history", server.HandlePushHistory)
 	http.HandleFunc("/push/test-morning", server.HandleTestMorningPush)
 	http.HandleFunc("/map", server.MapHandler)
+	http.HandleFunc("/isochrone", server.IsochroneHandler)
+	http.HandleFunc("/tracks/", server.TracksHandler)
+	http.HandleFunc("/routes/", server.RoutesHandler)
 	http.HandleFunc("/backfill-streets", server.BackfillStreetsHandler)
 
 	h := server.WithCors(http.DefaultServeMux)
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,8 +13,9 @@ import (
 // AgentsHandler handles /agents endpoint
 // GET /agents - list all agents
 // GET /agents/{id} or /agents?id=xxx - get specific agent
-// POST /agents - create agent (lat, lon, prompt params)
-// POST /agents/{id} - instruct agent (action, prompt, lat, lon params)
+// GET /agents/{id}/health - recent task runs and health summary
+// POST /agents - create agent (lat, lon, prompt, tasks params)
+// POST /agents/{id} - instruct agent (action, prompt, forget, tasks, lat, lon params)
 // DELETE /agents/{id} - kill agent
 //
 // Accepts form params, query params, or JSON body (if Content-Type: application/json)
@@ -32,7 +34,9 @@ func AgentsHandler(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case "GET":
-		if agentID == "" {
+		if id := strings.TrimSuffix(agentID, "/health"); id != agentID {
+			getAgentHealth(w, r, id)
+		} else if agentID == "" {
 			listAgents(w, r)
 		} else {
 			getAgent(w, r, agentID)
@@ -60,13 +64,23 @@ func listAgents(w http.ResponseWriter, r *http.Request) {
 	agents := db.ListAgents()
 
 	var result []map[string]interface{}
+	modes := make(map[string]int)
+	health := make(map[string]int)
+	var saved int
 	for _, a := range agents {
-		result = append(result, agentToJSON(a))
+		j := agentToJSON(a)
+		modes[j["mode"].(string)]++
+		health[j["health"].(string)]++
+		saved += j["apiCallsSaved"].(int)
+		result = append(result, j)
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"agents": result,
-		"count":  len(result),
+		"agents":        result,
+		"count":         len(result),
+		"modes":         modes,
+		"health":        health,
+		"apiCallsSaved": saved,
 	})
 }
 
@@ -83,19 +97,40 @@ func getAgent(w http.ResponseWriter, r *http.Request, id string) {
 	json.NewEncoder(w).Encode(agentToJSON(agent))
 }
 
+// GET /agents/{id}/health - task run history and health summary
+func getAgentHealth(w http.ResponseWriter, r *http.Request, id string) {
+	db := spatial.Get()
+	agent := db.GetByID(id)
+
+	if agent == nil || agent.Type != spatial.EntityAgent {
+		JsonError(w, "agent not found", 404)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":     agent.ID,
+		"name":   agent.Name,
+		"mode":   spatial.AgentMode(agent),
+		"health": spatial.GetAgentHealth(agent),
+		"runs":   spatial.AgentTaskRuns(agent.ID),
+	})
+}
+
 // POST /agents - create agent at location
-// Params: lat, lon, prompt
+// Params: lat, lon, prompt, tasks (comma separated task names)
 func createAgent(w http.ResponseWriter, r *http.Request) {
 	lat, _ := strconv.ParseFloat(r.Form.Get("lat"), 64)
 	lon, _ := strconv.ParseFloat(r.Form.Get("lon"), 64)
 	prompt := r.Form.Get("prompt")
+	tasks, setTasks := formTasks(r)
 
 	// Also try JSON body if content-type is set
 	if r.Header.Get("Content-Type") == "application/json" {
 		var req struct {
-			Lat    float64 `json:"lat"`
-			Lon    float64 `json:"lon"`
-			Prompt string  `json:"prompt"`
+			Lat    float64   `json:"lat"`
+			Lon    float64   `json:"lon"`
+			Prompt string    `json:"prompt"`
+			Tasks  *[]string `json:"tasks"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err == nil {
 			if req.Lat != 0 {
@@ -107,6 +142,9 @@ func createAgent(w http.ResponseWriter, r *http.Request) {
 			if req.Prompt != "" {
 				prompt = req.Prompt
 			}
+			if req.Tasks != nil {
+				tasks, setTasks = *req.Tasks, true
+			}
 		}
 	}
 
@@ -118,15 +156,32 @@ func createAgent(w http.ResponseWriter, r *http.Request) {
 	db := spatial.Get()
 	agent := db.FindOrCreateAgent(lat, lon)
 
-	// Note: prompt not stored in typed AgentEntityData - stored elsewhere if needed
-	_ = prompt
+	if setTasks {
+		if err := spatial.SetAgentTasks(agent, tasks); err != nil {
+			JsonError(w, err.Error(), 400)
+			return
+		}
+	}
+
+	// The prompt becomes the agent's first standing instruction
+	if prompt != "" {
+		if _, err := spatial.AddInstruction(agent, prompt); err != nil {
+			JsonError(w, err.Error(), 400)
+			return
+		}
+	}
 
 	w.WriteHeader(201)
 	json.NewEncoder(w).Encode(agentToJSON(agent))
 }
 
 // POST /agents/{id} - send instruction to agent
-// Params: action (refresh|move|pause|resume), prompt, lat, lon
+// Params: action (refresh|move|pause|resume|delete), prompt, forget, tasks, lat, lon
+// Actions take effect at once: pause stops the agent's scheduled work,
+// refresh fetches live data now, move reindexes around the new spot.
+// A prompt is kept as a standing instruction the agent checks periodically;
+// forget drops one by ID. tasks picks the live data tasks it runs, empty
+// for all of its region's
 func instructAgent(w http.ResponseWriter, r *http.Request, id string) {
 	db := spatial.Get()
 	agent := db.GetByID(id)
@@ -138,16 +193,20 @@ func instructAgent(w http.ResponseWriter, r *http.Request, id string) {
 
 	action := r.Form.Get("action")
 	prompt := r.Form.Get("prompt")
+	forget := r.Form.Get("forget")
+	tasks, setTasks := formTasks(r)
 	lat, _ := strconv.ParseFloat(r.Form.Get("lat"), 64)
 	lon, _ := strconv.ParseFloat(r.Form.Get("lon"), 64)
 
 	// Also try JSON body if content-type is set
 	if r.Header.Get("Content-Type") == "application/json" {
 		var req struct {
-			Action string  `json:"action"`
-			Prompt string  `json:"prompt"`
-			Lat    float64 `json:"lat"`
-			Lon    float64 `json:"lon"`
+			Action string    `json:"action"`
+			Prompt string    `json:"prompt"`
+			Forget string    `json:"forget"`
+			Tasks  *[]string `json:"tasks"`
+			Lat    float64   `json:"lat"`
+			Lon    float64   `json:"lon"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err == nil {
 			if req.Action != "" {
@@ -156,6 +215,12 @@ func instructAgent(w http.ResponseWriter, r *http.Request, id string) {
 			if req.Prompt != "" {
 				prompt = req.Prompt
 			}
+			if req.Forget != "" {
+				forget = req.Forget
+			}
+			if req.Tasks != nil {
+				tasks, setTasks = *req.Tasks, true
+			}
 			if req.Lat != 0 {
 				lat = req.Lat
 			}
@@ -165,30 +230,58 @@ func instructAgent(w http.ResponseWriter, r *http.Request, id string) {
 		}
 	}
 
-	agentData := agent.GetAgentData()
-	if agentData == nil {
-		agentData = &spatial.AgentEntityData{}
+	// A deleted agent takes nothing else, storing it again would bring it
+	// back. Other params sent with delete are listed as ignored
+	if action == "delete" {
+		if err := spatial.ControlAgent(agent, action, 0, 0); err != nil {
+			JsonError(w, err.Error(), 409)
+			return
+		}
+		result := agentToJSON(agent)
+		var ignored []string
+		for name, given := range map[string]bool{
+			"prompt": prompt != "",
+			"forget": forget != "",
+			"tasks":  setTasks,
+			"lat":    lat != 0,
+			"lon":    lon != 0,
+		} {
+			if given {
+				ignored = append(ignored, name)
+			}
+		}
+		if len(ignored) > 0 {
+			sort.Strings(ignored)
+			result["ignored"] = ignored
+		}
+		json.NewEncoder(w).Encode(result)
+		return
 	}
 
-	switch action {
-	case "refresh":
-		agentData.Status = "refreshing"
-	case "move":
-		if lat != 0 || lon != 0 {
-			agent.Lat = lat
-			agent.Lon = lon
+	if action != "" {
+		if err := spatial.ControlAgent(agent, action, lat, lon); err != nil {
+			JsonError(w, err.Error(), 409)
+			return
+		}
+	}
+	if setTasks {
+		if err := spatial.SetAgentTasks(agent, tasks); err != nil {
+			JsonError(w, err.Error(), 400)
+			return
+		}
+	}
+	if forget != "" {
+		if err := spatial.RemoveInstruction(agent, forget); err != nil {
+			JsonError(w, err.Error(), 404)
+			return
+		}
+	}
+	if prompt != "" {
+		if _, err := spatial.AddInstruction(agent, prompt); err != nil {
+			JsonError(w, err.Error(), 400)
+			return
 		}
-	case "pause":
-		agentData.Status = "paused"
-	case "resume":
-		agentData.Status = "active"
 	}
-
-	// Note: prompt not stored in AgentEntityData
-	_ = prompt
-
-	agent.Data = agentData
-	db.Insert(agent)
 	json.NewEncoder(w).Encode(agentToJSON(agent))
 }
 
@@ -202,13 +295,31 @@ func deleteAgent(w http.ResponseWriter, r *http.Request, id string) {
 		return
 	}
 
-	db.Delete(id)
+	if err := spatial.ControlAgent(agent, "delete", 0, 0); err != nil {
+		JsonError(w, err.Error(), 409)
+		return
+	}
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"deleted": id,
 		"ok":      true,
 	})
 }
 
+// formTasks reads a comma separated tasks param, reporting whether one was
+// given; an empty one clears the agent's own task set
+func formTasks(r *http.Request) ([]string, bool) {
+	if _, ok := r.Form["tasks"]; !ok {
+		return nil, false
+	}
+	var tasks []string
+	for _, name := range strings.Split(r.Form.Get("tasks"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			tasks = append(tasks, name)
+		}
+	}
+	return tasks, true
+}
+
 func agentToJSON(a *spatial.Entity) map[string]interface{} {
 	result := map[string]interface{}{
 		"id":        a.ID,
@@ -218,8 +329,28 @@ func agentToJSON(a *spatial.Entity) map[string]interface{} {
 		"updatedAt": a.UpdatedAt,
 	}
 
+	// Demand: live, slow, hibernating or paused, from how recently a user was nearby
+	result["mode"] = spatial.AgentMode(a)
+	result["apiCallsSaved"] = spatial.AgentScheduler().CallsSaved(a.ID)
+	result["tasks"] = spatial.AgentTaskNames(a)
+	result["instructions"] = spatial.AgentInstructions(a)
+
+	// Health: ok, degraded, failing, stale, idle or unknown, from recent task runs
+	health := spatial.GetAgentHealth(a)
+	result["health"] = health.Status
+	if health.Reason != "" {
+		result["healthReason"] = health.Reason
+	}
+	if health.LastSuccess != nil {
+		result["lastSuccess"] = health.LastSuccess.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if last := spatial.AgentScheduler().LastVisit(a.ID); !last.IsZero() {
+		result["lastDemand"] = last.Format("2006-01-02T15:04:05Z07:00")
+	}
+
 	if agentData := a.GetAgentData(); agentData != nil {
 		result["status"] = agentData.Status
+		result["state"] = spatial.AgentState(a)
 		result["radius"] = agentData.Radius
 		result["poiCount"] = agentData.POICount
 		if agentData.LastIndex != nil {
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"malten.ai/spatial"
+)
+
+// TestInstructDeleteWithPrompt checks a prompt sent with delete doesn't
+// store the deleted agent again
+func TestInstructDeleteWithPrompt(t *testing.T) {
+	id := "test-agent-instruct-delete"
+	spatial.Get().Insert(&spatial.Entity{
+		ID: id, Type: spatial.EntityAgent, Name: "Instructed",
+		Lat: 51.5007, Lon: -0.1246,
+		Data: &spatial.AgentEntityData{Radius: spatial.AgentRadius, Status: spatial.AgentActive},
+	})
+	t.Cleanup(func() { spatial.Get().Delete(id) })
+
+	form := url.Values{"action": {"delete"}, "prompt": {"watch the weather"}}
+	r := httptest.NewRequest("POST", "/agents/"+id, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	AgentsHandler(w, r)
+
+	if spatial.Get().GetByID(id) != nil {
+		t.Fatalf("deleted agent stored again: %s", w.Body)
+	}
+	var resp struct {
+		Ignored []string `json:"ignored"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Ignored) != 1 || resp.Ignored[0] != "prompt" {
+		t.Errorf("response %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	"malten.ai/spatial"
+)
+
+// exportFormat reads the format from /tracks/me.gpx or /routes/me.geojson
+// Only "me" exists: exports are always the caller's own session
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch path.Base(r.URL.Path) {
+	case "me.gpx":
+		return "gpx", true
+	case "me.geojson":
+		return "geojson", true
+	}
+	http.NotFound(w, r)
+	return "", false
+}
+
+// writeExport sends GPX or GeoJSON as a download
+func writeExport(w http.ResponseWriter, kind, format string, gpx func() ([]byte, error), geojson func() interface{}) {
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, spatial.ExportFilename(kind, format)))
+	if format == "geojson" {
+		w.Header().Set("Content-Type", "application/geo+json")
+		json.NewEncoder(w).Encode(geojson())
+		return
+	}
+	b, err := gpx()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/gpx+xml")
+	w.Write(b)
+}
+
+// TracksHandler exports the session's recorded track
+// GET /tracks/me.gpx or /tracks/me.geojson, ?simplify=<metres> to thin it out
+func TracksHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	var epsilon float64
+	if s := r.URL.Query().Get("simplify"); s != "" {
+		var err error
+		if epsilon, err = strconv.ParseFloat(s, 64); err != nil || epsilon < 0 {
+			http.Error(w, "simplify must be metres", http.StatusBadRequest)
+			return
+		}
+	}
+
+	segments := spatial.SessionTrack(getSessionToken(w, r), epsilon)
+	if len(segments) == 0 {
+		http.Error(w, "no track recorded yet", http.StatusNotFound)
+		return
+	}
+	writeExport(w, "track", format,
+		func() ([]byte, error) { return spatial.TrackGPX(segments, "My track") },
+		func() interface{} { return spatial.TrackGeoJSON(segments, "My track") })
+}
+
+// RoutesHandler exports the session's latest route from directions, journeys or errands
+// GET /routes/me.gpx or /routes/me.geojson
+func RoutesHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	route, name := spatial.SessionRoute(getSessionToken(w, r))
+	if route == nil {
+		http.Error(w, "no route to export, ask for directions first", http.StatusNotFound)
+		return
+	}
+	writeExport(w, "route", format,
+		func() ([]byte, error) { return spatial.RouteGPX(route, name) },
+		func() interface{} { return spatial.RouteGeoJSON(route, name) })
+}
@@ -421,8 +421,9 @@ func DebugHandler(w http.ResponseWriter, r *http.Request) {
 			"arrivals": stats.Arrivals,
 			"places":   stats.Places,
 		},
-		"cache":  cacheStats,
-		"uptime": time.Since(startTime).Round(time.Second).String(),
+		"cache":     cacheStats,
+		"scheduler": spatial.AgentScheduler().Stats(),
+		"uptime":    time.Since(startTime).Round(time.Second).String(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -43,6 +43,7 @@ type MapAgent struct {
 	Lon      float64 `json:"lon"`
 	Radius   float64 `json:"radius"`
 	Status   string  `json:"status"`
+	Health   string  `json:"health"`
 	POICount int     `json:"poiCount"`
 }
 
@@ -127,6 +128,7 @@ func MapHandler(w http.ResponseWriter, r *http.Request) {
 			Lon:      a.Lon,
 			Radius:   agentRadius,
 			Status:   status,
+			Health:   spatial.GetAgentHealth(a).Status,
 			POICount: poiCount,
 		})
 	}
@@ -242,3 +244,42 @@ func MapHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// IsochroneHandler handles GET /isochrone?lat=..&lon=..&minutes=10&mode=walk
+// Returns the reachable area as a GeoJSON Feature for the map
+func IsochroneHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(q.Get("lon"), 64)
+	if latErr != nil || lonErr != nil {
+		http.Error(w, "lat and lon required", http.StatusBadRequest)
+		return
+	}
+	minutes := 10
+	if m := q.Get("minutes"); m != "" {
+		var err error
+		if minutes, err = strconv.Atoi(m); err != nil {
+			http.Error(w, "bad minutes", http.StatusBadRequest)
+			return
+		}
+	}
+	profile := spatial.ProfileFor(q.Get("mode"))
+	if profile == nil {
+		http.Error(w, "mode must be walk, cycle, drive or wheelchair", http.StatusBadRequest)
+		return
+	}
+
+	iso, err := spatial.ComputeIsochrone(lat, lon, minutes, profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(iso.GeoJSON())
+}
@@ -10,6 +10,7 @@ import (
 
 	webpush "github.com/SherClockHolmes/webpush-go"
 	"malten.ai/data"
+	"malten.ai/spatial"
 )
 
 // Type aliases - use data package types
@@ -70,6 +71,9 @@ func GetPushManager() *PushManager {
 // load copies from data.Subscriptions to local map
 func (pm *PushManager) load() {
 	for _, u := range data.Subscriptions().GetAllUsers() {
+		if u.Lat != 0 || u.Lon != 0 {
+			u.Timezone = spatial.TimezoneAt(u.Lat, u.Lon)
+		}
 		pm.users[u.SessionID] = u
 	}
 }
@@ -122,9 +126,7 @@ func (pm *PushManager) UpdateLocation(sessionID string, lat, lon float64) {
 	user.Lon = lon
 	user.LastPing = time.Now()
 
-	// Estimate timezone from longitude (rough: 15° per hour)
-	offsetHours := int(lon / 15)
-	user.Timezone = time.FixedZone("local", offsetHours*3600)
+	user.Timezone = spatial.TimezoneAt(lat, lon)
 }
 
 // backgroundLoop runs once per minute, checks for morning notification
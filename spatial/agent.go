@@ -53,14 +53,9 @@ Index and maintain:
 - Prayer times
 Update live data every 30 seconds. Re-index static POIs daily.`
 
-// CreateAgent creates a new agent with a prompt
+// CreateAgent creates a new agent. Callers claim it a territory tile,
+// which is what keeps agents from overlapping
 func (d *DB) CreateAgent(lat, lon, radius float64, name string) *Entity {
-	// Safety check: don't create if one already exists within 1km
-	if existing := d.FindAgent(lat, lon, 1000); existing != nil {
-		log.Printf("[agent] Not creating %s - agent %s already covers this area", name, existing.Name)
-		return existing
-	}
-
 	agent := &Entity{
 		ID:   GenerateID(EntityAgent, lat, lon, name),
 		Type: EntityAgent,
@@ -75,6 +70,7 @@ func (d *DB) CreateAgent(lat, lon, radius float64, name string) *Entity {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	clearDeleted(agent.ID)
 	d.Insert(agent)
 	return agent
 }
@@ -82,17 +78,23 @@ func (d *DB) CreateAgent(lat, lon, radius float64, name string) *Entity {
 // FindOrCreateAgent finds or creates an agent for a location
 // Uses cached name or generic name to avoid blocking on geocoding
 func (d *DB) FindOrCreateAgent(lat, lon float64) *Entity {
-	// Check cache first - avoid any external calls
-	existing := d.FindAgent(lat, lon, 1000)
-	if existing != nil {
+	// Check the territory index first - avoid any external calls
+	if existing := d.TerritoryAgent(lat, lon); existing != nil {
 		return existing
 	}
 
 	// Use generic name immediately - geocode in background
 	areaName := fmt.Sprintf("Area %.2f,%.2f", lat, lon)
 	agent := d.FindOrCreateAgentNamed(lat, lon, areaName)
+	if agent.Name == areaName {
+		d.nameAgentAsync(agent)
+	}
+	return agent
+}
 
-	// Update name asynchronously after geocoding
+// nameAgentAsync replaces an agent's generic name once geocoding finds a better one
+func (d *DB) nameAgentAsync(agent *Entity) {
+	areaName, lat, lon := agent.Name, agent.Lat, agent.Lon
 	go func() {
 		if name := ReverseGeocode(lat, lon); name != "" && name != areaName {
 			agent.Name = name
@@ -101,124 +103,103 @@ func (d *DB) FindOrCreateAgent(lat, lon float64) *Entity {
 			log.Printf("[agent] Renamed %s to %s", areaName, name)
 		}
 	}()
-
-	return agent
 }
 
-// FindOrCreateAgentNamed finds agent by location (within 1km) or creates one
+// FindOrCreateAgentNamed returns the agent whose territory covers the
+// location, or creates one owning a new tile there
 func (d *DB) FindOrCreateAgentNamed(lat, lon float64, name string) *Entity {
-	// Check if agent already exists within 1km - prevents duplicates
-	existing := d.FindAgent(lat, lon, 1000)
-	if existing != nil {
+	territoriesMu.Lock()
+	d.loadTerritoriesLocked()
+	if existing := d.territoryAgentLocked(lat, lon); existing != nil {
+		territoriesMu.Unlock()
 		return existing
 	}
 
 	// Also check by base name (without region suffix like ", Greater London")
-	// This catches cases where Nominatim returns different names for similar locations
-	baseName := name
-	if idx := strings.Index(name, ", "); idx > 0 {
-		baseName = name[:idx]
-	}
+	// Nominatim can name one place differently, so an agent of the same name
+	// that already reaches here serves it rather than a twin next door
 	for _, agent := range d.ListAgents() {
-		agentBase := agent.Name
-		if idx := strings.Index(agent.Name, ", "); idx > 0 {
-			agentBase = agent.Name[:idx]
-		}
-		if agentBase == baseName {
+		if agentBaseName(agent.Name) == agentBaseName(name) && haversineMeters(lat, lon, agent.Lat, agent.Lon) <= agentReach(agent) {
+			territoriesMu.Unlock()
 			log.Printf("[agent] Not creating %s - similar agent %s exists", name, agent.Name)
 			return agent
 		}
 	}
 
+	tile := newTile(territories, lat, lon)
 	agent := d.CreateAgent(lat, lon, AgentRadius, name)
+	claimTile(agent, tile, lat, lon)
+	territoriesMu.Unlock()
+
+	log.Printf("[agent] %s covers tile %s", name, tile)
 	StartAgentLoop(agent)
 	return agent
 }
 
-// Track running agent loops
-var runningAgents = make(map[string]bool)
-var runningAgentsMu sync.Mutex
-
-// StartAgentLoop starts the continuous agent loop
-func StartAgentLoop(agent *Entity) {
-	runningAgentsMu.Lock()
-	if runningAgents[agent.ID] {
-		runningAgentsMu.Unlock()
-		return // Already running
+// agentBaseName is an agent's name without its region suffix
+func agentBaseName(name string) string {
+	if idx := strings.Index(name, ", "); idx > 0 {
+		return name[:idx]
 	}
-	runningAgents[agent.ID] = true
-	runningAgentsMu.Unlock()
+	return name
+}
 
-	go agentLoop(agent)
+// agentReach is how far an agent's indexing and live data reach
+func agentReach(agent *Entity) float64 {
+	if ad := agent.GetAgentData(); ad != nil && ad.Radius > 0 {
+		return ad.Radius
+	}
+	return AgentRadius
 }
 
-func agentLoop(agent *Entity) {
+// StartAgentLoop queues an agent's recurring work with the scheduler:
+// live data every 30s and a POI index now and daily
+func StartAgentLoop(agent *Entity) {
 	region := GetRegion(agent.Lat, agent.Lon)
 	regionName := "unknown"
 	if region != nil {
 		regionName = region.Name
 	}
-	log.Printf("[agent] %s started (region: %s)", agent.Name, regionName)
-
-	// Start data loop immediately (don't wait for POI index)
-	go func() {
-		// Add random initial delay (0-30s) to spread out agent updates
-		initialDelay := time.Duration(time.Now().UnixNano()%30000) * time.Millisecond
-		time.Sleep(initialDelay)
-
-		updateLiveData(agent)
-
-		// Continuous loop: 30s base + 0-5s jitter
-		for {
-			jitter := time.Duration(time.Now().UnixNano()%5000) * time.Millisecond
-			time.Sleep(30*time.Second + jitter)
-			updateLiveData(agent)
+	if state := AgentState(agent); state != AgentActive {
+		if state == AgentHibernating {
+			AgentScheduler().Hibernating(agent.ID)
 		}
-	}()
+		log.Printf("[agent] %s is %s (region: %s)", agent.Name, state, regionName)
+		return
+	}
+	log.Printf("[agent] %s started (region: %s)", agent.Name, regionName)
 
-	// POI index runs in parallel (takes longer)
-	IndexAgent(agent)
+	sched := AgentScheduler()
+	now := time.Now()
+	// Spread first refreshes so a restart doesn't fire every agent at once
+	sched.Schedule(agent, TaskLive, now.Add(liveStartDelay()), true)
+	sched.Schedule(agent, TaskIndex, now, true)
 }
 
+// updateLiveData runs the agent's tasks (see tasks.go) that are due
 func updateLiveData(agent *Entity) {
 	db := Get()
+	stop := agentStop(agent)
 
-	// Location name (reverse geocode) - only if not cached
-	// fetchLocation inserts under lock, returns entity or nil
-	fetchLocation(agent.Lat, agent.Lon)
-
-	// Weather - fetchWeather inserts under lock
-	fetchWeather(agent.Lat, agent.Lon)
-
-	// Prayer times - fetchPrayerTimes inserts under lock
-	fetchPrayerTimes(agent.Lat, agent.Lon)
-
-	// Transport arrivals (buses, tubes, trains)
-	// fetchTransportArrivals inserts under lock, returns entities for counting
-	var totalArrivals int
-
-	// Buses
-	busArrivals := fetchTransportArrivals(agent.Lat, agent.Lon, "NaptanPublicBusCoachTram", "🚌")
-	if busArrivals != nil {
-		if len(busArrivals) > 0 {
-			totalArrivals += len(busArrivals)
-		} else {
-			// API returned empty - extend existing arrivals TTL
-			db.ExtendArrivalsTTL(agent.Lat, agent.Lon, 500)
+	var ran, stored int
+	for _, task := range AgentTasks(agent) {
+		// Paused, moved or deleted since this started
+		if stopped(stop) {
+			return
+		}
+		if !taskDue(agent, task, time.Now()) {
+			continue
 		}
+		stored += runLiveTask(agent, task)
+		ran++
+	}
+	if stored > 0 {
+		log.Printf("[agent] %s ran %d tasks, stored %d entities", agent.Name, ran, stored)
 	}
-	// nil = skipped because fresh cache exists, don't extend TTL
-
-	// Tube stations
-	tubeArrivals := fetchTransportArrivals(agent.Lat, agent.Lon, "NaptanMetroStation", "🚇")
-	totalArrivals += len(tubeArrivals)
-
-	// Rail stations (Overground, National Rail)
-	railArrivals := fetchTransportArrivals(agent.Lat, agent.Lon, "NaptanRailStation", "🚆")
-	totalArrivals += len(railArrivals)
 
-	if totalArrivals > 0 {
-		log.Printf("[agent] %s refreshed %d arrivals", agent.Name, totalArrivals)
+	// Writing this agent back would undo a move or delete
+	if stopped(stop) {
+		return
 	}
 
 	// Update agent timestamp
@@ -231,7 +212,7 @@ func updateLiveData(agent *Entity) {
 
 }
 
-// recoverStaleAgents starts loops for all agents
+// recoverStaleAgents schedules all agents
 func (d *DB) recoverStaleAgents() {
 	agents := d.ListAgents()
 	log.Printf("[agent] Recovering %d agents", len(agents))
@@ -240,132 +221,167 @@ func (d *DB) recoverStaleAgents() {
 	}
 }
 
-// IndexAgent indexes POIs in agent's territory
-func IndexAgent(agent *Entity) {
+// indexCategories are the OSM tags IndexAgent fetches places for
+var indexCategories = []string{
+	// Food & drink
+	"amenity=cafe", "amenity=restaurant", "amenity=fast_food",
+	"amenity=pub", "amenity=bar",
+	// Health
+	"amenity=pharmacy", "amenity=hospital", "amenity=clinic",
+	"amenity=dentist", "amenity=doctors",
+	// Transport
+	"railway=station", "railway=halt",
+	"highway=bus_stop", "amenity=bus_station",
+	"public_transport=station",
+	// Services
+	"amenity=bank", "amenity=atm", "amenity=post_office",
+	"amenity=fuel", "amenity=parking",
+	// Shopping
+	"shop=supermarket", "shop=convenience", "shop=bakery",
+	"shop=butcher", "shop=greengrocer",
+	// Entertainment
+	"amenity=cinema", "amenity=theatre",
+	// Other
+	"amenity=place_of_worship", "tourism=hotel",
+	"leisure=park", "amenity=library",
+}
+
+// indexCategoryLimit is the most places an index keeps per category
+const indexCategoryLimit = 50
+
+// IndexAgent indexes POIs in agent's territory, returning how many it stored
+// and the last category that failed
+func IndexAgent(agent *Entity) (int, error) {
 	if agent == nil || agent.Type != EntityAgent {
-		return
+		return 0, nil
+	}
+
+	agentData := agent.GetAgentData()
+	radius := AgentRadius
+	if agentData != nil && agentData.Radius > 0 {
+		radius = agentData.Radius // Reaches the whole territory tile
 	}
 
 	// Check if we already have recent POI data for this area (last 24h)
 	db := Get()
-	recentPOIs := db.Query(agent.Lat, agent.Lon, AgentRadius, EntityPlace, 100)
+	recentPOIs := db.Query(agent.Lat, agent.Lon, radius, EntityPlace, 100)
 	if len(recentPOIs) > 20 {
 		// Already have decent POI coverage, skip indexing
 		log.Printf("[spatial] Skipping index for %s - have %d POIs", agent.Name, len(recentPOIs))
-		return
+		return 0, nil
 	}
 
 	// Use TryLock to avoid blocking on startup
 	// If another agent is indexing, skip this round
 	if !indexMu.TryLock() {
 		log.Printf("[spatial] Skipping index for %s - another agent indexing", agent.Name)
-		return
+		return 0, nil
 	}
 	defer indexMu.Unlock()
 
 	log.Printf("[spatial] Indexing %s", agent.Name)
 
-	agentData := agent.GetAgentData()
-	radius := AgentRadius
-	if agentData != nil && agentData.Radius > 0 {
-		radius = agentData.Radius
-	}
+	stop := agentStop(agent)
 
 	// Set status to indexing
 	if agentData == nil {
 		agentData = &AgentEntityData{Radius: radius}
 	}
-	agentData.Status = "indexing"
+	if AgentState(agent) == AgentActive {
+		agentData.Status = "indexing"
+	}
+	// Writing this agent back would undo a move or delete
+	if stopped(stop) {
+		return 0, nil
+	}
 	agent.Data = agentData
 	Get().Insert(agent)
 
-	categories := []string{
-		// Food & drink
-		"amenity=cafe", "amenity=restaurant", "amenity=fast_food",
-		"amenity=pub", "amenity=bar",
-		// Health
-		"amenity=pharmacy", "amenity=hospital", "amenity=clinic",
-		"amenity=dentist", "amenity=doctors",
-		// Transport
-		"railway=station", "railway=halt",
-		"highway=bus_stop", "amenity=bus_station",
-		"public_transport=station",
-		// Services
-		"amenity=bank", "amenity=atm", "amenity=post_office",
-		"amenity=fuel", "amenity=parking",
-		// Shopping
-		"shop=supermarket", "shop=convenience", "shop=bakery",
-		"shop=butcher", "shop=greengrocer",
-		// Entertainment
-		"amenity=cinema", "amenity=theatre",
-		// Other
-		"amenity=place_of_worship", "tourism=hotel",
-		"leisure=park", "amenity=library",
-	}
-
 	var totalCount int
-	for _, cat := range categories {
-		count := indexCategory(agent, cat, radius)
+	var lastErr error
+	for _, cat := range indexCategories {
+		if stopped(stop) {
+			log.Printf("[spatial] Stopped indexing %s", agent.Name)
+			return totalCount, lastErr
+		}
+		count, err := indexCategory(agent, cat, radius)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", cat, err)
+		}
 		totalCount += count
 		time.Sleep(OSMRateLimit)
 	}
 
 	log.Printf("[spatial] %s indexed %d POIs", agent.Name, totalCount)
 
+	// Moved or deleted during the last category
+	if stopped(stop) {
+		return totalCount, lastErr
+	}
+
 	// Update agent with final stats
 	agentData = agent.GetAgentData()
 	if agentData == nil {
 		agentData = &AgentEntityData{Radius: radius}
 	}
-	agentData.Status = "active"
+	agentData.Status = AgentState(agent)
 	agentData.POICount = totalCount
 	now := time.Now()
 	agentData.LastIndex = &now
 	agent.Data = agentData
 	Get().Insert(agent)
+	return totalCount, lastErr
 }
 
-func indexCategory(agent *Entity, category string, radius float64) int {
+func indexCategory(agent *Entity, category string, radius float64) (int, error) {
 	query := fmt.Sprintf(`
 [out:json][timeout:25];
 (
   node[%s](around:%.0f,%f,%f);
   way[%s](around:%.0f,%f,%f);
 );
-out center 50;
-`, category, radius, agent.Lat, agent.Lon, category, radius, agent.Lat, agent.Lon)
+out center %d;
+`, category, radius, agent.Lat, agent.Lon, category, radius, agent.Lat, agent.Lon, indexCategoryLimit)
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.PostForm(OverpassURL, url.Values{"data": {query}})
 	if err != nil {
 		log.Printf("[index] %s %s: request failed: %v", agent.Name, category, err)
-		return 0
+		return 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		log.Printf("[index] %s %s: status %d", agent.Name, category, resp.StatusCode)
-		return 0
+		return 0, fmt.Errorf("status %d", resp.StatusCode)
 	}
 
 	var data struct {
-		Elements []struct {
-			Type   string  `json:"type"`
-			ID     int64   `json:"id"`
-			Lat    float64 `json:"lat"`
-			Lon    float64 `json:"lon"`
-			Center *struct {
-				Lat float64 `json:"lat"`
-				Lon float64 `json:"lon"`
-			} `json:"center,omitempty"`
-			Tags map[string]string `json:"tags"`
-		} `json:"elements"`
+		Elements []overpassElement `json:"elements"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return 0
+		return 0, err
 	}
 
+	return storeIndexed(agent, category, data.Elements), nil
+}
+
+// overpassElement is a node, or a way with its center, from Overpass
+type overpassElement struct {
+	Type   string  `json:"type"`
+	ID     int64   `json:"id"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Center *struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"center,omitempty"`
+	Tags map[string]string `json:"tags"`
+}
+
+// storeIndexed stores a category's elements as the agent's places
+func storeIndexed(agent *Entity, category string, elements []overpassElement) int {
 	// Extract category name from tag=value format
 	cat := category
 	if idx := strings.Index(category, "="); idx > 0 {
@@ -373,7 +389,7 @@ out center 50;
 	}
 
 	db := Get()
-	for _, el := range data.Elements {
+	for _, el := range elements {
 		lat, lon := el.Lat, el.Lon
 		if lat == 0 && el.Center != nil {
 			lat, lon = el.Center.Lat, el.Center.Lon
@@ -403,12 +419,19 @@ out center 50;
 		db.Insert(entity)
 	}
 
-	return len(data.Elements)
+	return len(elements)
 }
 
 // ReverseGeocode gets area name from coordinates
 // Returns "Suburb, City" or "Town, County" format for clarity
 func ReverseGeocode(lat, lon float64) string {
+	// Indexed location cells often already know the suburb
+	if name := reverseGeocodeAreaLocal(lat, lon); name != "" {
+		GetCacheStats().RecordLocationLocal()
+		return name
+	}
+
+	GetCacheStats().RecordLocationNominatim()
 	url := fmt.Sprintf("%s/reverse?lat=%f&lon=%f&format=json&zoom=14", NominatimURL, lat, lon)
 	resp, err := LocationGet(url)
 	if err != nil {
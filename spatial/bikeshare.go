@@ -0,0 +1,566 @@
+package spatial
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tflBikePointURL   = tflBaseURL + "/BikePoint"
+	tflBikeNetwork    = "Santander Cycles"
+	bikeDockTTL       = 3 * time.Minute // Availability changes quickly
+	bikeFetchInterval = 2 * time.Minute // Refetch before stored docks expire
+	bikeDockRadius    = 1500.0          // Docks stored per agent refresh
+	BikeNearbyRadius  = 800.0           // Docks worth walking to
+)
+
+// BikeShareSystem is a GBFS bike-share system bound to a region
+// TfL's BikePoint API covers London and isn't configured here
+type BikeShareSystem struct {
+	Name   string `json:"name"`
+	GBFS   string `json:"gbfs"`   // Auto-discovery URL (gbfs.json)
+	Region string `json:"region"` // Known region name (see regions.go)
+}
+
+type bikeShareConfig struct {
+	Updated string            `json:"updated"`
+	Systems []BikeShareSystem `json:"systems"`
+}
+
+// bikeNetwork caches the last fetch of a whole network
+// Networks are fetched once per interval and shared by all agents in them
+type bikeNetwork struct {
+	docks       []*Entity
+	fetchedAt   time.Time // Last successful fetch
+	attemptedAt time.Time // Last attempt, so failures aren't retried every tick
+}
+
+var (
+	bikeSystems  []BikeShareSystem
+	bikeMu       sync.Mutex
+	bikeNetworks = make(map[string]*bikeNetwork) // Network name -> last fetch
+	bikeStoredAt = make(map[string]time.Time)    // Agent cell -> network fetch last stored
+)
+
+func init() {
+	loadBikeShareSystems()
+}
+
+func loadBikeShareSystems() {
+	// Find data directory relative to source file
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		return
+	}
+	configFile := filepath.Join(filepath.Dir(filename), "..", "data", "bikeshare_systems.json")
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		log.Printf("[bikes] Could not load bikeshare_systems.json, TfL only: %v", err)
+		return
+	}
+
+	var cfg bikeShareConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		log.Printf("[bikes] Could not parse bikeshare_systems.json: %v", err)
+		return
+	}
+	bikeSystems = cfg.Systems
+	log.Printf("[bikes] Loaded %d GBFS systems (updated %s)", len(bikeSystems), cfg.Updated)
+}
+
+// fetchBikeDocks refreshes bike-share networks covering the location and
+// stores docks near it with a short TTL. Returns the stored entities, or nil
+// if there's no network here or nothing new since the last store, and the
+// last network fetch error.
+func fetchBikeDocks(lat, lon float64) ([]*Entity, error) {
+	var networks []string
+	fetchers := make(map[string]func() ([]*Entity, error))
+	if IsLondon(lat, lon) {
+		networks = append(networks, tflBikeNetwork)
+		fetchers[tflBikeNetwork] = fetchTfLBikePoints
+	}
+	if r := GetRegion(lat, lon); r != nil {
+		for _, sys := range bikeSystems {
+			if sys.Region == r.Name {
+				sys := sys
+				networks = append(networks, sys.Name)
+				fetchers[sys.Name] = func() ([]*Entity, error) { return fetchGBFS(sys) }
+			}
+		}
+	}
+	if len(networks) == 0 {
+		return nil, nil
+	}
+
+	db := Get()
+	cell := fmt.Sprintf("%.3f,%.3f", lat, lon)
+	var stored []*Entity
+	var lastErr error
+	for _, name := range networks {
+		network, err := refreshBikeNetwork(name, fetchers[name])
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", name, err)
+		}
+		if network == nil {
+			continue
+		}
+
+		key := name + "@" + cell
+		bikeMu.Lock()
+		fresh := network.fetchedAt.After(bikeStoredAt[key])
+		if fresh {
+			pruneBikeStored(network.fetchedAt)
+			bikeStoredAt[key] = network.fetchedAt
+		}
+		bikeMu.Unlock()
+		if !fresh {
+			continue
+		}
+
+		expiry := network.fetchedAt.Add(bikeDockTTL)
+		for _, dock := range network.docks {
+			if haversineMeters(lat, lon, dock.Lat, dock.Lon) > bikeDockRadius {
+				continue
+			}
+			e := *dock
+			e.ExpiresAt = &expiry
+			db.Insert(&e)
+			stored = append(stored, &e)
+		}
+	}
+	if len(stored) > 0 {
+		log.Printf("[bikes] Stored %d docks near %.4f,%.4f", len(stored), lat, lon)
+	}
+	return stored, lastErr
+}
+
+// pruneBikeStored forgets cells whose docks have expired, so the map doesn't
+// grow with every cell an agent has been in. Caller holds bikeMu
+func pruneBikeStored(now time.Time) {
+	for key, at := range bikeStoredAt {
+		if now.Sub(at) > bikeDockTTL {
+			delete(bikeStoredAt, key)
+		}
+	}
+}
+
+// refreshBikeNetwork returns the cached network, fetching it if due
+// Only one caller fetches; others get the previous copy meanwhile
+func refreshBikeNetwork(name string, fetch func() ([]*Entity, error)) (*bikeNetwork, error) {
+	bikeMu.Lock()
+	network := bikeNetworks[name]
+	if network == nil {
+		network = &bikeNetwork{}
+		bikeNetworks[name] = network
+	}
+	due := time.Since(network.attemptedAt) >= bikeFetchInterval
+	if due {
+		network.attemptedAt = time.Now()
+	}
+	current := *network
+	bikeMu.Unlock()
+
+	if !due {
+		if current.fetchedAt.IsZero() {
+			return nil, nil
+		}
+		return &current, nil
+	}
+
+	docks, err := fetch()
+	if err != nil {
+		log.Printf("[bikes] %s fetch error: %v", name, err)
+		return nil, err
+	}
+
+	bikeMu.Lock()
+	network.docks = docks
+	network.fetchedAt = time.Now()
+	current = *network
+	bikeMu.Unlock()
+	log.Printf("[bikes] Fetched %d %s docks", len(docks), name)
+	return &current, nil
+}
+
+// RefreshBikeDocks fetches docks near a location now, for queries before
+// the area's agent has run
+func RefreshBikeDocks(lat, lon float64) {
+	fetchBikeDocks(lat, lon)
+}
+
+// =============================================================================
+// TfL BikePoint
+// =============================================================================
+
+// tflBikePoint mirrors the TfL BikePoint response
+type tflBikePoint struct {
+	ID                   string  `json:"id"`
+	CommonName           string  `json:"commonName"`
+	Lat                  float64 `json:"lat"`
+	Lon                  float64 `json:"lon"`
+	AdditionalProperties []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"additionalProperties"`
+}
+
+func fetchTfLBikePoints() ([]*Entity, error) {
+	resp, err := TfLGet(tflBikePointURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("TfL returned status %d", resp.StatusCode)
+	}
+
+	var points []tflBikePoint
+	if err := json.NewDecoder(resp.Body).Decode(&points); err != nil {
+		return nil, err
+	}
+	return parseTfLBikePoints(points), nil
+}
+
+func parseTfLBikePoints(points []tflBikePoint) []*Entity {
+	var docks []*Entity
+	for _, p := range points {
+		props := make(map[string]string)
+		for _, ap := range p.AdditionalProperties {
+			props[ap.Key] = ap.Value
+		}
+		atoi := func(key string) int {
+			n, _ := strconv.Atoi(props[key])
+			return n
+		}
+
+		bd := &BikeDockData{
+			DockID:     p.ID,
+			Network:    tflBikeNetwork,
+			EBikes:     atoi("NbEBikes"),
+			EmptyDocks: atoi("NbEmptyDocks"),
+			TotalDocks: atoi("NbDocks"),
+			Renting:    props["Installed"] != "false" && props["Locked"] != "true",
+		}
+		// NbBikes includes e-bikes; NbStandardBikes is newer and may be missing
+		if _, ok := props["NbStandardBikes"]; ok {
+			bd.Bikes = atoi("NbStandardBikes")
+		} else {
+			bd.Bikes = atoi("NbBikes") - bd.EBikes
+		}
+		docks = append(docks, bikeDockEntity(cleanDockName(p.CommonName), p.Lat, p.Lon, bd))
+	}
+	return docks
+}
+
+// cleanDockName tidies TfL names like "River Street , Clerkenwell"
+func cleanDockName(name string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(name, " ,", ",")), " ")
+}
+
+func bikeDockEntity(name string, lat, lon float64, bd *BikeDockData) *Entity {
+	return &Entity{
+		ID:   GenerateID(EntityBikeDock, 0, 0, bd.Network+":"+bd.DockID),
+		Type: EntityBikeDock,
+		Name: name,
+		Lat:  lat,
+		Lon:  lon,
+		Data: bd,
+	}
+}
+
+// =============================================================================
+// GBFS (General Bikeshare Feed Specification) v1-v3
+// See https://github.com/MobilityData/gbfs
+// =============================================================================
+
+// gbfsBool accepts GBFS booleans encoded as true/false or 1/0
+type gbfsBool bool
+
+func (b *gbfsBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = gbfsBool(s == "true" || s == "1")
+	return nil
+}
+
+// gbfsText accepts a plain string (v1, v2) or localized strings (v3)
+type gbfsText string
+
+func (t *gbfsText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = gbfsText(s)
+		return nil
+	}
+	var localized []struct {
+		Text     string `json:"text"`
+		Language string `json:"language"`
+	}
+	if err := json.Unmarshal(data, &localized); err != nil {
+		return err
+	}
+	for _, l := range localized {
+		if *t == "" || strings.HasPrefix(l.Language, "en") {
+			*t = gbfsText(l.Text)
+		}
+	}
+	return nil
+}
+
+type gbfsFeed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type gbfsStationInfo struct {
+	Data struct {
+		Stations []struct {
+			StationID string   `json:"station_id"`
+			Name      gbfsText `json:"name"`
+			Lat       float64  `json:"lat"`
+			Lon       float64  `json:"lon"`
+			Capacity  int      `json:"capacity"`
+		} `json:"stations"`
+	} `json:"data"`
+}
+
+type gbfsStationStatus struct {
+	Data struct {
+		Stations []struct {
+			StationID             string   `json:"station_id"`
+			NumBikesAvailable     *int     `json:"num_bikes_available"`    // v1, v2
+			NumVehiclesAvailable  *int     `json:"num_vehicles_available"` // v3
+			NumEBikesAvailable    *int     `json:"num_ebikes_available"`   // Common extension
+			NumDocksAvailable     int      `json:"num_docks_available"`
+			IsInstalled           gbfsBool `json:"is_installed"`
+			IsRenting             gbfsBool `json:"is_renting"`
+			VehicleTypesAvailable []struct {
+				VehicleTypeID string `json:"vehicle_type_id"`
+				Count         int    `json:"count"`
+			} `json:"vehicle_types_available"`
+		} `json:"stations"`
+	} `json:"data"`
+}
+
+type gbfsVehicleTypes struct {
+	Data struct {
+		VehicleTypes []struct {
+			VehicleTypeID  string `json:"vehicle_type_id"`
+			PropulsionType string `json:"propulsion_type"`
+		} `json:"vehicle_types"`
+	} `json:"data"`
+}
+
+// parseGBFSDiscovery returns feed name -> URL from gbfs.json
+// v1/v2 nest feeds by language, v3 lists them directly
+func parseGBFSDiscovery(body []byte) (map[string]string, error) {
+	var doc struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	var feeds []gbfsFeed
+	if raw, ok := doc.Data["feeds"]; ok {
+		if err := json.Unmarshal(raw, &feeds); err != nil {
+			return nil, err
+		}
+	} else {
+		lang := "en"
+		if _, ok := doc.Data[lang]; !ok {
+			for l := range doc.Data {
+				lang = l
+				break
+			}
+		}
+		var localized struct {
+			Feeds []gbfsFeed `json:"feeds"`
+		}
+		if err := json.Unmarshal(doc.Data[lang], &localized); err != nil {
+			return nil, err
+		}
+		feeds = localized.Feeds
+	}
+
+	urls := make(map[string]string)
+	for _, f := range feeds {
+		urls[f.Name] = f.URL
+	}
+	if urls["station_information"] == "" || urls["station_status"] == "" {
+		return nil, fmt.Errorf("no station feeds")
+	}
+	return urls, nil
+}
+
+// parseGBFSStations joins station information and status into dock entities
+// vehicleTypes may be nil; it's used to count e-bikes when status doesn't
+func parseGBFSStations(network string, info, status, vehicleTypes []byte) ([]*Entity, error) {
+	var si gbfsStationInfo
+	if err := json.Unmarshal(info, &si); err != nil {
+		return nil, fmt.Errorf("station_information: %v", err)
+	}
+	var ss gbfsStationStatus
+	if err := json.Unmarshal(status, &ss); err != nil {
+		return nil, fmt.Errorf("station_status: %v", err)
+	}
+
+	electric := make(map[string]bool)
+	if len(vehicleTypes) > 0 {
+		var vt gbfsVehicleTypes
+		if err := json.Unmarshal(vehicleTypes, &vt); err == nil {
+			for _, t := range vt.Data.VehicleTypes {
+				electric[t.VehicleTypeID] = t.PropulsionType != "" && t.PropulsionType != "human"
+			}
+		}
+	}
+
+	type stationStatus struct {
+		bikes, ebikes, empty int
+		renting              bool
+	}
+	statuses := make(map[string]stationStatus)
+	for _, s := range ss.Data.Stations {
+		st := stationStatus{empty: s.NumDocksAvailable, renting: bool(s.IsInstalled) && bool(s.IsRenting)}
+		total := 0
+		if s.NumBikesAvailable != nil {
+			total = *s.NumBikesAvailable
+		} else if s.NumVehiclesAvailable != nil {
+			total = *s.NumVehiclesAvailable
+		}
+		if s.NumEBikesAvailable != nil {
+			st.ebikes = *s.NumEBikesAvailable
+		} else {
+			for _, v := range s.VehicleTypesAvailable {
+				if electric[v.VehicleTypeID] {
+					st.ebikes += v.Count
+				}
+			}
+		}
+		st.bikes = total - st.ebikes
+		if st.bikes < 0 {
+			st.bikes = 0
+		}
+		statuses[s.StationID] = st
+	}
+
+	var docks []*Entity
+	for _, s := range si.Data.Stations {
+		st, ok := statuses[s.StationID]
+		if !ok || s.Lat == 0 || s.Name == "" {
+			continue
+		}
+		bd := &BikeDockData{
+			DockID:     s.StationID,
+			Network:    network,
+			Bikes:      st.bikes,
+			EBikes:     st.ebikes,
+			EmptyDocks: st.empty,
+			TotalDocks: s.Capacity,
+			Renting:    st.renting,
+		}
+		docks = append(docks, bikeDockEntity(string(s.Name), s.Lat, s.Lon, bd))
+	}
+	return docks, nil
+}
+
+func fetchGBFS(sys BikeShareSystem) ([]*Entity, error) {
+	discovery, err := gbfsGet(sys.GBFS)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := parseGBFSDiscovery(discovery)
+	if err != nil {
+		return nil, err
+	}
+	info, err := gbfsGet(urls["station_information"])
+	if err != nil {
+		return nil, err
+	}
+	status, err := gbfsGet(urls["station_status"])
+	if err != nil {
+		return nil, err
+	}
+	var vehicleTypes []byte
+	if u := urls["vehicle_types"]; u != "" {
+		vehicleTypes, _ = gbfsGet(u)
+	}
+	return parseGBFSStations(sys.Name, info, status, vehicleTypes)
+}
+
+func gbfsGet(url string) ([]byte, error) {
+	resp, err := External.Get("gbfs", url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s returned status %d", truncateURL(url), resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// =============================================================================
+// Queries and display
+// =============================================================================
+
+// NearbyBikeDock is a dock with its distance from a point
+type NearbyBikeDock struct {
+	Entity   *Entity
+	Data     *BikeDockData
+	Distance float64 // meters
+}
+
+// GetNearbyBikeDocks returns renting docks within radius, nearest first
+func GetNearbyBikeDocks(lat, lon, radiusMeters float64) []NearbyBikeDock {
+	db := Get()
+	var result []NearbyBikeDock
+	for _, e := range db.Query(lat, lon, radiusMeters, EntityBikeDock, 50) {
+		bd := e.GetBikeDockData()
+		if bd == nil || !bd.Renting {
+			continue
+		}
+		dist := haversineMeters(lat, lon, e.Lat, e.Lon)
+		if dist > radiusMeters {
+			continue
+		}
+		result = append(result, NearbyBikeDock{Entity: e, Data: bd, Distance: dist})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Distance < result[j].Distance
+	})
+	return result
+}
+
+// Summary returns availability like "5 bikes · 2 e-bikes · 8 spaces"
+func (bd *BikeDockData) Summary() string {
+	parts := []string{plural(bd.Bikes, "bike")}
+	if bd.EBikes > 0 {
+		parts = append(parts, plural(bd.EBikes, "e-bike"))
+	}
+	parts = append(parts, plural(bd.EmptyDocks, "space"))
+	return strings.Join(parts, " · ")
+}
+
+// FormatBikeDock formats a dock for display: "🚲 River Street (120m): 5 bikes · 8 spaces"
+func FormatBikeDock(nd NearbyBikeDock) string {
+	return fmt.Sprintf("🚲 %s (%s): %s", nd.Entity.Name, FormatDistance(nd.Distance), nd.Data.Summary())
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
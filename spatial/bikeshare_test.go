@@ -0,0 +1,92 @@
+package spatial
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readGBFS reads a recorded GBFS v2.3 feed file
+func readGBFS(t *testing.T, name string) []byte {
+	b, err := os.ReadFile("testdata/gbfs/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestGBFSDiscovery checks feed URLs are read from the discovery file
+func TestGBFSDiscovery(t *testing.T) {
+	urls, err := parseGBFSDiscovery(readGBFS(t, "gbfs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(urls["station_status"], "/station_status.json") {
+		t.Errorf("station_status url = %q", urls["station_status"])
+	}
+}
+
+// TestGBFSStations checks bike dock parsing against a recorded GBFS feed
+func TestGBFSStations(t *testing.T) {
+	docks, err := parseGBFSStations("Bee Network", readGBFS(t, "station_information.json"),
+		readGBFS(t, "station_status.json"), readGBFS(t, "vehicle_types.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bay-104 has no status and bay-999 no information
+	if len(docks) != 3 {
+		t.Fatalf("got %d docks, want 3", len(docks))
+	}
+
+	testCases := []struct {
+		name                 string
+		bikes, ebikes, empty int
+		renting              bool
+	}{
+		{"Piccadilly Gardens", 4, 3, 13, true},
+		{"Oxford Road Station", 1, 3, 8, true}, // e-bikes from vehicle types
+		{"Deansgate Locks", 0, 0, 10, false},
+	}
+	for i, tc := range testCases {
+		bd := docks[i].GetBikeDockData()
+		if docks[i].Name != tc.name || bd.Bikes != tc.bikes || bd.EBikes != tc.ebikes || bd.EmptyDocks != tc.empty || bd.Renting != tc.renting {
+			t.Errorf("dock %d = %s %+v, want %+v", i, docks[i].Name, bd, tc)
+		}
+	}
+	if got := docks[0].GetBikeDockData().Summary(); got != "4 bikes · 3 e-bikes · 13 spaces" {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+// TestTfLBikePoints checks TfL BikePoint e-bikes, counted inside NbBikes,
+// come off the bikes count
+func TestTfLBikePoints(t *testing.T) {
+	points := []tflBikePoint{{ID: "BikePoints_1", CommonName: "River Street , Clerkenwell", Lat: 51.529, Lon: -0.109}}
+	for k, v := range map[string]string{"NbBikes": "5", "NbEBikes": "2", "NbEmptyDocks": "14", "NbDocks": "19", "Installed": "true", "Locked": "false"} {
+		points[0].AdditionalProperties = append(points[0].AdditionalProperties, struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		}{k, v})
+	}
+	tfl := parseTfLBikePoints(points)
+	if bd := tfl[0].GetBikeDockData(); tfl[0].Name != "River Street, Clerkenwell" || bd.Bikes != 3 || bd.EBikes != 2 || !bd.Renting {
+		t.Errorf("TfL dock = %s %+v", tfl[0].Name, bd)
+	}
+}
+
+// TestPruneBikeStored checks cells whose docks expired are forgotten
+func TestPruneBikeStored(t *testing.T) {
+	now := time.Now()
+	bikeMu.Lock()
+	bikeStoredAt["test@old"] = now.Add(-2 * bikeDockTTL)
+	bikeStoredAt["test@new"] = now
+	pruneBikeStored(now)
+	_, old := bikeStoredAt["test@old"]
+	_, kept := bikeStoredAt["test@new"]
+	delete(bikeStoredAt, "test@new")
+	bikeMu.Unlock()
+	if old || !kept {
+		t.Errorf("pruned old=%v new=%v", !old, !kept)
+	}
+}
@@ -3,11 +3,21 @@ package spatial
 import (
 	"fmt"
 	"sync"
+	"time"
 )
 
+// How long a route from /directions is watched for new disruptions
+const routeWatchTTL = 2 * time.Hour
+
 // SessionContext tracks last context sent to each session
 type sessionEntry struct {
 	ctx *ContextData
+
+	// Route the session is following, watched for disruptions
+	route       [][]float64
+	routeSetAt  time.Time
+	routeWarned map[string]bool // Disruption IDs already reported
+	routeWarnMu sync.Mutex
 }
 
 var (
@@ -23,8 +33,9 @@ type ContextChanges struct {
 	NewPrayer       string
 	WeatherChanged  bool
 	NewWeather      string
-	RainWarning     string // Non-empty if new rain warning
-	BusArriving     string // Non-empty if bus arriving soon (<3 min)
+	RainWarning     string   // Non-empty if new rain warning
+	BusArriving     string   // Non-empty if bus arriving soon (<3 min)
+	LineChanges     []string // Status changes on lines serving nearby stops
 }
 
 // DetectChanges compares old and new context, returns what changed meaningfully
@@ -74,6 +85,24 @@ func DetectChanges(old, new *ContextData) *ContextChanges {
 		}
 	}
 
+	// Line status changed on a line the user was already near
+	oldLines := make(map[string]LineStatusInfo)
+	for _, l := range old.Lines {
+		oldLines[l.ID] = l
+	}
+	for _, l := range new.Lines {
+		prev, ok := oldLines[l.ID]
+		// Lines closing for the night and reopening aren't news
+		if !ok || prev.Severity == l.Severity || prev.Severity == 20 || l.Severity == 20 {
+			continue
+		}
+		if l.Good {
+			changes.LineChanges = append(changes.LineChanges, fmt.Sprintf("✅ %s: %s again", l.Name, l.Status))
+		} else {
+			changes.LineChanges = append(changes.LineChanges, l.Display)
+		}
+	}
+
 	// Bus arriving soon (would need bus info in context)
 	// TODO: implement when bus data is in ContextData
 
@@ -107,8 +136,57 @@ func SetSessionContext(session string, ctx *ContextData) {
 	sessionContexts[session].ctx = ctx
 }
 
+// SetSessionRoute records the route a session is following so new disruptions
+// along it can be pushed. warned holds disruption entities already shown.
+func SetSessionRoute(session string, coords [][]float64, warned []*Entity) {
+	if session == "" || len(coords) == 0 {
+		return
+	}
+	sessionContextsMu.Lock()
+	defer sessionContextsMu.Unlock()
+	if sessionContexts[session] == nil {
+		sessionContexts[session] = &sessionEntry{}
+	}
+	entry := sessionContexts[session]
+	entry.routeWarnMu.Lock()
+	defer entry.routeWarnMu.Unlock()
+	entry.route = coords
+	entry.routeSetAt = time.Now()
+	entry.routeWarned = make(map[string]bool)
+	for _, e := range warned {
+		entry.routeWarned[e.ID] = true
+	}
+}
+
+// routeDisruptionWarnings returns warnings for disruptions newly found on the session's route
+func routeDisruptionWarnings(entry *sessionEntry, lat, lon float64) []string {
+	entry.routeWarnMu.Lock()
+	defer entry.routeWarnMu.Unlock()
+
+	if len(entry.route) == 0 {
+		return nil
+	}
+	// Stop watching once the route is stale or the destination is reached
+	end := entry.route[len(entry.route)-1]
+	if time.Since(entry.routeSetAt) > routeWatchTTL || haversineMeters(lat, lon, end[1], end[0]) < 50 {
+		entry.route = nil
+		entry.routeWarned = nil
+		return nil
+	}
+
+	var fresh []*Entity
+	for _, e := range Get().DisruptionsAlongRoute(entry.route, RouteDisruptionBuffer) {
+		if !entry.routeWarned[e.ID] {
+			entry.routeWarned[e.ID] = true
+			fresh = append(fresh, e)
+		}
+	}
+	return FormatRouteDisruptions(fresh)
+}
+
 // GetContextWithChanges returns context and any meaningful changes to push
 // accuracy is GPS accuracy in meters (0 if unknown), speed is in m/s (0 if unknown)
+// It's the user's ping, so it counts as a visit to the area's agent
 func GetContextWithChanges(session string, lat, lon, accuracy, speed float64) (*ContextData, []string) {
 	entry := getSessionEntry(session)
 	var old *ContextData
@@ -117,6 +195,10 @@ func GetContextWithChanges(session string, lat, lon, accuracy, speed float64) (*
 	}
 	new := GetContextData(lat, lon)
 
+	// Someone's here: this agent's work comes first, and it wakes if asleep.
+	// Pushes and tools also build context, but only a ping is demand
+	AgentVisited(Get().TerritoryAgent(lat, lon))
+
 	changes := DetectChanges(old, new)
 	SetSessionContext(session, new)
 
@@ -139,6 +221,13 @@ func GetContextWithChanges(session string, lat, lon, accuracy, speed float64) (*
 		messages = append(messages, fmt.Sprintf("🌡️ %s", changes.NewWeather))
 	}
 
+	messages = append(messages, changes.LineChanges...)
+
+	// New disruptions on the route the user is following
+	if entry != nil {
+		messages = append(messages, routeDisruptionWarnings(entry, lat, lon)...)
+	}
+
 	return new, messages
 }
 
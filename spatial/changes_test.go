@@ -0,0 +1,33 @@
+package spatial
+
+import "testing"
+
+// testTerritoryAgent stores an agent owning the tile at a point, removed after the test
+func testTerritoryAgent(t *testing.T, id string, lat, lon float64) *Entity {
+	agent := &Entity{ID: id, Type: EntityAgent, Name: "Territory", Lat: lat, Lon: lon,
+		Data: &AgentEntityData{Radius: AgentRadius, Status: AgentActive}}
+	Get().Insert(agent)
+	territoriesMu.Lock()
+	Get().loadTerritoriesLocked()
+	claimTile(agent, Geohash(lat, lon, tilePrecision), lat, lon)
+	territoriesMu.Unlock()
+	t.Cleanup(func() {
+		releaseTerritory(id)
+		Get().Delete(id)
+	})
+	return agent
+}
+
+// TestContextVisit checks a ping counts as a visit to the area's agent,
+// and context built for anything else doesn't
+func TestContextVisit(t *testing.T) {
+	agent := testTerritoryAgent(t, "test-agent-context-visit", 56.5, -4.5)
+	GetContextData(56.5, -4.5)
+	if !AgentScheduler().LastVisit(agent.ID).IsZero() {
+		t.Fatal("context without a ping recorded a visit")
+	}
+	GetContextWithChanges("context-visit-session", 56.5, -4.5, 0, 0)
+	if AgentScheduler().LastVisit(agent.ID).IsZero() {
+		t.Error("ping not recorded as a visit")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
 	"strings"
 	"time"
 )
@@ -16,19 +17,24 @@ type Place struct {
 	Postcode string  `json:"postcode,omitempty"`
 	Phone    string  `json:"phone,omitempty"`
 	Hours    string  `json:"hours,omitempty"`
+	OpenNow  *bool   `json:"open_now,omitempty"`  // nil when hours are unknown
+	ClosesAt string  `json:"closes_at,omitempty"` // HH:MM local time
 	Lat      float64 `json:"lat"`
 	Lon      float64 `json:"lon"`
 }
 
 // ContextData is the structured context response
 type ContextData struct {
-	HTML     string             `json:"html"`     // Formatted display text
-	Location *LocationInfo      `json:"location"` // Where you are
-	Weather  *WeatherInfo       `json:"weather"`  // Current weather
-	Prayer   *PrayerInfo        `json:"prayer"`   // Prayer times
-	Bus      *BusInfo           `json:"bus"`      // Nearest bus
-	Places   map[string][]Place `json:"places"`   // Nearby places by category
-	Agent    *AgentInfo         `json:"agent"`    // Agent for this area
+	HTML        string             `json:"html"`                  // Formatted display text
+	Location    *LocationInfo      `json:"location"`              // Where you are
+	Weather     *WeatherInfo       `json:"weather"`               // Current weather
+	Prayer      *PrayerInfo        `json:"prayer"`                // Prayer times
+	Bus         *BusInfo           `json:"bus"`                   // Nearest bus
+	Disruptions []DisruptionInfo   `json:"disruptions,omitempty"` // Nearby road disruptions
+	Bikes       []BikeDockInfo     `json:"bikes,omitempty"`       // Nearby bike-share docks
+	Lines       []LineStatusInfo   `json:"lines,omitempty"`       // Status of lines serving nearby stops
+	Places      map[string][]Place `json:"places"`                // Nearby places by category
+	Agent       *AgentInfo         `json:"agent"`                 // Agent for this area
 }
 
 type AgentInfo struct {
@@ -59,6 +65,41 @@ type PrayerInfo struct {
 	Display  string `json:"display"` // Formatted: "Asr now · Maghrib 16:06"
 }
 
+type DisruptionInfo struct {
+	ID          string   `json:"id"`
+	Severity    string   `json:"severity"`
+	Category    string   `json:"category"`
+	Roads       []string `json:"roads,omitempty"`
+	Description string   `json:"description"`
+	Closure     bool     `json:"closure,omitempty"`
+	Distance    int      `json:"distance"` // meters
+	Display     string   `json:"display"`  // Formatted: "🚧 1.2km: A3 Kingston Road: ..."
+}
+
+type BikeDockInfo struct {
+	Name       string  `json:"name"`
+	Network    string  `json:"network"`
+	Bikes      int     `json:"bikes"`
+	EBikes     int     `json:"ebikes"`
+	EmptyDocks int     `json:"empty_docks"`
+	Distance   int     `json:"distance"` // meters
+	Lat        float64 `json:"lat"`
+	Lon        float64 `json:"lon"`
+	Display    string  `json:"display"` // Formatted: "🚲 River Street (120 m): 5 bikes · 8 spaces"
+}
+
+type LineStatusInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Mode     string `json:"mode"`
+	Status   string `json:"status"` // "Good Service", "Minor Delays"
+	Severity int    `json:"severity"`
+	Reason   string `json:"reason,omitempty"`
+	Until    string `json:"until,omitempty"` // When the status is due to end
+	Good     bool   `json:"good"`
+	Display  string `json:"display"` // Formatted: "🚇 District: Minor Delays - ..."
+}
+
 type BusInfo struct {
 	StopName string   `json:"stop_name"`
 	Distance int      `json:"distance"` // meters
@@ -74,12 +115,13 @@ func GetContextData(lat, lon float64) *ContextData {
 	}
 	var htmlParts []string
 
-	// Add formatted date/time for AI context
-	now := time.Now()
+	// Add formatted date/time for AI context, in the location's timezone
+	tz := TimezoneAt(lat, lon)
+	now := time.Now().In(tz)
 	htmlParts = append(htmlParts, now.Format("Monday, 2 January 2006 15:04"))
 
 	// Ensure agent exists
-	agent := db.FindAgent(lat, lon, AgentRadius)
+	agent := db.TerritoryAgent(lat, lon)
 	if agent == nil {
 		log.Printf("[context] Creating new agent for %.4f,%.4f", lat, lon)
 		agent = db.FindOrCreateAgent(lat, lon)
@@ -100,13 +142,13 @@ func GetContextData(lat, lon float64) *ContextData {
 		}
 		// Last index time from UpdatedAt
 		if !agent.UpdatedAt.IsZero() {
-			ctx.Agent.LastIndex = agent.UpdatedAt.Format("15:04")
+			ctx.Agent.LastIndex = agent.UpdatedAt.In(tz).Format("15:04")
 		}
 	}
 
 	// Location - find nearest location point or create one
 	// GPS jitter tolerance: 10m - if we have a point within 10m, use it
-	// Otherwise fetch from Nominatim and store a NEW point at these exact coords
+	// Otherwise try the offline geocoder, then Nominatim, and store a NEW point at these exact coords
 	tLoc := time.Now()
 	loc := db.GetNearestLocation(lat, lon, 10) // 10m tolerance for GPS jitter
 	log.Printf("[context] location lookup: %v", time.Since(tLoc))
@@ -115,6 +157,10 @@ func GetContextData(lat, lon float64) *ContextData {
 	if loc != nil {
 		locationName = loc.Name
 		GetCacheStats().RecordLocationHit()
+	} else if local := localLocation(lat, lon); local != nil {
+		// Answered from indexed streets, postcodes and nearby cells
+		locationName = local.Name
+		GetCacheStats().RecordLocationLocal()
 	} else {
 		// No point nearby - fetch in background, don't block
 		// This ensures /ping never waits for Nominatim API
@@ -214,8 +260,25 @@ func GetContextData(lat, lon float64) *ContextData {
 
 	// Traffic disruptions
 	t1 := time.Now()
-	if disruption := getTrafficDisruptions(lat, lon); disruption != "" {
-		htmlParts = append(htmlParts, disruption)
+	for i, nd := range GetNearbyDisruptions(lat, lon, disruptionNearbyKm*1000) {
+		if i >= 3 {
+			break
+		}
+		info := DisruptionInfo{
+			ID:          nd.Data.DisruptionID,
+			Severity:    nd.Data.Severity,
+			Category:    nd.Data.Category,
+			Roads:       nd.Data.Roads,
+			Description: nd.Data.Description,
+			Closure:     nd.Data.Closure,
+			Distance:    int(nd.Distance),
+			Display:     formatNearbyDisruption(nd),
+		}
+		ctx.Disruptions = append(ctx.Disruptions, info)
+	}
+	if len(ctx.Disruptions) > 0 {
+		// Only the closest one makes the display text
+		htmlParts = append(htmlParts, ctx.Disruptions[0].Display)
 	}
 	log.Printf("[context] disruptions: %v", time.Since(t1))
 
@@ -247,6 +310,63 @@ func GetContextData(lat, lon float64) *ContextData {
 	}
 	log.Printf("[context] bus arrivals: %v", time.Since(t2))
 
+	// Line status for lines serving nearby stops
+	for _, ld := range GetNearbyLineStatus(lat, lon) {
+		info := LineStatusInfo{
+			ID:       ld.LineID,
+			Name:     ld.LineName,
+			Mode:     ld.Mode,
+			Status:   ld.Status,
+			Severity: ld.Severity,
+			Reason:   ld.Reason,
+			Good:     ld.IsGood(),
+			Display:  FormatLineStatus(ld),
+		}
+		if ld.ValidTo != nil && !ld.IsGood() {
+			info.Until = ld.ValidTo.In(tz).Format(time.RFC3339)
+		}
+		ctx.Lines = append(ctx.Lines, info)
+	}
+	// Only problems make the display text, worst first
+	var lineProblems []string
+	for _, l := range ctx.Lines {
+		if !l.Good && len(lineProblems) < 2 {
+			lineProblems = append(lineProblems, l.Display)
+		}
+	}
+	if len(lineProblems) > 0 {
+		htmlParts = append(htmlParts, strings.Join(lineProblems, "\n"))
+	}
+
+	// Bike-share docks - cached only, refreshed by the agent
+	for i, nd := range GetNearbyBikeDocks(lat, lon, BikeNearbyRadius) {
+		if i >= 3 {
+			break
+		}
+		ctx.Bikes = append(ctx.Bikes, BikeDockInfo{
+			Name:       nd.Entity.Name,
+			Network:    nd.Data.Network,
+			Bikes:      nd.Data.Bikes,
+			EBikes:     nd.Data.EBikes,
+			EmptyDocks: nd.Data.EmptyDocks,
+			Distance:   int(nd.Distance),
+			Lat:        nd.Entity.Lat,
+			Lon:        nd.Entity.Lon,
+			Display:    FormatBikeDock(nd),
+		})
+	}
+	if len(ctx.Bikes) > 0 {
+		// Closest dock that has a bike, else the closest
+		display := ctx.Bikes[0].Display
+		for _, b := range ctx.Bikes {
+			if b.Bikes+b.EBikes > 0 {
+				display = b.Display
+				break
+			}
+		}
+		htmlParts = append(htmlParts, display)
+	}
+
 	// Places by category
 	categories := []struct {
 		osmTag   string
@@ -262,6 +382,7 @@ func GetContextData(lat, lon float64) *ContextData {
 	// Places - only use cached data, agents handle fetching
 	tPlaces := time.Now()
 	var placeParts []string
+	var closing []closingPlace
 	for _, c := range categories {
 		places := db.QueryPlaces(lat, lon, 500, c.category, 10)
 
@@ -277,20 +398,7 @@ func GetContextData(lat, lon float64) *ContextData {
 					Lat:  p.Lat,
 					Lon:  p.Lon,
 				}
-				// Try typed data first, then legacy map
-				var tags map[string]string
-				if placeData := p.GetPlaceData(); placeData != nil {
-					tags = placeData.Tags
-				} else if m, ok := p.Data.(map[string]interface{}); ok {
-					if tagsRaw, ok := m["tags"].(map[string]interface{}); ok {
-						tags = make(map[string]string)
-						for k, v := range tagsRaw {
-							if s, ok := v.(string); ok {
-								tags[k] = s
-							}
-						}
-					}
-				}
+				tags := PlaceTags(p)
 				if len(tags) > 0 {
 					var addr string
 					if num := tags["addr:housenumber"]; num != "" {
@@ -305,6 +413,19 @@ func GetContextData(lat, lon float64) *ContextData {
 					place.Address = addr
 					place.Postcode = tags["addr:postcode"]
 					place.Hours = tags["opening_hours"]
+					if place.Hours != "" {
+						state := HoursOpenState(place.Hours, p.Lat, p.Lon, now)
+						if state.Known {
+							open := state.Open
+							place.OpenNow = &open
+							if open && !state.ClosesAt.IsZero() {
+								place.ClosesAt = state.ClosesAt.Format("15:04")
+							}
+							if state.ClosingWithin(now, closingSoonWindow) {
+								closing = append(closing, closingPlace{p.Name, state.ClosesAt})
+							}
+						}
+					}
 					if phone := tags["phone"]; phone != "" {
 						place.Phone = phone
 					} else {
@@ -330,12 +451,40 @@ func GetContextData(lat, lon float64) *ContextData {
 	if len(placeParts) > 0 {
 		htmlParts = append(htmlParts, strings.Join(placeParts, " · "))
 	}
+	if hint := closingSoonHint(closing, now); hint != "" {
+		htmlParts = append(htmlParts, hint)
+	}
 
 	ctx.HTML = strings.Join(htmlParts, "\n")
 	log.Printf("[context] GetContextData took %v", time.Since(start))
 	return ctx
 }
 
+// closingSoonWindow is how far ahead closing places are flagged
+const closingSoonWindow = 30 * time.Minute
+
+type closingPlace struct {
+	name     string
+	closesAt time.Time
+}
+
+// closingSoonHint returns "⏳ Costa closes in 20 min" for the soonest closing places
+func closingSoonHint(closing []closingPlace, now time.Time) string {
+	if len(closing) == 0 {
+		return ""
+	}
+	sort.Slice(closing, func(i, j int) bool { return closing[i].closesAt.Before(closing[j].closesAt) })
+	var parts []string
+	for i, c := range closing {
+		if i >= 2 {
+			break
+		}
+		mins := int(math.Ceil(c.closesAt.Sub(now).Minutes()))
+		parts = append(parts, fmt.Sprintf("%s closes in %d min", c.name, mins))
+	}
+	return "⏳ " + strings.Join(parts, " · ")
+}
+
 // GetContextJSON returns context as JSON string
 func GetContextJSON(lat, lon float64) string {
 	ctx := GetContextData(lat, lon)
@@ -5,7 +5,6 @@ import (
 	"log"
 	"math"
 	"os"
-	"sort"
 	"time"
 )
 
@@ -22,12 +21,21 @@ type CourierState struct {
 	MetersWalked  float64     `json:"meters_walked"`
 	Enabled       bool        `json:"enabled"`
 	ManualTarget  bool        `json:"manual_target"` // If true, don't auto-pick next destination
+
+	// Coverage gained by frontier planning (see frontier.go)
+	OSRMCalls int     `json:"osrm_calls,omitempty"` // Routes requested
+	NewMeters float64 `json:"new_meters,omitempty"` // Route meters where no street was stored
+	NewCells  int     `json:"new_cells,omitempty"`  // Geohash-7 cells first reached
 }
 
 var courierState *CourierState
 
 const courierStateFile = "courier_state.json"
 
+// courierID names the single courier's frontier claims; regional couriers
+// use their cluster ID
+const courierID = "courier"
+
 // InitCourier initializes the courier agent
 func InitCourier() *CourierState {
 	if courierState != nil {
@@ -95,6 +103,7 @@ func DisableCourier() {
 	if courierState != nil {
 		courierState.Enabled = false
 		saveCourierState()
+		releaseFrontier(courierID)
 		log.Printf("[courier] disabled")
 	}
 }
@@ -115,6 +124,7 @@ func CourierStep() bool {
 
 	// If no current route, pick a new destination
 	if len(courierState.Route) == 0 || courierState.RouteIndex >= len(courierState.Route) {
+		releaseFrontier(courierID)
 		if !pickCourierDestination(db) {
 			return false
 		}
@@ -124,112 +134,35 @@ func CourierStep() bool {
 	return walkCourierRoute(db)
 }
 
-// pickCourierDestination chooses the next agent to visit
+// pickCourierDestination routes to the frontier cell expected to add the
+// most new streets
 func pickCourierDestination(db *DB) bool {
-	agents := db.ListAgents()
-	if len(agents) < 2 {
-		log.Printf("[courier] need at least 2 agents to courier between")
+	frontier, cov := planFrontier(db, courierState.CurrentLat, courierState.CurrentLon)
+	if len(frontier) == 0 {
+		log.Printf("[courier] no frontier within %.0fkm", frontierRadius/1000)
 		return false
 	}
 
-	// Find agents we haven't connected to yet (no street exists)
-	// Sort by distance from current position
-	type agentDist struct {
-		agent      *Entity
-		dist       float64
-		has_street bool
-	}
-	var candidates []agentDist
-
-	for _, agent := range agents {
-		// Skip if we're already at this agent
-		dist := DistanceMeters(courierState.CurrentLat, courierState.CurrentLon, agent.Lat, agent.Lon)
-		if dist < 100 {
-			continue
-		}
-
-		// Check if there's already a street connecting these points
-		// Look for streets near both our current position and the target
-		nearbyStreets := db.Query(agent.Lat, agent.Lon, 200, EntityStreet, 20)
-		hasStreet := false
-		for _, street := range nearbyStreets {
-			sd := street.GetStreetData()
-			if sd == nil {
-				continue
-			}
-			// Check if this street starts near our current position
-			if len(sd.Points) > 0 {
-				startLon, startLat := sd.Points[0][0], sd.Points[0][1]
-				startDist := DistanceMeters(courierState.CurrentLat, courierState.CurrentLon, startLat, startLon)
-				if startDist < 200 {
-					hasStreet = true
-					break
-				}
-			}
+	// Fall through to the next cell if a route fails, passing over cells
+	// regional couriers are heading for
+	tried := 0
+	for _, f := range frontier {
+		if tried >= 3 {
+			break
 		}
-
-		candidates = append(candidates, agentDist{agent, dist, hasStreet})
-	}
-
-	if len(candidates) == 0 {
-		log.Printf("[courier] no candidates found")
-		return false
-	}
-
-	// Prefer unconnected agents, then by distance
-	sort.Slice(candidates, func(i, j int) bool {
-		// Unconnected first
-		if !candidates[i].has_street && candidates[j].has_street {
-			return true
+		if frontierClaimed(courierID, f.Cell) {
+			continue
 		}
-		if candidates[i].has_street && !candidates[j].has_street {
-			return false
+		tried++
+		if _, err := startCourierTrip(db, courierID, courierState, f, cov); err != nil {
+			log.Printf("[courier] route to %s failed: %v", f.Name, err)
+			continue
 		}
-		// Then by distance (prefer closer)
-		return candidates[i].dist < candidates[j].dist
-	})
-
-	// Pick the best candidate
-	target := candidates[0].agent
-
-	// Get walking route
-	route, err := GetWalkingRoute(courierState.CurrentLat, courierState.CurrentLon, target.Lat, target.Lon)
-	if err != nil {
-		log.Printf("[courier] route to %s failed: %v", target.Name, err)
-		return false
+		log.Printf("[courier] starting trip to %s (score %.0f, %d points)",
+			f.Name, f.Score, len(courierState.Route))
+		return true
 	}
-
-	if len(route.Coordinates) < 2 {
-		log.Printf("[courier] route to %s too short", target.Name)
-		return false
-	}
-
-	courierState.TargetAgent = target.ID
-	courierState.TargetName = target.Name
-	courierState.Route = route.Coordinates
-	courierState.RouteIndex = 0
-
-	log.Printf("[courier] starting trip to %s (%.0fm, %d points)",
-		target.Name, route.Distance, len(route.Coordinates))
-
-	// Index the full street geometry now
-	street := &Entity{
-		ID:   GenerateID(EntityStreet, courierState.CurrentLat, courierState.CurrentLon, target.Name),
-		Type: EntityStreet,
-		Name: "Courier route to " + target.Name,
-		Lat:  courierState.CurrentLat,
-		Lon:  courierState.CurrentLon,
-		Data: &StreetData{
-			Points: route.Coordinates,
-			Length: route.Distance,
-			ToName: target.Name,
-		},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	db.Insert(street)
-
-	return true
+	return false
 }
 
 // walkCourierRoute moves the courier along the current route
@@ -324,6 +257,7 @@ func GetCourierStats() map[string]interface{} {
 		"trips_complete": courierState.TripsComplete,
 		"meters_walked":  courierState.MetersWalked,
 		"km_walked":      math.Round(courierState.MetersWalked/100) / 10,
+		"coverage":       coverageGain(courierState),
 	}
 
 	if courierState.TargetName != "" {
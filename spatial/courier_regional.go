@@ -142,8 +142,9 @@ func DisableRegionalCouriers() {
 
 	regionalManager.mu.Lock()
 	regionalManager.enabled = false
-	for _, c := range regionalManager.couriers {
+	for id, c := range regionalManager.couriers {
 		c.Enabled = false
+		releaseFrontier(id)
 	}
 	regionalManager.mu.Unlock()
 
@@ -201,6 +202,9 @@ func RegionalCourierStep() {
 func stepRegionalCourier(db *DB, clusterID string, courier *CourierState) {
 	// If no current route, pick a new destination within this cluster
 	if len(courier.Route) == 0 || courier.RouteIndex >= len(courier.Route) {
+		// Arrived: the cell it was heading for is free for others
+		releaseFrontier(clusterID)
+
 		// If this was a manual target, check for next waypoint
 		if courier.ManualTarget {
 			log.Printf("[regional-courier] %s: arrived at manual target %s", clusterID[:8], courier.TargetName)
@@ -209,7 +213,7 @@ func stepRegionalCourier(db *DB, clusterID string, courier *CourierState) {
 			next := popNextWaypoint()
 			if next != nil {
 				log.Printf("[regional-courier] %s: next waypoint %s", clusterID[:8], next.Name)
-				route, err := GetWalkingRoute(courier.CurrentLat, courier.CurrentLon, next.Lat, next.Lon)
+				route, err := OSRMWalkingRoute(courier.CurrentLat, courier.CurrentLon, next.Lat, next.Lon)
 				if err == nil {
 					courier.Route = route.Coordinates
 					courier.RouteIndex = 0
@@ -235,101 +239,33 @@ func stepRegionalCourier(db *DB, clusterID string, courier *CourierState) {
 	walkRegionalRoute(db, courier)
 }
 
-// pickRegionalDestination chooses next destination to maximize network connectivity
+// pickRegionalDestination routes a regional courier to the frontier cell
+// expected to add the most new streets that no other courier has claimed,
+// which favours bridging parts of the network that don't connect yet
 func pickRegionalDestination(db *DB, clusterID string, courier *CourierState) bool {
-	const clusterRadius = 50000.0
-	agents := db.ListAgents()
-
-	var candidates []*Entity
-	for _, agent := range agents {
-		dist := DistanceMeters(courier.CurrentLat, courier.CurrentLon, agent.Lat, agent.Lon)
-		if dist < clusterRadius && dist > 100 {
-			candidates = append(candidates, agent)
+	frontier, cov := planFrontier(db, courier.CurrentLat, courier.CurrentLon)
+	tried := 0
+	for _, f := range frontier {
+		if tried >= 3 {
+			break
 		}
-	}
-
-	if len(candidates) == 0 {
-		return false
-	}
-
-	// Build connectivity graph - which agents are connected via streets?
-	connected := buildConnectivityGraph(db, candidates)
-
-	// Find which component the courier is currently in
-	courierComponent := findComponent(courier.CurrentLat, courier.CurrentLon, candidates, connected)
-
-	// Strategy 1: Find agent in a DIFFERENT component (bridges isolated areas)
-	// Find all different components and their nearest representatives
-	componentAgents := make(map[string]*Entity) // component -> nearest agent in that component
-	componentDists := make(map[string]float64)
-
-	for _, agent := range candidates {
-		agentComponent := findComponent(agent.Lat, agent.Lon, candidates, connected)
-		if agentComponent != courierComponent && agentComponent != "" {
-			dist := DistanceMeters(courier.CurrentLat, courier.CurrentLon, agent.Lat, agent.Lon)
-			if _, exists := componentDists[agentComponent]; !exists || dist < componentDists[agentComponent] {
-				componentAgents[agentComponent] = agent
-				componentDists[agentComponent] = dist
-			}
-		}
-	}
-
-	// Pick the FARTHEST component (to expand coverage faster)
-	var bridgeCandidate *Entity
-	bridgeDist := float64(0)
-
-	for comp, agent := range componentAgents {
-		dist := componentDists[comp]
-		if dist > bridgeDist {
-			bridgeCandidate = agent
-			bridgeDist = dist
+		// Couriers nearby plan from much the same frontier; leave them
+		// the cells they have claimed
+		if frontierClaimed(clusterID, f.Cell) {
+			continue
 		}
-	}
-
-	if bridgeCandidate != nil {
-		log.Printf("[regional-courier] %s: bridging to %s (different component, %.0fm)", clusterID[:8], bridgeCandidate.Name, bridgeDist)
-		return getRouteToAgent(courier, bridgeCandidate)
-	}
-
-	// Strategy 2: Find least-connected agent (has fewest street connections)
-	var leastConnected *Entity
-	minConnections := 999
-	leastDist := float64(999999999)
-
-	for _, agent := range candidates {
-		conns := countConnections(db, agent)
-		dist := DistanceMeters(courier.CurrentLat, courier.CurrentLon, agent.Lat, agent.Lon)
-
-		// Prefer fewer connections, then farther away (to expand network)
-		if conns < minConnections || (conns == minConnections && dist > leastDist) {
-			minConnections = conns
-			leastConnected = agent
-			leastDist = dist
+		tried++
+		if _, err := startCourierTrip(db, clusterID, courier, f, cov); err != nil {
+			log.Printf("[regional-courier] %s: route error to %s: %v", clusterID[:8], f.Name, err)
+			continue
 		}
-	}
-
-	if leastConnected != nil && minConnections < 3 {
-		log.Printf("[regional-courier] %s: connecting to %s (only %d connections, %.0fm)", clusterID[:8], leastConnected.Name, minConnections, leastDist)
-		return getRouteToAgent(courier, leastConnected)
-	}
-
-	// Strategy 3: Pick farthest unvisited agent (expand coverage)
-	var farthest *Entity
-	farthestDist := float64(0)
-
-	for _, agent := range candidates {
-		dist := DistanceMeters(courier.CurrentLat, courier.CurrentLon, agent.Lat, agent.Lon)
-		if dist > farthestDist {
-			farthest = agent
-			farthestDist = dist
+		why := "frontier"
+		if f.Bridge {
+			why = "bridging"
 		}
+		log.Printf("[regional-courier] %s: %s to %s (%.0fm, score %.0f)", clusterID[:8], why, f.Name, f.Distance, f.Score)
+		return true
 	}
-
-	if farthest != nil {
-		log.Printf("[regional-courier] %s: expanding to %s (farthest, %.0fm)", clusterID[:8], farthest.Name, farthestDist)
-		return getRouteToAgent(courier, farthest)
-	}
-
 	return false
 }
 
@@ -434,28 +370,6 @@ func findComponent(lat, lon float64, agents []*Entity, connected map[string]map[
 	return minID
 }
 
-// countConnections returns how many street connections an agent has
-func countConnections(db *DB, agent *Entity) int {
-	streets := db.Query(agent.Lat, agent.Lon, 500, EntityStreet, 50)
-	return len(streets)
-}
-
-// getRouteToAgent gets walking route to an agent
-func getRouteToAgent(courier *CourierState, agent *Entity) bool {
-	route, err := GetWalkingRoute(courier.CurrentLat, courier.CurrentLon, agent.Lat, agent.Lon)
-	if err != nil {
-		log.Printf("[regional-courier] route error to %s: %v", agent.Name, err)
-		return false
-	}
-
-	courier.Route = route.Coordinates
-	courier.RouteIndex = 0
-	courier.TargetAgent = agent.ID
-	courier.TargetName = agent.Name
-
-	log.Printf("[regional-courier] %s starting trip to %s (%.0fm)", courier.TargetAgent[:8], agent.Name, route.Distance)
-	return true
-}
 func walkRegionalRoute(db *DB, courier *CourierState) {
 	if len(courier.Route) == 0 {
 		return
@@ -550,10 +464,14 @@ func GetRegionalCourierStatus() map[string]interface{} {
 	courierStats := make([]map[string]interface{}, 0)
 	totalTrips := 0
 	totalWalked := 0.0
+	var total CourierState
 
 	for id, c := range regionalManager.couriers {
 		totalTrips += c.TripsComplete
 		totalWalked += c.MetersWalked
+		total.OSRMCalls += c.OSRMCalls
+		total.NewMeters += c.NewMeters
+		total.NewCells += c.NewCells
 
 		status := "idle"
 		if c.Enabled && len(c.Route) > 0 {
@@ -569,6 +487,7 @@ func GetRegionalCourierStatus() map[string]interface{} {
 			"trips":    c.TripsComplete,
 			"walked":   c.MetersWalked / 1000,
 			"progress": float64(c.RouteIndex) / float64(max(1, len(c.Route))) * 100,
+			"coverage": coverageGain(c),
 		})
 	}
 
@@ -577,6 +496,7 @@ func GetRegionalCourierStatus() map[string]interface{} {
 		"courier_count": len(regionalManager.couriers),
 		"total_trips":   totalTrips,
 		"total_walked":  totalWalked / 1000,
+		"coverage":      coverageGain(&total),
 		"couriers":      courierStats,
 	}
 }
@@ -626,7 +546,7 @@ func SendCourierTo(lat, lon float64, name string) error {
 	}
 
 	// Get route (no lock - this is slow)
-	route, err := GetWalkingRoute(startLat, startLon, lat, lon)
+	route, err := OSRMWalkingRoute(startLat, startLon, lat, lon)
 	if err != nil {
 		return fmt.Errorf("couldn't get route: %v", err)
 	}
@@ -236,6 +236,7 @@ func (d *DB) QueryWithMaxAge(lat, lon, radiusMeters float64, entityType EntityTy
 }
 
 // QueryPlaces finds places by category
+// Supplementary datasets for the category are merged in
 func (d *DB) QueryPlaces(lat, lon, radiusMeters float64, category string, limit int) []*Entity {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -271,7 +272,7 @@ func (d *DB) QueryPlaces(lat, lon, radiusMeters float64, category string, limit
 			results = append(results, entity)
 		}
 	}
-	return results
+	return mergeSupplementary(results, lat, lon, radiusMeters, EntityPlace, category, limit)
 }
 
 // FindByName searches entities by name
@@ -400,6 +401,51 @@ func (d *DB) QueryByNameContains(lat, lon, radiusMeters float64, nameContains st
 	return result
 }
 
+// QueryNamed finds entities of the given types whose name contains any of the tokens
+// Expiry is ignored - names and positions outlive live data like arrivals
+func (d *DB) QueryNamed(lat, lon, radiusMeters float64, tokens []string, types []EntityType, limit int) []*Entity {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	center := quadtree.NewPoint(lat, lon, nil)
+	half := center.HalfPoint(radiusMeters)
+	boundary := quadtree.NewAABB(center, half)
+
+	filter := func(p *quadtree.Point) bool {
+		entity, ok := p.Data().(*Entity)
+		if !ok {
+			return false
+		}
+		typeOK := false
+		for _, t := range types {
+			if entity.Type == t {
+				typeOK = true
+				break
+			}
+		}
+		if !typeOK {
+			return false
+		}
+		name := strings.ToLower(entity.Name)
+		for _, tok := range tokens {
+			if strings.Contains(name, tok) {
+				return true
+			}
+		}
+		return false
+	}
+
+	points := d.tree.KNearest(boundary, limit, filter)
+
+	var results []*Entity
+	for _, p := range points {
+		if entity, ok := p.Data().(*Entity); ok {
+			results = append(results, entity)
+		}
+	}
+	return results
+}
+
 // GetNearestLocation finds the nearest location entity to the given coordinates
 // Returns nil if no location exists within toleranceMeters (typically 10m for GPS jitter)
 func (d *DB) GetNearestLocation(lat, lon, toleranceMeters float64) *Entity {
@@ -494,6 +540,49 @@ func (d *DB) CountByAgentID(agentID string, entityType EntityType) int {
 	return count
 }
 
+// ReassignPlaces hands each place to the agent owner picks for its location,
+// rewriting the agent_id CountByAgentID reads. Places outside every
+// territory keep theirs. Returns how many changed
+func (d *DB) ReassignPlaces(owner func(lat, lon float64) string) int {
+	d.mu.RLock()
+	var places []*Entity
+	for _, point := range d.entities {
+		if entity, ok := point.Data().(*Entity); ok && entity.Type == EntityPlace {
+			places = append(places, entity)
+		}
+	}
+	d.mu.RUnlock()
+
+	changed := 0
+	for _, e := range places {
+		id := owner(e.Lat, e.Lon)
+		pd := e.GetPlaceData()
+		if id == "" || pd == nil || pd.AgentID == id {
+			continue
+		}
+		// Copy rather than write under readers' feet
+		moved := *e
+		switch data := e.Data.(type) {
+		case *PlaceData:
+			updated := *data
+			updated.AgentID = id
+			moved.Data = &updated
+		case map[string]interface{}:
+			updated := make(map[string]interface{}, len(data))
+			for k, v := range data {
+				updated[k] = v
+			}
+			updated["agent_id"] = id
+			moved.Data = updated
+		default:
+			continue
+		}
+		d.Insert(&moved)
+		changed++
+	}
+	return changed
+}
+
 // CleanupExpired removes all expired entities from the database
 func (d *DB) CleanupExpired() int {
 	d.mu.Lock()
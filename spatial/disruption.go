@@ -0,0 +1,452 @@
+package spatial
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tflDisruptionURL      = tflBaseURL + "/Road/all/Disruption"
+	disruptionNearbyKm    = 5.0  // Only mention disruptions within 5km
+	RouteDisruptionBuffer = 30.0 // meters either side of a route
+	disruptionRetry       = time.Minute
+	disruptionReach       = 5000.0 // meters a disruption's lines may run from its stored point
+)
+
+// TfL returns every London disruption in one call, so a single fetch
+// serves all agents until disruptionTTL expires
+var (
+	disruptionFetchMu   sync.Mutex
+	disruptionFetchedAt time.Time
+)
+
+// tflDisruption mirrors the TfL Road Disruption response
+type tflDisruption struct {
+	ID            string `json:"id"`
+	Severity      string `json:"severity"`
+	Category      string `json:"category"`
+	SubCategory   string `json:"subCategory"`
+	Comments      string `json:"comments"`
+	CurrentUpdate string `json:"currentUpdate"`
+	Location      string `json:"location"`
+	Status        string `json:"status"`
+	HasClosures   bool   `json:"hasClosures"`
+	StartDateTime string `json:"startDateTime"`
+	EndDateTime   string `json:"endDateTime"`
+	Geography     struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geography"`
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Streets []struct {
+		Name     string `json:"name"`
+		Closure  string `json:"closure"`
+		Segments []struct {
+			LineString string `json:"lineString"` // JSON encoded [[lon, lat], ...]
+		} `json:"segments"`
+	} `json:"streets"`
+}
+
+// fetchTrafficDisruptions fetches TfL road disruptions and stores each one as an entity
+// Returns the stored entities, or nil if skipped (outside London or fetched recently)
+func fetchTrafficDisruptions(lat, lon float64) ([]*Entity, error) {
+	if !IsLondon(lat, lon) {
+		return nil, nil
+	}
+
+	disruptionFetchMu.Lock()
+	if time.Since(disruptionFetchedAt) < disruptionTTL {
+		disruptionFetchMu.Unlock()
+		return nil, nil
+	}
+	// Hold off other fetches while this one runs, but only for
+	// disruptionRetry if it fails; success stamps the full TTL below
+	disruptionFetchedAt = time.Now().Add(disruptionRetry - disruptionTTL)
+	disruptionFetchMu.Unlock()
+
+	resp, err := TfLGet(tflDisruptionURL)
+	if err != nil {
+		log.Printf("[disruption] TfL API error: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		log.Printf("[disruption] TfL API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("tfl returned %d", resp.StatusCode)
+	}
+
+	var disruptions []tflDisruption
+	if err := json.NewDecoder(resp.Body).Decode(&disruptions); err != nil {
+		log.Printf("[disruption] Decode error: %v", err)
+		return nil, err
+	}
+
+	disruptionFetchMu.Lock()
+	disruptionFetchedAt = time.Now()
+	disruptionFetchMu.Unlock()
+
+	db := Get()
+	now := time.Now()
+	expiry := now.Add(disruptionTTL)
+
+	var entities []*Entity
+	for _, d := range disruptions {
+		entity := disruptionEntity(d, now)
+		if entity == nil {
+			continue
+		}
+		entity.ExpiresAt = &expiry
+		db.Insert(entity)
+		entities = append(entities, entity)
+	}
+
+	log.Printf("[disruption] Stored %d of %d TfL disruptions", len(entities), len(disruptions))
+	return entities, nil
+}
+
+// disruptionEntity converts a TfL disruption into a typed entity
+// Returns nil for disruptions that have already ended or have no location
+func disruptionEntity(d tflDisruption, now time.Time) *Entity {
+	dd := &DisruptionData{
+		DisruptionID: d.ID,
+		Severity:     d.Severity,
+		Category:     d.Category,
+		SubCategory:  d.SubCategory,
+		Description:  strings.TrimSpace(d.Comments),
+		Location:     d.Location,
+		Closure:      d.HasClosures,
+	}
+	if dd.Description == "" {
+		dd.Description = strings.TrimSpace(d.CurrentUpdate)
+	}
+	if t, err := time.Parse(time.RFC3339, d.StartDateTime); err == nil {
+		dd.Start = &t
+	}
+	if t, err := time.Parse(time.RFC3339, d.EndDateTime); err == nil {
+		dd.End = &t
+	}
+	if dd.End != nil && dd.End.Before(now) {
+		return nil
+	}
+
+	seenRoads := make(map[string]bool)
+	for _, st := range d.Streets {
+		if st.Name != "" && !seenRoads[st.Name] {
+			seenRoads[st.Name] = true
+			dd.Roads = append(dd.Roads, st.Name)
+		}
+		if st.Closure != "" && st.Closure != "Open" {
+			dd.Closure = true
+		}
+		for _, seg := range st.Segments {
+			var line [][]float64
+			if err := json.Unmarshal([]byte(seg.LineString), &line); err == nil && len(line) >= 2 {
+				dd.Lines = append(dd.Lines, line)
+			}
+		}
+	}
+
+	switch d.Geometry.Type {
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(d.Geometry.Coordinates, &rings); err == nil && len(rings) > 0 {
+			dd.Polygon = rings[0]
+		}
+	case "MultiPolygon":
+		var polys [][][][]float64
+		if err := json.Unmarshal(d.Geometry.Coordinates, &polys); err == nil && len(polys) > 0 && len(polys[0]) > 0 {
+			dd.Polygon = polys[0][0]
+		}
+	}
+
+	// Position: the reported point, else the first vertex of the geometry
+	var lat, lon float64
+	if d.Geography.Type == "Point" && len(d.Geography.Coordinates) >= 2 {
+		lon, lat = d.Geography.Coordinates[0], d.Geography.Coordinates[1]
+	} else if len(dd.Lines) > 0 {
+		lon, lat = dd.Lines[0][0][0], dd.Lines[0][0][1]
+	} else if len(dd.Polygon) > 0 {
+		lon, lat = dd.Polygon[0][0], dd.Polygon[0][1]
+	}
+	if lat == 0 && lon == 0 {
+		return nil
+	}
+
+	return &Entity{
+		ID:   GenerateID(EntityDisruption, 0, 0, d.ID),
+		Type: EntityDisruption,
+		Name: disruptionIcon(dd) + " " + dd.Summary(),
+		Lat:  lat,
+		Lon:  lon,
+		Data: dd,
+	}
+}
+
+// IsActive returns true if the disruption is in effect at the given time
+func (dd *DisruptionData) IsActive(now time.Time) bool {
+	if dd.Start != nil && now.Before(*dd.Start) {
+		return false
+	}
+	if dd.End != nil && now.After(*dd.End) {
+		return false
+	}
+	return true
+}
+
+// IsSignificant returns true for disruptions worth telling users about
+func (dd *DisruptionData) IsSignificant() bool {
+	return dd.Severity == "Serious" || dd.Severity == "Moderate" || dd.Category == "Collisions" || dd.Closure
+}
+
+// Summary returns a short human readable description
+func (dd *DisruptionData) Summary() string {
+	text := truncate(dd.Description, 80)
+	where := dd.Location
+	if len(dd.Roads) > 0 {
+		where = strings.Join(dd.Roads, ", ")
+	}
+	if where == "" {
+		return text
+	}
+	if text == "" {
+		return where
+	}
+	return where + ": " + text
+}
+
+func disruptionIcon(dd *DisruptionData) string {
+	lower := strings.ToLower(dd.Description)
+	if dd.Category == "Collisions" || strings.Contains(lower, "collision") || strings.Contains(lower, "accident") {
+		return "🚨"
+	}
+	if dd.Closure {
+		return "⛔"
+	}
+	return "🚧"
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "Serious":
+		return 0
+	case "Moderate":
+		return 1
+	case "Minimal":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// NearbyDisruption is a disruption with its distance from a point
+type NearbyDisruption struct {
+	Entity   *Entity
+	Data     *DisruptionData
+	Distance float64 // meters to the nearest part of the disruption
+}
+
+// GetNearbyDisruptions returns active, significant disruptions near a location, closest first
+func GetNearbyDisruptions(lat, lon, radiusMeters float64) []NearbyDisruption {
+	db := Get()
+	now := time.Now()
+
+	// Disruptions are stored at one point, so a long closure or area crossing
+	// the radius may have its point well outside it
+	var result []NearbyDisruption
+	for _, e := range db.Query(lat, lon, radiusMeters+disruptionReach, EntityDisruption, 500) {
+		dd := e.GetDisruptionData()
+		if dd == nil || !dd.IsActive(now) || !dd.IsSignificant() {
+			continue
+		}
+		dist := disruptionDistance(e, dd, [][]float64{{lon, lat}})
+		if dist > radiusMeters {
+			continue
+		}
+		result = append(result, NearbyDisruption{Entity: e, Data: dd, Distance: dist})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Distance < result[j].Distance
+	})
+	return result
+}
+
+// formatNearbyDisruption formats a disruption for the context display
+func formatNearbyDisruption(nd NearbyDisruption) string {
+	return fmt.Sprintf("%s %.1fkm: %s", disruptionIcon(nd.Data), nd.Distance/1000, nd.Data.Summary())
+}
+
+// DisruptionsAlongRoute returns active disruptions within bufferMeters of a route
+// coords are [lon, lat] pairs as returned by OSRM. Most severe first.
+func (d *DB) DisruptionsAlongRoute(coords [][]float64, bufferMeters float64) []*Entity {
+	if len(coords) == 0 {
+		return nil
+	}
+
+	// Query a circle around the route bounding box
+	minLat, maxLat := coords[0][1], coords[0][1]
+	minLon, maxLon := coords[0][0], coords[0][0]
+	for _, c := range coords {
+		minLat, maxLat = math.Min(minLat, c[1]), math.Max(maxLat, c[1])
+		minLon, maxLon = math.Min(minLon, c[0]), math.Max(maxLon, c[0])
+	}
+	centerLat, centerLon := (minLat+maxLat)/2, (minLon+maxLon)/2
+	radius := haversineMeters(centerLat, centerLon, maxLat, maxLon) + disruptionReach
+
+	now := time.Now()
+	type match struct {
+		entity *Entity
+		rank   int
+		dist   float64
+	}
+	var matches []match
+	for _, e := range d.Query(centerLat, centerLon, radius, EntityDisruption, 500) {
+		dd := e.GetDisruptionData()
+		if dd == nil || !dd.IsActive(now) {
+			continue
+		}
+		dist := disruptionDistance(e, dd, coords)
+		if dist <= bufferMeters {
+			matches = append(matches, match{entity: e, rank: severityRank(dd.Severity), dist: dist})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		return matches[i].dist < matches[j].dist
+	})
+
+	result := make([]*Entity, len(matches))
+	for i, m := range matches {
+		result[i] = m.entity
+	}
+	return result
+}
+
+// FormatRouteDisruptions returns one warning line per disruption on a route
+func FormatRouteDisruptions(disruptions []*Entity) []string {
+	var lines []string
+	for _, e := range disruptions {
+		dd := e.GetDisruptionData()
+		if dd == nil {
+			continue
+		}
+		what := "Disruption"
+		if dd.Closure {
+			what = "Road closure"
+		} else if dd.Category == "Collisions" {
+			what = "Collision"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s on your route: %s", disruptionIcon(dd), what, dd.Summary()))
+	}
+	return lines
+}
+
+// disruptionDistance returns the distance in meters between a disruption and a line
+// A single-point line measures distance from that point
+func disruptionDistance(e *Entity, dd *DisruptionData, line [][]float64) float64 {
+	best := pointToPolylineMeters(e.Lat, e.Lon, line)
+
+	for _, seg := range dd.Lines {
+		best = math.Min(best, polylineDistanceMeters(seg, line))
+	}
+
+	if len(dd.Polygon) >= 3 {
+		for _, c := range line {
+			if pointInPolygon(c[1], c[0], dd.Polygon) {
+				return 0
+			}
+		}
+		best = math.Min(best, polylineDistanceMeters(dd.Polygon, line))
+	}
+
+	return best
+}
+
+// polylineDistanceMeters returns the closest distance between two [lon, lat] lines
+func polylineDistanceMeters(a, b [][]float64) float64 {
+	// Lines that cross have no vertex near each other, so check that first
+	for i := 1; i < len(a); i++ {
+		for j := 1; j < len(b); j++ {
+			if segmentsCross(a[i-1], a[i], b[j-1], b[j]) {
+				return 0
+			}
+		}
+	}
+
+	best := math.Inf(1)
+	for _, p := range a {
+		best = math.Min(best, pointToPolylineMeters(p[1], p[0], b))
+	}
+	for _, p := range b {
+		best = math.Min(best, pointToPolylineMeters(p[1], p[0], a))
+	}
+	return best
+}
+
+// segmentsCross tests whether segments p1-p2 and q1-q2 intersect
+func segmentsCross(p1, p2, q1, q2 []float64) bool {
+	orient := func(a, b, c []float64) float64 {
+		return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	}
+	d1 := orient(q1, q2, p1)
+	d2 := orient(q1, q2, p2)
+	d3 := orient(p1, p2, q1)
+	d4 := orient(p1, p2, q2)
+	return ((d1 > 0) != (d2 > 0)) && ((d3 > 0) != (d4 > 0))
+}
+
+// pointToPolylineMeters returns the distance from a point to a [lon, lat] line
+func pointToPolylineMeters(lat, lon float64, line [][]float64) float64 {
+	if len(line) == 0 {
+		return math.Inf(1)
+	}
+	if len(line) == 1 {
+		return haversineMeters(lat, lon, line[0][1], line[0][0])
+	}
+	best := math.Inf(1)
+	for i := 1; i < len(line); i++ {
+		d := pointToSegmentMeters(lat, lon, line[i-1][1], line[i-1][0], line[i][1], line[i][0])
+		if d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// pointToSegmentMeters returns the distance from a point to the segment a-b
+// Uses a local flat projection, accurate for the short distances we deal with
+func pointToSegmentMeters(lat, lon, aLat, aLon, bLat, bLon float64) float64 {
+	return perpendicularDistance(
+		TrackPoint{Lat: lat, Lon: lon},
+		TrackPoint{Lat: aLat, Lon: aLon},
+		TrackPoint{Lat: bLat, Lon: bLon},
+	)
+}
+
+// pointInPolygon tests a point against a [lon, lat] ring using ray casting
+func pointInPolygon(lat, lon float64, ring [][]float64) bool {
+	inside := false
+	j := len(ring) - 1
+	for i := 0; i < len(ring); i++ {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+		j = i
+	}
+	return inside
+}
@@ -0,0 +1,108 @@
+package spatial
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// testRoadClosure is a TfL closure of Milton Road, Hampton, 200m long
+func testRoadClosure() tflDisruption {
+	var d tflDisruption
+	d.ID = "TIMS-1"
+	d.Severity = "Serious"
+	d.Comments = "Road closed for resurfacing"
+	d.Geography.Type = "Point"
+	d.Geography.Coordinates = []float64{-0.3700, 51.4170}
+	d.Streets = append(d.Streets, struct {
+		Name     string `json:"name"`
+		Closure  string `json:"closure"`
+		Segments []struct {
+			LineString string `json:"lineString"`
+		} `json:"segments"`
+	}{
+		Name:    "Milton Road",
+		Closure: "Closed",
+		Segments: []struct {
+			LineString string `json:"lineString"`
+		}{{LineString: "[[-0.3700,51.4170],[-0.3700,51.4190]]"}},
+	})
+	return d
+}
+
+// TestDisruptionEntity checks a TfL disruption keeps its closure, road and line geometry
+func TestDisruptionEntity(t *testing.T) {
+	e := disruptionEntity(testRoadClosure(), time.Now())
+	if e == nil {
+		t.Fatal("disruptionEntity returned nil")
+	}
+	dd := e.GetDisruptionData()
+	if dd == nil || !dd.Closure || len(dd.Lines) != 1 || len(dd.Roads) != 1 {
+		t.Fatalf("unexpected disruption data: %+v", dd)
+	}
+}
+
+// TestDisruptionEnded checks disruptions past their end time are dropped
+func TestDisruptionEnded(t *testing.T) {
+	now := time.Now()
+	d := testRoadClosure()
+	d.EndDateTime = now.Add(-time.Hour).Format(time.RFC3339)
+	if disruptionEntity(d, now) != nil {
+		t.Error("expected ended disruption to be skipped")
+	}
+}
+
+// TestDisruptionOnRoute checks disruption geometry is matched against route lines
+func TestDisruptionOnRoute(t *testing.T) {
+	e := disruptionEntity(testRoadClosure(), time.Now())
+	dd := e.GetDisruptionData()
+
+	testCases := []struct {
+		name  string
+		route [][]float64
+		want  bool
+	}{
+		{"Crosses closed road", [][]float64{{-0.3710, 51.4180}, {-0.3690, 51.4180}}, true},
+		{"Runs alongside 15m away", [][]float64{{-0.37022, 51.4172}, {-0.37022, 51.4188}}, true},
+		{"Parallel street 300m away", [][]float64{{-0.3743, 51.4170}, {-0.3743, 51.4190}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := disruptionDistance(e, dd, tc.route) <= RouteDisruptionBuffer
+			if got != tc.want {
+				t.Errorf("on route = %v, want %v (distance %.0fm)", got, tc.want, disruptionDistance(e, dd, tc.route))
+			}
+		})
+	}
+}
+
+// TestNearbyDisruptionByGeometry checks a closure counts as nearby when its
+// road runs past, even with its stored point outside the radius
+func TestNearbyDisruptionByGeometry(t *testing.T) {
+	d := testRoadClosure()
+	d.ID = "TIMS-LONG"
+	d.HasClosures = true
+	d.Streets[0].Segments[0].LineString = "[[-0.3700,51.4170],[-0.3700,51.4400]]"
+	e := disruptionEntity(d, time.Now())
+	Get().Insert(e)
+	defer Get().Delete(e.ID)
+
+	// 2.4km up the road from its point
+	nearby := GetNearbyDisruptions(51.4390, -0.3702, 500)
+	if len(nearby) != 1 || nearby[0].Entity.ID != e.ID || nearby[0].Distance > 50 {
+		t.Fatalf("closure along the road not found: %+v", nearby)
+	}
+	if far := GetNearbyDisruptions(51.4390, -0.3900, 500); len(far) != 0 {
+		t.Errorf("closure 1.4km away reported: %+v", far)
+	}
+}
+
+// TestDisruptionSummary checks long descriptions are cut between characters, not inside one
+func TestDisruptionSummary(t *testing.T) {
+	long := DisruptionData{Description: strings.Repeat("é", 100)}
+	if got := long.Summary(); !utf8.ValidString(got) || !strings.HasPrefix(got, strings.Repeat("é", 80)+"...") {
+		t.Errorf("Summary() = %q", got)
+	}
+}
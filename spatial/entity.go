@@ -25,6 +25,8 @@ const (
 	EntityNews       EntityType = "news"       // Breaking news/headlines
 	EntityDisruption EntityType = "disruption" // Traffic disruptions
 	EntityStreet     EntityType = "street"     // Street/road geometry
+	EntityBikeDock   EntityType = "bikedock"   // Bike-share docking stations
+	EntityLineStatus EntityType = "linestatus" // Rail/tube line service status
 )
 
 // EntityData is the interface that all typed entity data must implement
@@ -121,15 +123,22 @@ type AgentEntityData struct {
 	HomeLon    float64 `json:"home_lon,omitempty"`
 	TotalSteps int     `json:"total_steps,omitempty"`
 	StepsToday int     `json:"steps_today,omitempty"`
+	// Geohash tile this agent covers (see territory.go)
+	Tile string `json:"tile,omitempty"`
+	// Standing instructions, run by the instructions task
+	Instructions []AgentInstruction `json:"instructions,omitempty"`
+	// Task names this agent runs, empty for all its region's tasks
+	Tasks []string `json:"tasks,omitempty"`
 }
 
 func (AgentEntityData) entityData() {}
 
 // StreetData holds street geometry
 type StreetData struct {
-	Points [][]float64 `json:"points"`
-	Length float64     `json:"length"`
-	ToName string      `json:"to_name"`
+	Points [][]float64       `json:"points"`
+	Length float64           `json:"length"`
+	ToName string            `json:"to_name"`
+	Tags   map[string]string `json:"tags,omitempty"` // OSM access tags for ways
 }
 
 func (StreetData) entityData() {}
@@ -138,10 +147,71 @@ func (StreetData) entityData() {}
 type LocationData struct {
 	Street   string `json:"street"`
 	Postcode string `json:"postcode"`
+	Suburb   string `json:"suburb,omitempty"`
+	City     string `json:"city,omitempty"`
+	Source   string `json:"source,omitempty"` // nominatim or local
 }
 
 func (LocationData) entityData() {}
 
+// DisruptionData holds a road disruption with its affected geometry
+type DisruptionData struct {
+	DisruptionID string     `json:"disruption_id"`
+	Severity     string     `json:"severity"`
+	Category     string     `json:"category"`
+	SubCategory  string     `json:"sub_category,omitempty"`
+	Description  string     `json:"description"`
+	Location     string     `json:"location,omitempty"`
+	Roads        []string   `json:"roads,omitempty"`
+	Closure      bool       `json:"closure,omitempty"`
+	Start        *time.Time `json:"start,omitempty"`
+	End          *time.Time `json:"end,omitempty"`
+	// Geometry - all coordinates are [lon, lat] like StreetData
+	Lines   [][][]float64 `json:"lines,omitempty"`   // Affected road segments
+	Polygon [][]float64   `json:"polygon,omitempty"` // Affected area outline
+}
+
+func (DisruptionData) entityData() {}
+
+// NewsData holds a single news item from an RSS or Atom feed
+type NewsData struct {
+	Source    string     `json:"source"`
+	Link      string     `json:"link"`
+	Summary   string     `json:"summary,omitempty"`
+	Published *time.Time `json:"published,omitempty"`
+	Region    string     `json:"region,omitempty"` // Feed region binding, empty = national
+	Place     string     `json:"place,omitempty"`  // Place name the item was geotagged to
+}
+
+func (NewsData) entityData() {}
+
+// BikeDockData holds live availability for a bike-share dock
+type BikeDockData struct {
+	DockID     string `json:"dock_id"`
+	Network    string `json:"network"`     // "Santander Cycles", GBFS system name
+	Bikes      int    `json:"bikes"`       // Classic bikes available
+	EBikes     int    `json:"ebikes"`      // E-bikes available
+	EmptyDocks int    `json:"empty_docks"` // Spaces to return a bike
+	TotalDocks int    `json:"total_docks,omitempty"`
+	Renting    bool   `json:"renting"` // false if the dock is locked or not installed
+}
+
+func (BikeDockData) entityData() {}
+
+// LineStatusData holds the current service status of a transport line
+type LineStatusData struct {
+	LineID    string     `json:"line_id"`
+	LineName  string     `json:"line_name"`
+	Mode      string     `json:"mode"`     // tube, overground, dlr, elizabeth-line, tram, national-rail
+	Severity  int        `json:"severity"` // TfL statusSeverity, 10 = good service
+	Status    string     `json:"status"`   // "Good Service", "Minor Delays", "Part Closure"
+	Reason    string     `json:"reason,omitempty"`
+	ValidFrom *time.Time `json:"valid_from,omitempty"`
+	ValidTo   *time.Time `json:"valid_to,omitempty"`
+}
+
+func (LineStatusData) entityData() {}
+
 // =============================================================================
 // Data Access Helpers - return typed data or fallback to legacy map
 // =============================================================================
@@ -246,6 +316,62 @@ func (e *Entity) GetLocationData() *LocationData {
 	return nil
 }
 
+// GetDisruptionData returns typed disruption data or nil
+func (e *Entity) GetDisruptionData() *DisruptionData {
+	if e.Type != EntityDisruption {
+		return nil
+	}
+	if dd, ok := e.Data.(*DisruptionData); ok {
+		return dd
+	}
+	if m, ok := e.Data.(map[string]interface{}); ok {
+		return disruptionDataFromMap(m)
+	}
+	return nil
+}
+
+// GetNewsData returns typed news data or nil
+func (e *Entity) GetNewsData() *NewsData {
+	if e.Type != EntityNews {
+		return nil
+	}
+	if nd, ok := e.Data.(*NewsData); ok {
+		return nd
+	}
+	if m, ok := e.Data.(map[string]interface{}); ok {
+		return newsDataFromMap(m)
+	}
+	return nil
+}
+
+// GetBikeDockData returns typed bike dock data or nil
+func (e *Entity) GetBikeDockData() *BikeDockData {
+	if e.Type != EntityBikeDock {
+		return nil
+	}
+	if bd, ok := e.Data.(*BikeDockData); ok {
+		return bd
+	}
+	if m, ok := e.Data.(map[string]interface{}); ok {
+		return bikeDockDataFromMap(m)
+	}
+	return nil
+}
+
+// GetLineStatusData returns typed line status data or nil
+func (e *Entity) GetLineStatusData() *LineStatusData {
+	if e.Type != EntityLineStatus {
+		return nil
+	}
+	if ld, ok := e.Data.(*LineStatusData); ok {
+		return ld
+	}
+	if m, ok := e.Data.(map[string]interface{}); ok {
+		return lineStatusDataFromMap(m)
+	}
+	return nil
+}
+
 // =============================================================================
 // Legacy Map Converters - for backward compatibility with existing JSON data
 // =============================================================================
@@ -335,6 +461,7 @@ func agentDataFromMap(m map[string]interface{}) *AgentEntityData {
 	ad := &AgentEntityData{}
 	ad.Radius, _ = m["radius"].(float64)
 	ad.Status, _ = m["status"].(string)
+	ad.Tile, _ = m["tile"].(string)
 	if poiCount, ok := m["poi_count"].(float64); ok {
 		ad.POICount = int(poiCount)
 	}
@@ -348,6 +475,18 @@ func agentDataFromMap(m map[string]interface{}) *AgentEntityData {
 			ad.LastLive = &t
 		}
 	}
+	if ins, ok := m["instructions"]; ok {
+		if b, err := json.Marshal(ins); err == nil {
+			json.Unmarshal(b, &ad.Instructions)
+		}
+	}
+	if tasks, ok := m["tasks"].([]interface{}); ok {
+		for _, t := range tasks {
+			if name, ok := t.(string); ok {
+				ad.Tasks = append(ad.Tasks, name)
+			}
+		}
+	}
 	return ad
 }
 
@@ -365,6 +504,14 @@ func streetDataFromMap(m map[string]interface{}) *StreetData {
 			}
 		}
 	}
+	if tags, ok := m["tags"].(map[string]interface{}); ok {
+		sd.Tags = make(map[string]string)
+		for k, v := range tags {
+			if s, ok := v.(string); ok {
+				sd.Tags[k] = s
+			}
+		}
+	}
 	return sd
 }
 
@@ -372,6 +519,78 @@ func locationDataFromMap(m map[string]interface{}) *LocationData {
 	ld := &LocationData{}
 	ld.Street, _ = m["street"].(string)
 	ld.Postcode, _ = m["postcode"].(string)
+	ld.Suburb, _ = m["suburb"].(string)
+	ld.City, _ = m["city"].(string)
+	ld.Source, _ = m["source"].(string)
+	// Legacy cells stored "road" and "area" (postcode, else suburb or town)
+	if ld.Street == "" {
+		ld.Street, _ = m["road"].(string)
+	}
+	if area, _ := m["area"].(string); area != "" && ld.Suburb == "" && !isPostcode(area) {
+		ld.Suburb = area
+	}
+	return ld
+}
+
+func disruptionDataFromMap(m map[string]interface{}) *DisruptionData {
+	dd := &DisruptionData{}
+	dd.DisruptionID, _ = m["disruption_id"].(string)
+	dd.Severity, _ = m["severity"].(string)
+	dd.Category, _ = m["category"].(string)
+	dd.Description, _ = m["description"].(string)
+	// Legacy entities stored the comment under "text"
+	if dd.Description == "" {
+		dd.Description, _ = m["text"].(string)
+	}
+	return dd
+}
+
+func newsDataFromMap(m map[string]interface{}) *NewsData {
+	nd := &NewsData{}
+	nd.Source, _ = m["source"].(string)
+	nd.Link, _ = m["link"].(string)
+	nd.Summary, _ = m["summary"].(string)
+	nd.Region, _ = m["region"].(string)
+	nd.Place, _ = m["place"].(string)
+	// Legacy headline entity stored the RSS pubDate string
+	if pub, ok := m["pubDate"].(string); ok {
+		if t, err := time.Parse(time.RFC1123Z, pub); err == nil {
+			nd.Published = &t
+		}
+	}
+	return nd
+}
+
+func bikeDockDataFromMap(m map[string]interface{}) *BikeDockData {
+	bd := &BikeDockData{}
+	bd.DockID, _ = m["dock_id"].(string)
+	bd.Network, _ = m["network"].(string)
+	bd.Renting, _ = m["renting"].(bool)
+	if v, ok := m["bikes"].(float64); ok {
+		bd.Bikes = int(v)
+	}
+	if v, ok := m["ebikes"].(float64); ok {
+		bd.EBikes = int(v)
+	}
+	if v, ok := m["empty_docks"].(float64); ok {
+		bd.EmptyDocks = int(v)
+	}
+	if v, ok := m["total_docks"].(float64); ok {
+		bd.TotalDocks = int(v)
+	}
+	return bd
+}
+
+func lineStatusDataFromMap(m map[string]interface{}) *LineStatusData {
+	ld := &LineStatusData{}
+	ld.LineID, _ = m["line_id"].(string)
+	ld.LineName, _ = m["line_name"].(string)
+	ld.Mode, _ = m["mode"].(string)
+	ld.Status, _ = m["status"].(string)
+	ld.Reason, _ = m["reason"].(string)
+	if v, ok := m["severity"].(float64); ok {
+		ld.Severity = int(v)
+	}
 	return ld
 }
 
@@ -454,6 +673,31 @@ func (e *Entity) UnmarshalJSON(b []byte) error {
 		}
 	case EntityLocation:
 		var ld LocationData
+		// Legacy cells have no "street" key - keep them as maps for locationDataFromMap
+		if err := json.Unmarshal(raw.Data, &ld); err == nil && ld.Street != "" {
+			e.Data = &ld
+			return nil
+		}
+	case EntityDisruption:
+		var dd DisruptionData
+		if err := json.Unmarshal(raw.Data, &dd); err == nil {
+			e.Data = &dd
+			return nil
+		}
+	case EntityNews:
+		var nd NewsData
+		if err := json.Unmarshal(raw.Data, &nd); err == nil {
+			e.Data = &nd
+			return nil
+		}
+	case EntityBikeDock:
+		var bd BikeDockData
+		if err := json.Unmarshal(raw.Data, &bd); err == nil {
+			e.Data = &bd
+			return nil
+		}
+	case EntityLineStatus:
+		var ld LineStatusData
 		if err := json.Unmarshal(raw.Data, &ld); err == nil {
 			e.Data = &ld
 			return nil
@@ -0,0 +1,235 @@
+package spatial
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Errand planning: pick one place per errand and an order to visit them,
+// optionally finishing somewhere, then walk the whole run as one route.
+// Runs are small, so every visiting order is tried; for each order the best
+// place per errand is chosen by dynamic programming over the candidates.
+
+const (
+	MaxErrands          = 6 // 720 orders, still instant
+	ErrandCandidates    = 5 // Places considered per errand
+	errandDetourPenalty = 1.3
+)
+
+// Errand is one thing to get and the places that could do it
+type Errand struct {
+	Name       string
+	Candidates []*Entity
+}
+
+// ErrandStop is the place chosen for an errand
+type ErrandStop struct {
+	Errand string
+	Place  *Entity
+}
+
+// ErrandPlan is a multi-stop walking run
+type ErrandPlan struct {
+	Stops   []ErrandStop
+	Missing []string // Errands with nowhere to do them
+	Route   *Route
+	EndName string
+	EndLat  float64
+	EndLon  float64
+}
+
+// HasEnd reports whether the run finishes at a destination rather than the last stop
+func (p *ErrandPlan) HasEnd() bool {
+	return p.EndLat != 0 || p.EndLon != 0
+}
+
+// PlanErrands chooses places and order for a run of errands and routes it
+// endLat/endLon are where to finish, 0,0 to finish at the last stop
+func PlanErrands(fromLat, fromLon float64, errands []Errand, endName string, endLat, endLon float64) (*ErrandPlan, error) {
+	if len(errands) > MaxErrands {
+		return nil, fmt.Errorf("too many errands, %d at most", MaxErrands)
+	}
+	plan := &ErrandPlan{EndName: endName, EndLat: endLat, EndLon: endLon}
+	var todo []Errand
+	for _, e := range errands {
+		if len(e.Candidates) == 0 {
+			plan.Missing = append(plan.Missing, e.Name)
+			continue
+		}
+		todo = append(todo, e)
+	}
+	if len(todo) == 0 {
+		return plan, fmt.Errorf("couldn't find anywhere for %s", strings.Join(plan.Missing, ", "))
+	}
+
+	plan.Stops = solveErrands(fromLat, fromLon, todo, endLat, endLon)
+
+	// Walk it leg by leg
+	waypoints := [][2]float64{{fromLat, fromLon}}
+	for _, s := range plan.Stops {
+		waypoints = append(waypoints, [2]float64{s.Place.Lat, s.Place.Lon})
+	}
+	if plan.HasEnd() {
+		waypoints = append(waypoints, [2]float64{endLat, endLon})
+	}
+	route, err := routeWaypoints(waypoints, plan.Stops, endName)
+	if err != nil {
+		return plan, err
+	}
+	plan.Route = route
+	return plan, nil
+}
+
+// solveErrands finds the order and places minimising total walking distance
+// Distances are straight-line with a detour allowance; real routes come after
+func solveErrands(fromLat, fromLon float64, errands []Errand, endLat, endLon float64) []ErrandStop {
+	hasEnd := endLat != 0 || endLon != 0
+	dist := func(a, b *Entity) float64 {
+		return haversineMeters(a.Lat, a.Lon, b.Lat, b.Lon) * errandDetourPenalty
+	}
+	start := &Entity{Lat: fromLat, Lon: fromLon}
+	end := &Entity{Lat: endLat, Lon: endLon}
+
+	best := math.Inf(1)
+	var bestStops []ErrandStop
+
+	order := make([]int, len(errands))
+	for i := range order {
+		order[i] = i
+	}
+	permute(order, 0, func(order []int) {
+		// cost[j]: cheapest way to reach candidate j of the current errand
+		first := errands[order[0]].Candidates
+		cost := make([]float64, len(first))
+		back := make([][]int, len(order))
+		for j, c := range first {
+			cost[j] = dist(start, c)
+		}
+		for k := 1; k < len(order); k++ {
+			prev := errands[order[k-1]].Candidates
+			cur := errands[order[k]].Candidates
+			next := make([]float64, len(cur))
+			back[k] = make([]int, len(cur))
+			for j, c := range cur {
+				next[j] = math.Inf(1)
+				for i, p := range prev {
+					if d := cost[i] + dist(p, c); d < next[j] {
+						next[j], back[k][j] = d, i
+					}
+				}
+			}
+			cost = next
+		}
+
+		last := errands[order[len(order)-1]].Candidates
+		pick, total := -1, math.Inf(1)
+		for j, c := range last {
+			d := cost[j]
+			if hasEnd {
+				d += dist(c, end)
+			}
+			if d < total {
+				pick, total = j, d
+			}
+		}
+		if total >= best {
+			return
+		}
+		best = total
+		bestStops = make([]ErrandStop, len(order))
+		for k := len(order) - 1; k >= 0; k-- {
+			e := errands[order[k]]
+			bestStops[k] = ErrandStop{Errand: e.Name, Place: e.Candidates[pick]}
+			if k > 0 {
+				pick = back[k][pick]
+			}
+		}
+	})
+	return bestStops
+}
+
+// permute calls fn with every ordering of a, swapping in place
+func permute(a []int, k int, fn func([]int)) {
+	if k == len(a) {
+		fn(a)
+		return
+	}
+	for i := k; i < len(a); i++ {
+		a[k], a[i] = a[i], a[k]
+		permute(a, k+1, fn)
+		a[k], a[i] = a[i], a[k]
+	}
+}
+
+// errandRouteFunc routes one leg of a run, replaced in tests
+var errandRouteFunc = GetWalkingDirections
+
+// routeWaypoints joins walking routes between waypoints into one route,
+// marking each stop along the way
+func routeWaypoints(waypoints [][2]float64, stops []ErrandStop, endName string) (*Route, error) {
+	route := &Route{Profile: ProfileFoot}
+	for i := 1; i < len(waypoints); i++ {
+		from, to := waypoints[i-1], waypoints[i]
+		leg, err := errandRouteFunc(from[0], from[1], to[0], to[1])
+		if err != nil {
+			return nil, fmt.Errorf("leg %d: %v", i, err)
+		}
+		route.Steps = append(route.Steps, leg.Steps...)
+		route.TotalDist += leg.TotalDist
+		route.TotalTime += leg.TotalTime
+		route.Geometry = append(route.Geometry, leg.Geometry...)
+
+		mark := RouteStep{Lat: to[0], Lon: to[1]}
+		if i <= len(stops) {
+			s := stops[i-1]
+			mark.Instruction = fmt.Sprintf("📍 Stop %d: %s (%s)", i, s.Place.Name, s.Errand)
+			mark.Name = s.Place.Name
+		} else {
+			mark.Instruction = "🏁 Arrive at " + endName
+		}
+		route.Steps = append(route.Steps, mark)
+	}
+	route.Summary = fmt.Sprintf("%s · %s", formatRouteSummary(ProfileFoot, route.TotalDist, route.TotalTime), plural(len(stops), "stop"))
+	return route, nil
+}
+
+// FormatErrands renders an errand run with its route and a map link through the stops
+func FormatErrands(plan *ErrandPlan, fromLat, fromLon float64) string {
+	var lines []string
+	for i, s := range plan.Stops {
+		lines = append(lines, fmt.Sprintf("%d. %s - %s", i+1, s.Errand, s.Place.Name))
+	}
+	if plan.HasEnd() {
+		lines = append(lines, fmt.Sprintf("🏁 then %s", plan.EndName))
+	}
+	if len(plan.Missing) > 0 {
+		lines = append(lines, fmt.Sprintf("❓ Nowhere nearby for %s", strings.Join(plan.Missing, ", ")))
+	}
+	if plan.Route == nil {
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, "", FormatDirections(plan.Route))
+
+	// Google Maps link through every stop
+	dest := plan.Stops[len(plan.Stops)-1].Place
+	destLat, destLon := dest.Lat, dest.Lon
+	via := plan.Stops
+	if plan.HasEnd() {
+		destLat, destLon = plan.EndLat, plan.EndLon
+	} else {
+		via = via[:len(via)-1]
+	}
+	var waypoints []string
+	for _, s := range via {
+		waypoints = append(waypoints, fmt.Sprintf("%.6f,%.6f", s.Place.Lat, s.Place.Lon))
+	}
+	mapURL := fmt.Sprintf("https://www.google.com/maps/dir/?api=1&origin=%.6f,%.6f&destination=%.6f,%.6f&travelmode=walking",
+		fromLat, fromLon, destLat, destLon)
+	if len(waypoints) > 0 {
+		mapURL += "&waypoints=" + strings.Join(waypoints, "%7C")
+	}
+	lines = append(lines, "", "🗺️ "+mapURL)
+	return strings.Join(lines, "\n")
+}
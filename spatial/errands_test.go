@@ -0,0 +1,103 @@
+package spatial
+
+import (
+	"strings"
+	"testing"
+)
+
+// testErrands walks straight lines between points and returns errands
+// for a walk north: the near pharmacy is behind, the far one on the way
+func testErrands(t *testing.T) []Errand {
+	errandRouteFunc = func(fromLat, fromLon, toLat, toLon float64) (*Route, error) {
+		d := haversineMeters(fromLat, fromLon, toLat, toLon)
+		return &Route{
+			TotalDist: d,
+			TotalTime: d / ProfileFoot.Speed,
+			Geometry:  [][]float64{{fromLon, fromLat}, {toLon, toLat}},
+			Steps:     []RouteStep{{Instruction: "Walk", Distance: d, Lat: fromLat, Lon: fromLon}},
+		}, nil
+	}
+	t.Cleanup(func() { errandRouteFunc = GetWalkingDirections })
+
+	place := func(name string, lat, lon float64) *Entity {
+		return &Entity{Name: name, Lat: lat, Lon: lon}
+	}
+	return []Errand{
+		{Name: "cash", Candidates: []*Entity{place("ATM North", 51.509, -0.1), place("ATM East", 51.5, -0.09)}},
+		{Name: "pharmacy", Candidates: []*Entity{place("Boots South", 51.499, -0.1), place("Boots Middle", 51.504, -0.1)}},
+		{Name: "bakery"},
+	}
+}
+
+// testErrandsHome plans the errands on the way home, 1.1 km north
+func testErrandsHome(t *testing.T) *ErrandPlan {
+	plan, err := PlanErrands(51.5, -0.1, testErrands(t), "Home", 51.51, -0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+// TestErrandStops checks the run picks places and order to keep the walk short
+func TestErrandStops(t *testing.T) {
+	plan := testErrandsHome(t)
+	if len(plan.Stops) != 2 || plan.Stops[0].Place.Name != "Boots Middle" || plan.Stops[1].Place.Name != "ATM North" {
+		t.Fatalf("stops = %+v", plan.Stops)
+	}
+}
+
+// TestErrandMissing checks errands with nowhere nearby are listed, not dropped
+func TestErrandMissing(t *testing.T) {
+	plan := testErrandsHome(t)
+	if len(plan.Missing) != 1 || plan.Missing[0] != "bakery" {
+		t.Errorf("missing = %v", plan.Missing)
+	}
+}
+
+// TestErrandRoute checks the route runs through every stop and the end,
+// each leg ending at a marker step
+func TestErrandRoute(t *testing.T) {
+	plan := testErrandsHome(t)
+	var marks []string
+	for _, s := range plan.Route.Steps {
+		if s.Instruction != "Walk" {
+			marks = append(marks, s.Instruction)
+		}
+	}
+	want := []string{"📍 Stop 1: Boots Middle (pharmacy)", "📍 Stop 2: ATM North (cash)", "🏁 Arrive at Home"}
+	if strings.Join(marks, "|") != strings.Join(want, "|") {
+		t.Errorf("marks = %v", marks)
+	}
+}
+
+// TestFormatErrands checks the run's text and its map link waypoints
+func TestFormatErrands(t *testing.T) {
+	out := FormatErrands(testErrandsHome(t), 51.5, -0.1)
+	for _, s := range []string{"1. pharmacy - Boots Middle", "🏁 then Home", "❓ Nowhere nearby for bakery",
+		"destination=51.510000,-0.100000", "&waypoints=51.504000,-0.100000%7C51.509000,-0.100000"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q in:\n%s", s, out)
+		}
+	}
+}
+
+// TestErrandNoEnd checks a run without an end finishes at the nearest places
+func TestErrandNoEnd(t *testing.T) {
+	plan, err := PlanErrands(51.5, -0.1, testErrands(t)[:2], "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Stops[0].Place.Name != "Boots South" || plan.Stops[1].Place.Name != "ATM East" {
+		t.Errorf("stops = %+v", plan.Stops)
+	}
+	if strings.Contains(FormatErrands(plan, 51.5, -0.1), "🏁") {
+		t.Error("shouldn't show a finish")
+	}
+}
+
+// TestErrandNowhere checks a run with nowhere to go is an error
+func TestErrandNowhere(t *testing.T) {
+	if _, err := PlanErrands(51.5, -0.1, []Errand{{Name: "bakery"}}, "", 0, 0); err == nil {
+		t.Error("expected an error with nowhere to go")
+	}
+}
@@ -0,0 +1,209 @@
+package spatial
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Export of recorded tracks and planned routes as GPX and GeoJSON, for use
+// in other apps. Only a session's own track and last route are exported.
+
+const exportRouteTTL = 6 * time.Hour
+
+// exportedRoute is the last route planned for a session
+type exportedRoute struct {
+	route *Route
+	name  string
+	at    time.Time
+}
+
+var (
+	sessionRoutes   = make(map[string]*exportedRoute)
+	sessionRoutesMu sync.Mutex
+)
+
+// RememberRoute keeps a session's latest route so it can be exported
+func RememberRoute(session string, route *Route, name string) {
+	if session == "" || route == nil || len(route.Geometry) < 2 {
+		return
+	}
+	sessionRoutesMu.Lock()
+	defer sessionRoutesMu.Unlock()
+	now := time.Now()
+	for s, r := range sessionRoutes {
+		if now.Sub(r.at) > exportRouteTTL {
+			delete(sessionRoutes, s)
+		}
+	}
+	sessionRoutes[session] = &exportedRoute{route: route, name: name, at: now}
+}
+
+// SessionRoute returns a session's latest route and its destination, nil if none
+func SessionRoute(session string) (*Route, string) {
+	sessionRoutesMu.Lock()
+	defer sessionRoutesMu.Unlock()
+	r, ok := sessionRoutes[session]
+	if !ok || time.Since(r.at) > exportRouteTTL {
+		return nil, ""
+	}
+	return r.route, r.name
+}
+
+// GPX 1.1 document, only the parts used here
+type gpxDoc struct {
+	XMLName   xml.Name `xml:"gpx"`
+	Version   string   `xml:"version,attr"`
+	Creator   string   `xml:"creator,attr"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	Metadata  *gpxMeta `xml:"metadata,omitempty"` // Schema order: metadata, wpt, trk
+	Waypoints []gpxPt  `xml:"wpt,omitempty"`
+	Tracks    []gpxTrk `xml:"trk"`
+}
+
+type gpxMeta struct {
+	Name string `xml:"name,omitempty"`
+	Desc string `xml:"desc,omitempty"`
+}
+
+type gpxTrk struct {
+	Name     string   `xml:"name,omitempty"`
+	Segments []gpxSeg `xml:"trkseg"`
+}
+
+type gpxSeg struct {
+	Points []gpxPt `xml:"trkpt"`
+}
+
+type gpxPt struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time,omitempty"`
+	Name string  `xml:"name,omitempty"`
+}
+
+func (d *gpxDoc) encode() ([]byte, error) {
+	d.Version, d.Creator, d.Xmlns = "1.1", "Malten", "http://www.topografix.com/GPX/1/1"
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// TrackGPX renders recorded segments as a GPX track, one trkseg each
+func TrackGPX(segments [][]TrackPoint, name string) ([]byte, error) {
+	trk := gpxTrk{Name: name}
+	for _, seg := range segments {
+		var s gpxSeg
+		for _, p := range seg {
+			s.Points = append(s.Points, gpxPt{Lat: p.Lat, Lon: p.Lon, Time: p.Timestamp.UTC().Format(time.RFC3339)})
+		}
+		trk.Segments = append(trk.Segments, s)
+	}
+	doc := &gpxDoc{Tracks: []gpxTrk{trk}}
+	return doc.encode()
+}
+
+// TrackGeoJSON renders recorded segments as a MultiLineString Feature
+// Timestamps go in the coordTimes property, as GPX converters do
+func TrackGeoJSON(segments [][]TrackPoint, name string) map[string]interface{} {
+	coords := [][][]float64{}
+	times := [][]string{}
+	for _, seg := range segments {
+		var line [][]float64
+		var ts []string
+		for _, p := range seg {
+			line = append(line, []float64{p.Lon, p.Lat})
+			ts = append(ts, p.Timestamp.UTC().Format(time.RFC3339))
+		}
+		coords = append(coords, line)
+		times = append(times, ts)
+	}
+	return map[string]interface{}{
+		"type": "Feature",
+		"geometry": map[string]interface{}{
+			"type":        "MultiLineString",
+			"coordinates": coords,
+		},
+		"properties": map[string]interface{}{
+			"name":       name,
+			"coordTimes": times,
+		},
+	}
+}
+
+// RouteGPX renders a route as a GPX track, with its turns as waypoints
+func RouteGPX(route *Route, name string) ([]byte, error) {
+	var seg gpxSeg
+	for _, pt := range route.Geometry {
+		if len(pt) >= 2 {
+			seg.Points = append(seg.Points, gpxPt{Lat: pt[1], Lon: pt[0]})
+		}
+	}
+	doc := &gpxDoc{
+		Metadata: &gpxMeta{Name: name, Desc: route.Summary},
+		Tracks:   []gpxTrk{{Name: name, Segments: []gpxSeg{seg}}},
+	}
+	for _, step := range route.Steps {
+		if step.Lat != 0 || step.Lon != 0 {
+			doc.Waypoints = append(doc.Waypoints, gpxPt{Lat: step.Lat, Lon: step.Lon, Name: step.Instruction})
+		}
+	}
+	return doc.encode()
+}
+
+// RouteGeoJSON renders a route as a FeatureCollection: the line, then a point per turn
+func RouteGeoJSON(route *Route, name string) map[string]interface{} {
+	mode := route.mode().Name
+	if len(route.Legs) > 0 {
+		mode = "transit"
+	}
+	features := []interface{}{
+		map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "LineString",
+				"coordinates": route.Geometry,
+			},
+			"properties": map[string]interface{}{
+				"name":     name,
+				"summary":  route.Summary,
+				"distance": route.TotalDist,
+				"duration": route.TotalTime,
+				"mode":     mode,
+			},
+		},
+	}
+	for i, step := range route.Steps {
+		if step.Lat == 0 && step.Lon == 0 {
+			continue
+		}
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "Point",
+				"coordinates": []float64{step.Lon, step.Lat},
+			},
+			"properties": map[string]interface{}{
+				"step":        i + 1,
+				"instruction": step.Instruction,
+			},
+		})
+	}
+	return map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	}
+}
+
+// ExportFilename names a download: "malten-track-2026-10-18.gpx"
+func ExportFilename(kind, ext string) string {
+	return fmt.Sprintf("malten-%s-%s.%s", kind, time.Now().Format("2006-01-02"), ext)
+}
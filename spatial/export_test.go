@@ -0,0 +1,142 @@
+package spatial
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testUserTrack records a session walking east now, removed after the test
+func testUserTrack(t *testing.T, session string) {
+	userTracksMu.Lock()
+	userTracks[session] = &UserTrack{SessionID: session, Points: []TrackPoint{
+		{Lat: 51.51, Lon: -0.1, Timestamp: time.Now().Add(-time.Minute)},
+		{Lat: 51.51, Lon: -0.099, Timestamp: time.Now()},
+	}}
+	userTracksMu.Unlock()
+	t.Cleanup(func() {
+		userTracksMu.Lock()
+		delete(userTracks, session)
+		userTracksMu.Unlock()
+	})
+}
+
+// testTrackSegments records a session's walk north an hour ago and walk
+// east now, returning both segments
+func testTrackSegments(t *testing.T, session string) [][]TrackPoint {
+	testUserTrack(t, session)
+	userTracksMu.Lock()
+	userTracks[session].Segments = [][]TrackPoint{{
+		{Lat: 51.5, Lon: -0.1, Timestamp: time.Now().Add(-time.Hour)},
+		{Lat: 51.5005, Lon: -0.1, Timestamp: time.Now().Add(-59 * time.Minute)},
+		{Lat: 51.501, Lon: -0.1, Timestamp: time.Now().Add(-58 * time.Minute)},
+	}}
+	userTracksMu.Unlock()
+	segs := SessionTrack(session, 0)
+	if len(segs) != 2 || len(segs[0]) != 3 {
+		t.Fatalf("segments = %v", segs)
+	}
+	return segs
+}
+
+// TestSessionTrackAgesOut checks archived segments age out of the history
+func TestSessionTrackAgesOut(t *testing.T) {
+	session := "export-test-aged"
+	testUserTrack(t, session)
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	userTracksMu.Lock()
+	track := userTracks[session]
+	current := track.Points
+	track.Points = []TrackPoint{
+		{Lat: 51.5, Lon: -0.1, Timestamp: start},
+		{Lat: 51.5005, Lon: -0.1, Timestamp: start.Add(time.Minute)},
+		{Lat: 51.501, Lon: -0.1, Timestamp: start.Add(2 * time.Minute)},
+	}
+	track.archive(start.Add(3 * time.Minute))
+	track.Points = current
+	userTracksMu.Unlock()
+
+	if segs := SessionTrack(session, 0); len(segs) != 1 || len(segs[0]) != 2 {
+		t.Fatalf("segments = %v", segs)
+	}
+}
+
+// TestSessionTrackSimplify checks a straight line simplifies to its ends,
+// keeping their times
+func TestSessionTrackSimplify(t *testing.T) {
+	session := "export-test-simplify"
+	segs := testTrackSegments(t, session)
+	simple := SessionTrack(session, 10)
+	if len(simple[0]) != 2 || !simple[0][1].Timestamp.Equal(segs[0][2].Timestamp) {
+		t.Errorf("simplified = %v", simple[0])
+	}
+}
+
+// TestTrackGPX checks GPX keeps a track's segments and timestamps
+func TestTrackGPX(t *testing.T) {
+	segs := testTrackSegments(t, "export-test-gpx")
+	gpx, err := TrackGPX(segs, "My track")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(gpx)
+	if strings.Count(s, "<trkseg>") != 2 || strings.Count(s, "<trkpt") != 5 ||
+		!strings.Contains(s, `<trkpt lat="51.5" lon="-0.1">`) ||
+		!strings.Contains(s, "<time>"+segs[0][0].Timestamp.UTC().Format(time.RFC3339)+"</time>") {
+		t.Errorf("gpx:\n%s", s)
+	}
+}
+
+// TestTrackGeoJSON checks GeoJSON keeps a track's segments and timestamps
+func TestTrackGeoJSON(t *testing.T) {
+	segs := testTrackSegments(t, "export-test-geojson")
+	b, _ := json.Marshal(TrackGeoJSON(segs, "My track"))
+	var fc struct {
+		Geometry struct {
+			Type        string        `json:"type"`
+			Coordinates [][][]float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			CoordTimes [][]string `json:"coordTimes"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &fc); err != nil {
+		t.Fatal(err)
+	}
+	if fc.Geometry.Type != "MultiLineString" || len(fc.Geometry.Coordinates) != 2 ||
+		fc.Geometry.Coordinates[1][1][0] != -0.099 || len(fc.Properties.CoordTimes[0]) != 3 {
+		t.Errorf("geojson: %s", b)
+	}
+}
+
+// testExportRoute is a five minute walk north to Church
+func testExportRoute() *Route {
+	return &Route{
+		Summary:  "5 min walk",
+		Geometry: [][]float64{{-0.1, 51.5}, {-0.1, 51.501}},
+		Steps:    []RouteStep{{Instruction: "Head north on High Street", Lat: 51.5, Lon: -0.1}},
+	}
+}
+
+// TestRememberRoute checks a session's latest route is kept with its destination
+func TestRememberRoute(t *testing.T) {
+	route := testExportRoute()
+	RememberRoute("export-test-route", route, "Church")
+	if got, name := SessionRoute("export-test-route"); got != route || name != "Church" {
+		t.Errorf("SessionRoute = %v, %q", got, name)
+	}
+}
+
+// TestRouteGPX checks metadata and turns come before the track, as GPX requires
+func TestRouteGPX(t *testing.T) {
+	gpx, err := RouteGPX(testExportRoute(), "Church")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(gpx)
+	meta, wpt, trk := strings.Index(s, "<metadata>"), strings.Index(s, "<wpt"), strings.Index(s, "<trk>")
+	if meta < 0 || !(meta < wpt && wpt < trk) || !strings.Contains(s, "<name>Head north on High Street</name>") {
+		t.Errorf("route gpx:\n%s", s)
+	}
+}
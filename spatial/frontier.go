@@ -0,0 +1,352 @@
+package spatial
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Frontier exploration. Couriers walk to learn streets, and every route costs
+// an OSRM call, so instead of heading for agents by simple rules they plan
+// from a coverage model: the streets already stored, marked on ~150m geohash
+// cells. Candidate destinations are the geohash-6 cells around agents, scored
+// by how much of the cell and the way there has no streets yet, whether it
+// bridges parts of the street network that don't connect, and how far it is.
+// The best scoring cell is the one route expected to add the most new street
+// metres. A courier claims the cell it heads for, so couriers planning from
+// nearby spots don't all walk to the same one.
+
+const (
+	frontierPrecision = 6       // Cells couriers head for, the size of an agent tile
+	coveragePrecision = 7       // ~150m cells a stored street marks as covered
+	frontierRadius    = 50000.0 // Meters a courier plans ahead, as a regional cluster
+	frontierSample    = 100.0   // Meters between samples along the way to a cell
+	coverageStep      = 50.0    // Meters between samples along a stored street
+	walkDetour        = 1.3     // Walking routes run this much longer than the crow flies
+	frontierScale     = 5000.0  // Meters at which distance halves a cell's score
+	frontierBridge    = 2.0     // Score multiplier for reaching a disconnected part
+	frontierSparse    = 1.5     // Score multiplier for an agent with few connections
+)
+
+// FrontierCell is a candidate courier destination
+type FrontierCell struct {
+	Cell      string  `json:"cell"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	AgentID   string  `json:"agent_id,omitempty"` // Agent the cell belongs to or borders
+	Name      string  `json:"name"`
+	Distance  float64 `json:"distance"`
+	Missing   float64 `json:"missing"`    // Share of the cell with no streets
+	Corridor  float64 `json:"corridor"`   // Share of the way there with no streets
+	NewMeters float64 `json:"new_meters"` // Street meters one route there is expected to add
+	Bridge    bool    `json:"bridge"`     // In a part of the network the courier's isn't connected to
+	Score     float64 `json:"score"`
+}
+
+var (
+	frontierClaims   = make(map[string]string) // Cell -> courier heading there
+	frontierClaimsMu sync.Mutex
+)
+
+// claimFrontier reserves a cell for a courier, dropping the cell it claimed
+// before. Reports false if another courier has it
+func claimFrontier(courierID, cell string) bool {
+	frontierClaimsMu.Lock()
+	defer frontierClaimsMu.Unlock()
+	if owner, ok := frontierClaims[cell]; ok && owner != courierID {
+		return false
+	}
+	for c, owner := range frontierClaims {
+		if owner == courierID {
+			delete(frontierClaims, c)
+		}
+	}
+	frontierClaims[cell] = courierID
+	return true
+}
+
+// releaseFrontier drops a courier's claim once it arrives or stops
+func releaseFrontier(courierID string) {
+	frontierClaimsMu.Lock()
+	defer frontierClaimsMu.Unlock()
+	for c, owner := range frontierClaims {
+		if owner == courierID {
+			delete(frontierClaims, c)
+		}
+	}
+}
+
+// frontierClaimed reports whether another courier is heading for a cell
+func frontierClaimed(courierID, cell string) bool {
+	frontierClaimsMu.Lock()
+	defer frontierClaimsMu.Unlock()
+	owner, ok := frontierClaims[cell]
+	return ok && owner != courierID
+}
+
+// streetCoverage is the set of geohash-7 cells stored streets pass through
+type streetCoverage map[string]bool
+
+// buildStreetCoverage marks the cells covered by streets within radius
+func buildStreetCoverage(db *DB, lat, lon, radius float64) streetCoverage {
+	cov := make(streetCoverage)
+	for _, street := range db.Query(lat, lon, radius, EntityStreet, 20000) {
+		if sd := street.GetStreetData(); sd != nil {
+			cov.add(sd.Points)
+		}
+	}
+	return cov
+}
+
+// add marks the cells along a line of [lon, lat] points
+func (c streetCoverage) add(points [][]float64) {
+	for i, p := range points {
+		c[Geohash(p[1], p[0], coveragePrecision)] = true
+		if i == 0 {
+			continue
+		}
+		prev := points[i-1]
+		eachSample(prev[1], prev[0], p[1], p[0], coverageStep, func(lat, lon float64) {
+			c[Geohash(lat, lon, coveragePrecision)] = true
+		})
+	}
+}
+
+// covered reports whether a street passes near a point
+func (c streetCoverage) covered(lat, lon float64) bool {
+	return c[Geohash(lat, lon, coveragePrecision)]
+}
+
+// missing is the share of a frontier cell's 32 sub-cells with no streets
+func (c streetCoverage) missing(cell string) float64 {
+	empty := 0
+	for i := 0; i < len(geohashBase32); i++ {
+		if !c[cell+geohashBase32[i:i+1]] {
+			empty++
+		}
+	}
+	return float64(empty) / float64(len(geohashBase32))
+}
+
+// corridor is the share of the straight line between two points with no streets
+func (c streetCoverage) corridor(lat1, lon1, lat2, lon2 float64) float64 {
+	samples, empty := 0, 0
+	eachSample(lat1, lon1, lat2, lon2, frontierSample, func(lat, lon float64) {
+		samples++
+		if !c.covered(lat, lon) {
+			empty++
+		}
+	})
+	if samples == 0 {
+		return 0
+	}
+	return float64(empty) / float64(samples)
+}
+
+// routeGain measures what a route of [lon, lat] points adds: the meters of
+// it on cells with no streets, and how many such cells it passes through.
+// Routes come from OSRM, whose geometry starts and ends on the streets it
+// snapped to, so there are no straight legs from the raw points to count
+func (c streetCoverage) routeGain(route [][]float64) (float64, int) {
+	meters := 0.0
+	fresh := make(map[string]bool)
+	for i := 1; i < len(route); i++ {
+		p1, p2 := route[i-1], route[i]
+		samples, empty := 0, 0
+		eachSample(p1[1], p1[0], p2[1], p2[0], coverageStep, func(lat, lon float64) {
+			samples++
+			if cell := Geohash(lat, lon, coveragePrecision); !c[cell] {
+				empty++
+				fresh[cell] = true
+			}
+		})
+		meters += DistanceMeters(p1[1], p1[0], p2[1], p2[0]) * float64(empty) / float64(samples)
+	}
+	return meters, len(fresh)
+}
+
+// eachSample calls fn at points every step meters from one end of a line to
+// the other, including the far end but not the near one
+func eachSample(lat1, lon1, lat2, lon2, step float64, fn func(lat, lon float64)) {
+	n := int(math.Ceil(DistanceMeters(lat1, lon1, lat2, lon2) / step))
+	if n < 1 {
+		n = 1
+	}
+	for i := 1; i <= n; i++ {
+		f := float64(i) / float64(n)
+		fn(lat1+(lat2-lat1)*f, lon1+(lon2-lon1)*f)
+	}
+}
+
+// frontierCells returns an agent's cell and the eight around it
+func frontierCells(agent *Entity) []string {
+	home := Geohash(agent.Lat, agent.Lon, frontierPrecision)
+	minLat, maxLat, minLon, maxLon := GeohashBounds(home)
+	lat, lon := GeohashCenter(home)
+	dLat, dLon := maxLat-minLat, maxLon-minLon
+
+	cells := []string{home}
+	for _, y := range []float64{-1, 0, 1} {
+		for _, x := range []float64{-1, 0, 1} {
+			if x != 0 || y != 0 {
+				cells = append(cells, Geohash(lat+y*dLat, lon+x*dLon, frontierPrecision))
+			}
+		}
+	}
+	return cells
+}
+
+// scoreFrontier ranks the cells around agents as destinations from a
+// position, best first, given street coverage and which agents connect
+func scoreFrontier(lat, lon float64, agents []*Entity, cov streetCoverage, connected map[string]map[string]bool, maxDist float64) []FrontierCell {
+	// Each cell goes to the agent inside it, or else the first to border it
+	owner := make(map[string]*Entity)
+	home := make(map[string]bool)
+	for _, agent := range agents {
+		for i, cell := range frontierCells(agent) {
+			if i == 0 && !home[cell] {
+				owner[cell], home[cell] = agent, true
+			} else if owner[cell] == nil {
+				owner[cell] = agent
+			}
+		}
+	}
+
+	here := findComponent(lat, lon, agents, connected)
+	var frontier []FrontierCell
+	for cell, agent := range owner {
+		f := FrontierCell{Cell: cell, AgentID: agent.ID, Name: agent.Name}
+		if home[cell] {
+			// Head for the agent itself, which is somewhere people are
+			f.Lat, f.Lon = agent.Lat, agent.Lon
+		} else {
+			f.Lat, f.Lon = GeohashCenter(cell)
+			f.Name = "near " + agent.Name
+		}
+
+		f.Distance = DistanceMeters(lat, lon, f.Lat, f.Lon)
+		if f.Distance < 100 || f.Distance > maxDist {
+			continue
+		}
+		if f.Missing = cov.missing(cell); f.Missing == 0 {
+			continue // Fully mapped
+		}
+		f.Corridor = cov.corridor(lat, lon, f.Lat, f.Lon)
+		f.NewMeters = f.Corridor * f.Distance * walkDetour
+
+		gap := 1.0
+		if comp := findComponent(f.Lat, f.Lon, agents, connected); comp != "" && comp != here {
+			f.Bridge = true
+			gap = frontierBridge
+		} else if len(connected[agent.ID]) < 3 {
+			gap = frontierSparse
+		}
+		// New meters per route, weighted to empty cells and gaps in the
+		// network, with nearer cells preferred for the same gain
+		f.Score = f.NewMeters * (0.5 + f.Missing/2) * gap / (1 + f.Distance/frontierScale)
+		frontier = append(frontier, f)
+	}
+
+	sort.Slice(frontier, func(i, j int) bool {
+		if frontier[i].Score != frontier[j].Score {
+			return frontier[i].Score > frontier[j].Score
+		}
+		return frontier[i].Cell < frontier[j].Cell
+	})
+	return frontier
+}
+
+// planFrontier ranks destinations for a courier at a position, returning the
+// coverage it planned from
+func planFrontier(db *DB, lat, lon float64) ([]FrontierCell, streetCoverage) {
+	var agents []*Entity
+	for _, agent := range db.ListAgents() {
+		if DistanceMeters(lat, lon, agent.Lat, agent.Lon) < frontierRadius {
+			agents = append(agents, agent)
+		}
+	}
+	if len(agents) == 0 {
+		return nil, nil
+	}
+
+	cov := buildStreetCoverage(db, lat, lon, frontierRadius)
+	connected := buildConnectivityGraph(db, agents)
+	return scoreFrontier(lat, lon, agents, cov, connected, frontierRadius), cov
+}
+
+// CourierFrontier returns the n best courier destinations from a position
+func CourierFrontier(lat, lon float64, n int) []FrontierCell {
+	frontier, _ := planFrontier(Get(), lat, lon)
+	if len(frontier) > n {
+		frontier = frontier[:n]
+	}
+	return frontier
+}
+
+// startCourierTrip routes a courier to a frontier cell, claims the cell and
+// stores the route as a street, returning the new street meters it adds
+func startCourierTrip(db *DB, courierID string, courier *CourierState, f FrontierCell, cov streetCoverage) (float64, error) {
+	if frontierClaimed(courierID, f.Cell) {
+		return 0, fmt.Errorf("another courier is heading there")
+	}
+	if !OSRMEnabled() {
+		return 0, fmt.Errorf("couriers need OSRM")
+	}
+	// Only requests OSRM actually gets count toward new meters per call
+	courier.OSRMCalls++
+	route, err := OSRMWalkingRoute(courier.CurrentLat, courier.CurrentLon, f.Lat, f.Lon)
+	if err != nil {
+		return 0, err
+	}
+	if len(route.Coordinates) < 2 {
+		return 0, fmt.Errorf("route too short")
+	}
+
+	claimFrontier(courierID, f.Cell)
+	gain, cells := cov.routeGain(route.Coordinates)
+	courier.NewMeters += gain
+	courier.NewCells += cells
+
+	courier.TargetAgent = f.AgentID
+	courier.TargetName = f.Name
+	courier.Route = route.Coordinates
+	courier.RouteIndex = 0
+
+	// Index the full street geometry now, so the next plan sees it covered
+	street := &Entity{
+		ID:   GenerateID(EntityStreet, courier.CurrentLat, courier.CurrentLon, f.Name),
+		Type: EntityStreet,
+		Name: "Courier route to " + f.Name,
+		Lat:  courier.CurrentLat,
+		Lon:  courier.CurrentLon,
+		Data: &StreetData{
+			Points: route.Coordinates,
+			Length: route.Distance,
+			ToName: f.Name,
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	db.Insert(street)
+
+	log.Printf("[courier] route to %s: %.0fm, %.0fm new (expected %.0fm), %d new cells",
+		f.Name, route.Distance, gain, f.NewMeters, cells)
+	return gain, nil
+}
+
+// coverageGain summarises what a courier's routes have added
+func coverageGain(c *CourierState) map[string]interface{} {
+	perCall := 0.0
+	if c.OSRMCalls > 0 {
+		perCall = c.NewMeters / float64(c.OSRMCalls)
+	}
+	return map[string]interface{}{
+		"osrm_calls":     c.OSRMCalls,
+		"new_km":         math.Round(c.NewMeters/100) / 10,
+		"new_cells":      c.NewCells,
+		"new_m_per_call": math.Round(perCall),
+	}
+}
@@ -0,0 +1,157 @@
+package spatial
+
+import "testing"
+
+// testFrontier has agents A and C joined by a street and B on its own
+type testFrontier struct {
+	a, b, c   *Entity
+	agents    []*Entity
+	street    [][]float64
+	cov       streetCoverage
+	connected map[string]map[string]bool
+}
+
+// newTestFrontier builds the three agents, the street and its coverage
+func newTestFrontier() *testFrontier {
+	f := &testFrontier{
+		a: &Entity{ID: "frontier-a", Name: "A", Lat: 51.40, Lon: -0.36},
+		b: &Entity{ID: "frontier-b", Name: "B", Lat: 51.41, Lon: -0.30},
+		c: &Entity{ID: "frontier-c", Name: "C", Lat: 51.46, Lon: -0.36},
+	}
+	f.agents = []*Entity{f.a, f.b, f.c}
+	f.street = [][]float64{{f.a.Lon, f.a.Lat}, {f.c.Lon, f.c.Lat}}
+	f.cov = make(streetCoverage)
+	f.cov.add(f.street)
+	f.connected = map[string]map[string]bool{
+		f.a.ID: {f.c.ID: true},
+		f.b.ID: {},
+		f.c.ID: {f.a.ID: true},
+	}
+	return f
+}
+
+// score ranks the frontier from A
+func (f *testFrontier) score() []FrontierCell {
+	return scoreFrontier(f.a.Lat, f.a.Lon, f.agents, f.cov, f.connected, frontierRadius)
+}
+
+// best is the top frontier cell from A, failing the test if there's none
+func (f *testFrontier) best(t *testing.T) FrontierCell {
+	frontier := f.score()
+	if len(frontier) == 0 {
+		t.Fatal("no frontier")
+	}
+	return frontier[0]
+}
+
+// TestFrontierBridge checks the best frontier bridges to an unconnected agent
+func TestFrontierBridge(t *testing.T) {
+	f := newTestFrontier()
+	if best := f.best(t); best.AgentID != f.b.ID || !best.Bridge || best.Corridor != 1 {
+		t.Errorf("best frontier %+v", best)
+	}
+}
+
+// TestFrontierMappedWay checks the way to an agent streets already reach
+// scores below the bridge
+func TestFrontierMappedWay(t *testing.T) {
+	f := newTestFrontier()
+	frontier := f.score()
+	for _, cell := range frontier {
+		if cell.Cell == Geohash(f.c.Lat, f.c.Lon, frontierPrecision) && (cell.Corridor > 0.1 || cell.Score >= frontier[0].Score) {
+			t.Errorf("mapped way to C scored %+v", cell)
+		}
+	}
+}
+
+// TestFrontierFilledCell checks a cell streets fill entirely is no longer a frontier
+func TestFrontierFilledCell(t *testing.T) {
+	f := newTestFrontier()
+	home := Geohash(f.b.Lat, f.b.Lon, frontierPrecision)
+	for i := 0; i < len(geohashBase32); i++ {
+		f.cov[home+geohashBase32[i:i+1]] = true
+	}
+	if f.cov.missing(home) != 0 {
+		t.Errorf("filled cell missing %.2f", f.cov.missing(home))
+	}
+	for _, cell := range f.score() {
+		if cell.Cell == home {
+			t.Errorf("filled cell still a frontier: %+v", cell)
+		}
+	}
+}
+
+// TestRouteGainKnownStreet checks walking a known street adds nothing
+func TestRouteGainKnownStreet(t *testing.T) {
+	f := newTestFrontier()
+	if gain, cells := f.cov.routeGain(f.street); gain > 1 || cells != 0 {
+		t.Errorf("known street gained %.0fm, %d cells", gain, cells)
+	}
+}
+
+// TestRouteGainNewRoute checks a route off the known streets is nearly all new
+func TestRouteGainNewRoute(t *testing.T) {
+	f := newTestFrontier()
+	a := f.a
+	toB := [][]float64{{a.Lon, a.Lat}, {a.Lon + 0.03, a.Lat + 0.005}}
+	length := DistanceMeters(a.Lat, a.Lon, a.Lat+0.005, a.Lon+0.03)
+	if gain, cells := f.cov.routeGain(toB); gain < length*0.9 || cells == 0 {
+		t.Errorf("new route gained %.0fm of %.0fm, %d cells", gain, length, cells)
+	}
+}
+
+// TestFrontierClaim checks one courier at a time claims a cell, and only
+// the others see it claimed
+func TestFrontierClaim(t *testing.T) {
+	cell := newTestFrontier().best(t).Cell
+	t.Cleanup(func() { releaseFrontier("frontier-one") })
+	if !claimFrontier("frontier-one", cell) || claimFrontier("frontier-two", cell) {
+		t.Error("two couriers claimed one cell")
+	}
+	if !frontierClaimed("frontier-two", cell) || frontierClaimed("frontier-one", cell) {
+		t.Error("claim not seen by the other courier only")
+	}
+}
+
+// TestFrontierClaimedTrip checks a courier doesn't route to a cell another
+// is heading for
+func TestFrontierClaimedTrip(t *testing.T) {
+	f := newTestFrontier()
+	best := f.best(t)
+	t.Cleanup(func() { releaseFrontier("frontier-one") })
+	claimFrontier("frontier-one", best.Cell)
+	courier := &CourierState{CurrentLat: f.a.Lat, CurrentLon: f.a.Lon}
+	if _, err := startCourierTrip(Get(), "frontier-two", courier, best, f.cov); err == nil || courier.OSRMCalls != 0 {
+		t.Errorf("trip to a claimed cell: %v, %d calls", err, courier.OSRMCalls)
+	}
+}
+
+// TestFrontierRelease checks a claim ends when its courier arrives
+func TestFrontierRelease(t *testing.T) {
+	cell := newTestFrontier().best(t).Cell
+	claimFrontier("frontier-one", cell)
+	releaseFrontier("frontier-one")
+	if frontierClaimed("frontier-two", cell) {
+		t.Error("claim kept after arrival")
+	}
+}
+
+// TestCourierTripWithoutOSRM checks no request is made or counted with OSRM off
+func TestCourierTripWithoutOSRM(t *testing.T) {
+	f := newTestFrontier()
+	best := f.best(t)
+	defer func(url string) { osrmBaseURL = url }(osrmBaseURL)
+	osrmBaseURL = "off"
+	courier := &CourierState{CurrentLat: f.a.Lat, CurrentLon: f.a.Lon}
+	if _, err := startCourierTrip(Get(), "frontier-two", courier, best, f.cov); err == nil || courier.OSRMCalls != 0 {
+		t.Errorf("trip without OSRM: %v, %d calls", err, courier.OSRMCalls)
+	}
+}
+
+// TestCoverageGain checks coverage counts what routes add per OSRM call
+func TestCoverageGain(t *testing.T) {
+	stats := coverageGain(&CourierState{OSRMCalls: 4, NewMeters: 2000, NewCells: 12})
+	if stats["new_m_per_call"] != 500.0 || stats["new_km"] != 2.0 {
+		t.Errorf("coverage stats %v", stats)
+	}
+}
@@ -0,0 +1,536 @@
+package spatial
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Offline reverse geocoding from data we already hold:
+//   - street:   location cells (exact Nominatim roads) then street geometry
+//   - postcode: imported postcode centroids then location cells
+//   - area:     suburb/city names recorded on location cells
+// Nominatim is only called when these can't answer.
+
+const (
+	geocodeStreetCellRadius = 30.0   // Location cell road is trusted within 30m
+	geocodeStreetLineRadius = 20.0   // Street geometry must pass within 20m
+	geocodePostcodeRadius   = 400.0  // Nearest postcode centroid within 400m
+	geocodeAreaRadius       = 1500.0 // Suburb vote from cells within 1.5km
+	geocodeCellMaxAge       = 7 * 24 * 3600
+)
+
+// Address is a reverse geocoded address
+type Address struct {
+	Street   string
+	Postcode string
+	Area     string // Suburb or town
+	City     string
+}
+
+// Name formats the address like fetchLocation: "Road, Postcode" or "Road, Area"
+func (a *Address) Name() string {
+	area := a.Postcode
+	if area == "" {
+		area = a.Area
+	}
+	switch {
+	case a.Street != "" && area != "":
+		return a.Street + ", " + area
+	case a.Street != "":
+		return a.Street
+	default:
+		return area
+	}
+}
+
+// postcodeCentroid is one row of the imported postcode dataset
+type postcodeCentroid struct {
+	postcode string
+	lat, lon float64
+}
+
+var (
+	postcodeGrid   map[[2]int][]postcodeCentroid // 0.01° cells (~1km)
+	postcodeByCode map[string]postcodeCentroid   // "TW122LL" -> centroid
+	postcodeGridMu sync.RWMutex
+)
+
+func init() {
+	loadPostcodeCentroids()
+}
+
+// loadPostcodeCentroids loads data/postcodes.csv if present
+// Accepts ONS (pcds, lat, long) or Code-Point style (postcode, latitude, longitude) headers
+func loadPostcodeCentroids() {
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		return
+	}
+	path := filepath.Join(filepath.Dir(filename), "..", "data", "postcodes.csv")
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("[geocode] No postcode centroids (%v), using cached locations only", err)
+		return
+	}
+	defer f.Close()
+
+	grid, err := readPostcodeCSV(f)
+	if err != nil {
+		log.Printf("[geocode] Could not parse postcodes.csv: %v", err)
+		return
+	}
+	setPostcodeGrid(grid)
+}
+
+// setPostcodeGrid installs a postcode dataset and indexes it by code
+func setPostcodeGrid(grid map[[2]int][]postcodeCentroid) {
+	byCode := make(map[string]postcodeCentroid)
+	for _, cell := range grid {
+		for _, pc := range cell {
+			byCode[postcodeKey(pc.postcode)] = pc
+		}
+	}
+
+	postcodeGridMu.Lock()
+	postcodeGrid = grid
+	postcodeByCode = byCode
+	postcodeGridMu.Unlock()
+}
+
+func postcodeKey(pc string) string {
+	return strings.ToUpper(strings.ReplaceAll(pc, " ", ""))
+}
+
+func readPostcodeCSV(r io.Reader) (map[[2]int][]postcodeCentroid, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	pcCol, latCol, lonCol := -1, -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "pcds", "postcode", "pcd":
+			if pcCol < 0 || h == "pcds" {
+				pcCol = i
+			}
+		case "lat", "latitude":
+			latCol = i
+		case "long", "lon", "longitude":
+			lonCol = i
+		}
+	}
+	if pcCol < 0 || latCol < 0 || lonCol < 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	grid := make(map[[2]int][]postcodeCentroid)
+	var count int
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		lat, err1 := strconv.ParseFloat(rec[latCol], 64)
+		lon, err2 := strconv.ParseFloat(rec[lonCol], 64)
+		// ONS uses 99.999999 for postcodes with no grid reference
+		if err1 != nil || err2 != nil || lat > 90 {
+			continue
+		}
+		pc := postcodeCentroid{postcode: strings.TrimSpace(rec[pcCol]), lat: lat, lon: lon}
+		key := postcodeCell(lat, lon)
+		grid[key] = append(grid[key], pc)
+		count++
+	}
+	log.Printf("[geocode] Loaded %d postcode centroids", count)
+	return grid, nil
+}
+
+func postcodeCell(lat, lon float64) [2]int {
+	return [2]int{int(math.Floor(lat * 100)), int(math.Floor(lon * 100))}
+}
+
+// nearestPostcode returns the closest imported postcode within maxMeters
+func nearestPostcode(lat, lon, maxMeters float64) string {
+	postcodeGridMu.RLock()
+	defer postcodeGridMu.RUnlock()
+	if postcodeGrid == nil {
+		return ""
+	}
+
+	center := postcodeCell(lat, lon)
+	best, bestDist := "", maxMeters
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			for _, pc := range postcodeGrid[[2]int{center[0] + dy, center[1] + dx}] {
+				if d := haversineMeters(lat, lon, pc.lat, pc.lon); d < bestDist {
+					best, bestDist = pc.postcode, d
+				}
+			}
+		}
+	}
+	return best
+}
+
+var postcodeRe = regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][A-Z]{2}$`)
+
+// isPostcode reports whether s looks like a UK postcode
+func isPostcode(s string) bool {
+	return postcodeRe.MatchString(strings.ToUpper(strings.TrimSpace(s)))
+}
+
+// ReverseGeocodeLocal answers street, postcode and area from indexed data only
+// Returns nil if nothing useful is known about the location
+func ReverseGeocodeLocal(lat, lon float64) *Address {
+	db := Get()
+	addr := &Address{Postcode: nearestPostcode(lat, lon, geocodePostcodeRadius)}
+
+	// Location cells hold exact Nominatim answers for nearby points
+	cells := db.QueryWithMaxAge(lat, lon, geocodeAreaRadius, EntityLocation, 200, geocodeCellMaxAge)
+	areaVotes := make(map[string]int)
+	cityVotes := make(map[string]int)
+	for _, c := range cells {
+		ld := c.GetLocationData()
+		if ld == nil {
+			continue
+		}
+		dist := haversineMeters(lat, lon, c.Lat, c.Lon)
+		// Cells are returned nearest first
+		if addr.Street == "" && ld.Street != "" && dist <= geocodeStreetCellRadius {
+			addr.Street = ld.Street
+		}
+		if addr.Postcode == "" && ld.Postcode != "" && dist <= geocodePostcodeRadius/2 {
+			addr.Postcode = ld.Postcode
+		}
+		if ld.Suburb != "" {
+			areaVotes[ld.Suburb]++
+		}
+		if ld.City != "" {
+			cityVotes[ld.City]++
+		}
+	}
+	addr.Area = topVote(areaVotes)
+	addr.City = topVote(cityVotes)
+
+	// Street geometry - name of the closest indexed street
+	if addr.Street == "" {
+		best := geocodeStreetLineRadius
+		for _, s := range db.Query(lat, lon, 1000, EntityStreet, 200) {
+			sd := s.GetStreetData()
+			if sd == nil || !realStreetName(s) {
+				continue
+			}
+			if d := pointToPolylineMeters(lat, lon, sd.Points); d < best {
+				best = d
+				addr.Street = s.Name
+			}
+		}
+	}
+
+	if addr.Street == "" && addr.Postcode == "" && addr.Area == "" {
+		return nil
+	}
+	return addr
+}
+
+// realStreetName reports whether a street is named after the street itself.
+// User tracks and courier routes are named after the trip, and would put a
+// user on "Courier route to Hampton"
+func realStreetName(s *Entity) bool {
+	if s.Name == "" || s.Name == "User route" || strings.HasPrefix(s.Name, "Courier route to ") {
+		return false
+	}
+	sd := s.GetStreetData()
+	return sd == nil || sd.ToName != "user track"
+}
+
+func topVote(votes map[string]int) string {
+	var best string
+	var bestCount int
+	for name, n := range votes {
+		if n > bestCount || (n == bestCount && name < best) {
+			best, bestCount = name, n
+		}
+	}
+	return best
+}
+
+// localLocation stores a location cell answered by the offline geocoder
+// Returns nil unless both a street and a postcode or area are known
+func localLocation(lat, lon float64) *Entity {
+	addr := ReverseGeocodeLocal(lat, lon)
+	if addr == nil || addr.Street == "" || (addr.Postcode == "" && addr.Area == "") {
+		return nil
+	}
+
+	expiry := time.Now().Add(1 * time.Hour)
+	entity := &Entity{
+		ID:   GenerateID(EntityLocation, lat, lon, "location"),
+		Type: EntityLocation,
+		Name: addr.Name(),
+		Lat:  lat,
+		Lon:  lon,
+		Data: &LocationData{
+			Street:   addr.Street,
+			Postcode: addr.Postcode,
+			Suburb:   addr.Area,
+			City:     addr.City,
+			Source:   "local",
+		},
+		ExpiresAt: &expiry,
+	}
+	Get().Insert(entity)
+	return entity
+}
+
+// reverseGeocodeAreaLocal answers the agent-style "Suburb, City" name offline
+func reverseGeocodeAreaLocal(lat, lon float64) string {
+	addr := ReverseGeocodeLocal(lat, lon)
+	if addr == nil || addr.Area == "" {
+		return ""
+	}
+	if addr.City != "" && addr.City != addr.Area {
+		return addr.Area + ", " + addr.City
+	}
+	return addr.Area
+}
+
+// =============================================================================
+// Forward geocoding
+// =============================================================================
+
+const (
+	geocodeSearchRadius = 15000.0 // Indexed candidates within 15km
+	GeocodeConfident    = 0.75    // Good enough to skip Nominatim
+	geocodeMinScore     = 0.45    // Below this a candidate isn't returned
+	geocodeSamePlace    = 200.0   // Meters within which same-named matches are one place
+)
+
+// GeocodeCandidate is a forward geocoding match
+type GeocodeCandidate struct {
+	Name       string
+	Kind       string // place, station, bus stop, street, area, postcode
+	Lat        float64
+	Lon        float64
+	Distance   float64 // meters from the search point
+	Confidence float64 // 0-1, text match weighted with proximity
+}
+
+// geocodeStopwords are ignored when matching names
+var geocodeStopwords = map[string]bool{
+	"the": true, "to": true, "a": true, "near": true, "nearest": true,
+}
+
+// geocodeAbbrev expands common abbreviations so "whitton stn" matches "Whitton Station"
+var geocodeAbbrev = map[string]string{
+	"st": "street", "rd": "road", "stn": "station", "ave": "avenue",
+	"ln": "lane", "sq": "square", "pk": "park", "hosp": "hospital",
+}
+
+func geocodeTokens(s string) []string {
+	s = strings.ToLower(s)
+	var tokens []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '\'')
+	}) {
+		f = strings.ReplaceAll(f, "'", "")
+		if full, ok := geocodeAbbrev[f]; ok {
+			f = full
+		}
+		if f != "" && !geocodeStopwords[f] {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+// geocodeTextScore scores how well a name matches a query, 0-1
+func geocodeTextScore(query, name string) float64 {
+	q := geocodeTokens(query)
+	n := geocodeTokens(name)
+	if len(q) == 0 || len(n) == 0 {
+		return 0
+	}
+	if strings.Join(q, " ") == strings.Join(n, " ") {
+		return 1
+	}
+
+	nameSet := make(map[string]bool)
+	for _, t := range n {
+		nameSet[t] = true
+	}
+	var matched int
+	for _, t := range q {
+		if nameSet[t] {
+			matched++
+		}
+	}
+	if matched == 0 {
+		return 0
+	}
+	// All query words present: strong match, less so when the name has many extra words
+	if matched == len(q) {
+		return 0.8 + 0.15*float64(len(q))/float64(len(n))
+	}
+	return 0.7 * float64(matched) / float64(len(q)+len(n)-matched)
+}
+
+// GeocodeLocal ranks indexed places, stations, streets, areas and imported
+// postcodes by text match and proximity. Best first.
+func GeocodeLocal(query string, nearLat, nearLon float64, limit int) []GeocodeCandidate {
+	var candidates []GeocodeCandidate
+
+	// Postcodes are exact lookups
+	if isPostcode(query) {
+		postcodeGridMu.RLock()
+		pc, ok := postcodeByCode[postcodeKey(query)]
+		postcodeGridMu.RUnlock()
+		if ok {
+			return []GeocodeCandidate{{
+				Name:       pc.postcode,
+				Kind:       "postcode",
+				Lat:        pc.lat,
+				Lon:        pc.lon,
+				Distance:   haversineMeters(nearLat, nearLon, pc.lat, pc.lon),
+				Confidence: 1,
+			}}
+		}
+	}
+
+	tokens := geocodeTokens(query)
+	var search []string
+	for _, t := range tokens {
+		// Short or generic words match too much to narrow the search
+		if len(t) >= 3 && t != "station" && t != "street" && t != "road" {
+			search = append(search, t)
+		}
+	}
+	if len(search) == 0 {
+		search = tokens
+	}
+	if len(search) == 0 {
+		return nil
+	}
+
+	types := []EntityType{EntityPlace, EntityArrival, EntityStreet, EntityAgent}
+	seen := make(map[string][]int) // kind+name -> indexes in candidates
+	for _, e := range Get().QueryNamed(nearLat, nearLon, geocodeSearchRadius, search, types, 200) {
+		name, kind := geocodeName(e)
+		if name == "" {
+			continue
+		}
+		text := geocodeTextScore(query, name)
+		if text == 0 {
+			continue
+		}
+		dist := haversineMeters(nearLat, nearLon, e.Lat, e.Lon)
+		proximity := 1 / (1 + dist/2000)
+		c := GeocodeCandidate{
+			Name:       name,
+			Kind:       kind,
+			Lat:        e.Lat,
+			Lon:        e.Lon,
+			Distance:   dist,
+			Confidence: 0.8*text + 0.2*proximity,
+		}
+		if c.Confidence < geocodeMinScore {
+			continue
+		}
+
+		// Same name and kind close together (e.g. both sides of a bus stop)
+		// - keep the closest. Far apart they're different places
+		key := kind + ":" + strings.ToLower(name)
+		merged := false
+		for _, i := range seen[key] {
+			if haversineMeters(c.Lat, c.Lon, candidates[i].Lat, candidates[i].Lon) > geocodeSamePlace {
+				continue
+			}
+			if c.Distance < candidates[i].Distance {
+				candidates[i] = c
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			seen[key] = append(seen[key], len(candidates))
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Distance < candidates[j].Distance
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// geocodeName returns the searchable name and kind of an entity
+func geocodeName(e *Entity) (string, string) {
+	switch e.Type {
+	case EntityArrival:
+		ad := e.GetArrivalData()
+		if ad == nil || ad.StopName == "" {
+			return "", ""
+		}
+		switch ad.StopType {
+		case "NaptanMetroStation", "NaptanRailStation", "IrishRailStation":
+			return ad.StopName, "station"
+		case "MetrolinkStop":
+			return ad.StopName, "tram stop"
+		}
+		return ad.StopName, "bus stop"
+	case EntityStreet:
+		// A courier route's point is where the courier set off from
+		if !realStreetName(e) {
+			return "", ""
+		}
+		return e.Name, "street"
+	case EntityAgent:
+		// "Hampton, London" -> "Hampton"
+		return strings.Split(e.Name, ",")[0], "area"
+	case EntityPlace:
+		if pd := e.GetPlaceData(); pd != nil {
+			if pd.Tags["railway"] == "station" || pd.Category == "railway_station" || pd.Tags["public_transport"] == "station" {
+				return e.Name, "station"
+			}
+		}
+		return e.Name, "place"
+	}
+	return "", ""
+}
+
+// IsAmbiguous reports whether the top candidates are too close to call:
+// both good matches with similar confidence, but in different places. Weak
+// matches aren't worth asking about, Nominatim may know the place
+func IsAmbiguous(candidates []GeocodeCandidate) bool {
+	if len(candidates) < 2 {
+		return false
+	}
+	a, b := candidates[0], candidates[1]
+	if b.Confidence < GeocodeConfident || a.Confidence-b.Confidence >= 0.1 {
+		return false
+	}
+	return haversineMeters(a.Lat, a.Lon, b.Lat, b.Lon) > geocodeSamePlace
+}
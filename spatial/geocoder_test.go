@@ -0,0 +1,160 @@
+package spatial
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNearestPostcode checks the postcode CSV import and nearest lookup
+func TestNearestPostcode(t *testing.T) {
+	csvData := "pcd,pcds,lat,long\n" +
+		"TW122LL,TW12 2LL,51.4179,-0.3706\n" +
+		"TW122AA,TW12 2AA,51.4158,-0.3713\n" +
+		"ZZ999ZZ,ZZ99 9ZZ,99.999999,0.000000\n"
+
+	grid, err := readPostcodeCSV(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("readPostcodeCSV: %v", err)
+	}
+
+	postcodeGridMu.RLock()
+	saved := postcodeGrid
+	postcodeGridMu.RUnlock()
+	setPostcodeGrid(grid)
+	defer setPostcodeGrid(saved)
+
+	if got := nearestPostcode(51.4160, -0.3712, 400); got != "TW12 2AA" {
+		t.Errorf("nearestPostcode = %q, want TW12 2AA", got)
+	}
+	if got := nearestPostcode(51.5074, -0.1278, 400); got != "" {
+		t.Errorf("nearestPostcode far away = %q, want empty", got)
+	}
+}
+
+// TestIsPostcode checks UK postcodes are told apart from place names
+func TestIsPostcode(t *testing.T) {
+	for _, s := range []string{"TW12 2LL", "sw1a 1aa", "EC1A1BB"} {
+		if !isPostcode(s) {
+			t.Errorf("isPostcode(%q) = false", s)
+		}
+	}
+	if isPostcode("Hampton") {
+		t.Error("isPostcode(Hampton) = true")
+	}
+}
+
+// Somewhere no other test stores anything
+const geocodeTestLat, geocodeTestLon = 55.1000, -3.1000
+
+// insertTestStreet stores an east-west street through the geocode test spot
+func insertTestStreet(t *testing.T, id, name, toName string) {
+	lat, lon := geocodeTestLat, geocodeTestLon
+	Get().Insert(&Entity{
+		ID: id, Type: EntityStreet, Name: name,
+		Lat: lat, Lon: lon,
+		Data: &StreetData{Points: [][]float64{{lon - 0.001, lat}, {lon + 0.001, lat}}, Length: 130, ToName: toName},
+	})
+	t.Cleanup(func() { Get().Delete(id) })
+}
+
+// TestReverseGeocodeCourierRoute checks a courier route doesn't name a location
+func TestReverseGeocodeCourierRoute(t *testing.T) {
+	insertTestStreet(t, "geocode-courier", "Courier route to Hampton", "Hampton")
+	if addr := ReverseGeocodeLocal(geocodeTestLat, geocodeTestLon); addr != nil && addr.Street != "" {
+		t.Errorf("located on %q", addr.Street)
+	}
+}
+
+// TestReverseGeocodeStreet checks the nearest indexed street names a location
+func TestReverseGeocodeStreet(t *testing.T) {
+	insertTestStreet(t, "geocode-street", "Mill Lane", "Mill")
+	if addr := ReverseGeocodeLocal(geocodeTestLat, geocodeTestLon); addr == nil || addr.Street != "Mill Lane" {
+		t.Errorf("street not found: %+v", addr)
+	}
+}
+
+// TestFetchLocationLocal checks agent location lookups answered from indexed
+// data are counted as local hits
+func TestFetchLocationLocal(t *testing.T) {
+	insertTestStreet(t, "geocode-fetch-street", "Mill Lane", "Mill")
+	grid, err := readPostcodeCSV(strings.NewReader("pcd,pcds,lat,long\nDG11AA,DG1 1AA,55.1001,-3.1001\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	postcodeGridMu.RLock()
+	saved := postcodeGrid
+	postcodeGridMu.RUnlock()
+	setPostcodeGrid(grid)
+	defer setPostcodeGrid(saved)
+
+	stats := GetCacheStats()
+	stats.mu.RLock()
+	before := stats.LocationLocal
+	stats.mu.RUnlock()
+
+	loc, err := fetchLocation(geocodeTestLat, geocodeTestLon)
+	if err != nil || loc == nil || loc.GetLocationData().Source != "local" {
+		t.Fatalf("fetchLocation = %+v, %v", loc, err)
+	}
+	defer Get().Delete(loc.ID)
+	stats.mu.RLock()
+	defer stats.mu.RUnlock()
+	if stats.LocationLocal != before+1 {
+		t.Errorf("local hits %d, want %d", stats.LocationLocal, before+1)
+	}
+}
+
+// TestGeocodeTextScore checks forward geocoding name matching
+func TestGeocodeTextScore(t *testing.T) {
+	testCases := []struct {
+		query, name string
+		min, max    float64
+	}{
+		{"Whitton Station", "Whitton Station", 1, 1},
+		{"whitton stn", "Whitton Station", 1, 1},
+		{"the Whitton station", "Whitton Station", 1, 1},
+		{"whitton", "Whitton Station", 0.8, 0.9},
+		{"whitton station", "Whitton Road", 0.1, 0.3},
+		{"whitton station", "Hampton Station", 0.1, 0.3},
+		{"tesco", "Whitton Station", 0, 0},
+	}
+	for _, tc := range testCases {
+		got := geocodeTextScore(tc.query, tc.name)
+		if got < tc.min || got > tc.max {
+			t.Errorf("geocodeTextScore(%q, %q) = %.2f, want %.2f-%.2f", tc.query, tc.name, got, tc.min, tc.max)
+		}
+	}
+}
+
+// TestIsAmbiguous checks two similar matches far apart are ambiguous, while
+// a clear winner or two weak matches aren't
+func TestIsAmbiguous(t *testing.T) {
+	ambiguous := []GeocodeCandidate{
+		{Name: "Tesco Express", Lat: 51.4179, Lon: -0.3706, Confidence: 0.86},
+		{Name: "Tesco Express", Lat: 51.4280, Lon: -0.3550, Confidence: 0.82},
+	}
+	if !IsAmbiguous(ambiguous) {
+		t.Error("expected far apart near-equal candidates to be ambiguous")
+	}
+	ambiguous[1].Confidence = 0.5
+	if IsAmbiguous(ambiguous) {
+		t.Error("expected clear winner not to be ambiguous")
+	}
+
+	weak := []GeocodeCandidate{ambiguous[0], ambiguous[0]}
+	weak[0].Confidence, weak[1].Confidence, weak[1].Lat = 0.6, 0.58, 51.4280
+	if IsAmbiguous(weak) {
+		t.Error("expected weak candidates not to be ambiguous")
+	}
+}
+
+// TestGeocodeLocalCourierRoute checks a courier route isn't offered as a
+// street, since its point is where the courier set off
+func TestGeocodeLocalCourierRoute(t *testing.T) {
+	insertTestStreet(t, "geocode-forward-courier", "Courier route to Hampton", "Hampton")
+	for _, c := range GeocodeLocal("Hampton", geocodeTestLat, geocodeTestLon, 5) {
+		if c.Kind == "street" {
+			t.Errorf("courier route geocoded as %+v", c)
+		}
+	}
+}
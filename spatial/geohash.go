@@ -1,11 +1,11 @@
 package spatial
 
+import "strings"
+
 // Geohash encodes lat/lon into a string
 // Precision 6 = ~1.2km x 0.6km cells
 // Precision 7 = ~150m x 150m cells
 func Geohash(lat, lon float64, precision int) string {
-	const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
-
 	minLat, maxLat := -90.0, 90.0
 	minLon, maxLon := -180.0, 180.0
 
@@ -36,7 +36,7 @@ func Geohash(lat, lon float64, precision int) string {
 
 		bit++
 		if bit == 5 {
-			hash = append(hash, base32[ch])
+			hash = append(hash, geohashBase32[ch])
 			bit = 0
 			ch = 0
 		}
@@ -50,3 +50,41 @@ func Geohash(lat, lon float64, precision int) string {
 func StreamFromLocation(lat, lon float64) string {
 	return Geohash(lat, lon, 6)
 }
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashBounds decodes a geohash into the cell it covers
+func GeohashBounds(hash string) (minLat, maxLat, minLon, maxLon float64) {
+	minLat, maxLat = -90.0, 90.0
+	minLon, maxLon = -180.0, 180.0
+	even := true
+	for i := 0; i < len(hash); i++ {
+		idx := strings.IndexByte(geohashBase32, hash[i])
+		for bit := 4; bit >= 0; bit-- {
+			on := idx >= 0 && idx&(1<<bit) != 0
+			if even {
+				mid := (minLon + maxLon) / 2
+				if on {
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if on {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return
+}
+
+// GeohashCenter returns the middle of a geohash cell
+func GeohashCenter(hash string) (lat, lon float64) {
+	minLat, maxLat, minLon, maxLon := GeohashBounds(hash)
+	return (minLat + maxLat) / 2, (minLon + maxLon) / 2
+}
@@ -0,0 +1,11 @@
+package spatial
+
+import "testing"
+
+// TestGeohashBounds checks a cell's centre is inside its bounds and hashes back to it
+func TestGeohashBounds(t *testing.T) {
+	minLat, maxLat, minLon, maxLon := GeohashBounds("gcpvj0")
+	if lat, lon := GeohashCenter("gcpvj0"); Geohash(lat, lon, 6) != "gcpvj0" || lat < minLat || lat > maxLat || lon < minLon || lon > maxLon {
+		t.Errorf("gcpvj0 bounds %f-%f %f-%f", minLat, maxLat, minLon, maxLon)
+	}
+}
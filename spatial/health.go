@@ -0,0 +1,203 @@
+package spatial
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Agent health. Every task an agent runs is recorded - how long it took,
+// whether it failed, how many entities it stored and which API it used - in
+// a rolling history per agent, so an area whose data quietly stopped
+// updating shows up in /agents and on the map rather than only in the logs.
+// The history is in memory and starts empty after a restart.
+
+const (
+	healthHistory    = 20               // Runs kept per agent and task
+	healthStaleLive  = 10 * time.Minute // No good live run for this long is stale
+	healthStaleSlow  = 30 * time.Minute // The same for an agent in slow mode
+	healthFailingPct = 50               // Errors in recent runs that make an agent failing
+)
+
+// Agent health statuses
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded" // Some recent runs failed
+	HealthFailing  = "failing"  // Most recent runs failed
+	HealthStale    = "stale"    // Should be running but nothing has succeeded lately
+	HealthIdle     = "idle"     // Paused or hibernating, not expected to run
+	HealthUnknown  = "unknown"  // Nothing run since startup
+)
+
+// TaskRun is one run of an agent task
+type TaskRun struct {
+	Task       string    `json:"task"`
+	API        string    `json:"api,omitempty"`
+	Start      time.Time `json:"start"`
+	DurationMs int64     `json:"durationMs"`
+	OK         bool      `json:"ok"`
+	Error      string    `json:"error,omitempty"`
+	Entities   int       `json:"entities"`
+}
+
+// TaskHealth summarises one task's recent runs
+type TaskHealth struct {
+	Runs        int        `json:"runs"`
+	Errors      int        `json:"errors"`
+	Entities    int        `json:"entities"`
+	AvgMs       int64      `json:"avgMs"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// AgentHealth summarises an agent's recent runs
+type AgentHealth struct {
+	Status      string                 `json:"status"`
+	Reason      string                 `json:"reason,omitempty"`
+	Runs        int                    `json:"runs"`
+	Errors      int                    `json:"errors"`
+	LastRun     *time.Time             `json:"lastRun,omitempty"`
+	LastSuccess *time.Time             `json:"lastSuccess,omitempty"`
+	Tasks       map[string]*TaskHealth `json:"tasks"`
+}
+
+var (
+	// Agent ID -> task -> runs, oldest first. Kept per task so the daily
+	// index isn't pushed out by live tasks running every 30s
+	taskHistory   = make(map[string]map[string][]TaskRun)
+	taskHistoryMu sync.Mutex
+)
+
+// recordTaskRun adds a run to an agent's history, dropping the oldest
+func recordTaskRun(agentID string, run TaskRun) {
+	taskHistoryMu.Lock()
+	defer taskHistoryMu.Unlock()
+	tasks := taskHistory[agentID]
+	if tasks == nil {
+		tasks = make(map[string][]TaskRun)
+		taskHistory[agentID] = tasks
+	}
+	runs := append(tasks[run.Task], run)
+	if len(runs) > healthHistory {
+		runs = runs[len(runs)-healthHistory:]
+	}
+	tasks[run.Task] = runs
+}
+
+// forgetTaskHistory drops a deleted agent's history
+func forgetTaskHistory(agentID string) {
+	taskHistoryMu.Lock()
+	defer taskHistoryMu.Unlock()
+	delete(taskHistory, agentID)
+}
+
+// AgentTaskRuns returns an agent's recent runs, newest first
+func AgentTaskRuns(agentID string) []TaskRun {
+	taskHistoryMu.Lock()
+	var runs []TaskRun
+	for _, r := range taskHistory[agentID] {
+		runs = append(runs, r...)
+	}
+	taskHistoryMu.Unlock()
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Start.After(runs[j].Start) })
+	return runs
+}
+
+// trackTaskRun times fn and records it. An error is a panic, or the error
+// fn returns, so each run answers for its own requests only
+func trackTaskRun(agent *Entity, task, api string, fn func() (int, error)) (n int) {
+	run := TaskRun{Task: task, API: api, Start: time.Now()}
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[agent] %s task %s panicked: %v", agent.Name, task, r)
+			run.Error = fmt.Sprintf("panic: %v", r)
+			n = 0
+		} else if err != nil {
+			run.Error = err.Error()
+		}
+		run.OK = run.Error == ""
+		run.Entities = n
+		run.DurationMs = time.Since(run.Start).Milliseconds()
+		recordTaskRun(agent.ID, run)
+	}()
+	n, err = fn()
+	return n
+}
+
+// summariseHealth rates an agent from its runs, newest first, given its mode
+// (live, slow, paused, hibernating) and the time now
+func summariseHealth(runs []TaskRun, mode string, now time.Time) *AgentHealth {
+	h := &AgentHealth{Status: HealthOK, Runs: len(runs), Tasks: make(map[string]*TaskHealth)}
+	var liveSuccess *time.Time
+	totalMs := make(map[string]int64)
+	for i := range runs {
+		r := runs[i]
+		t := h.Tasks[r.Task]
+		if t == nil {
+			t = &TaskHealth{}
+			h.Tasks[r.Task] = t
+		}
+		t.Runs++
+		t.Entities += r.Entities
+		totalMs[r.Task] += r.DurationMs
+		if h.LastRun == nil {
+			h.LastRun = &r.Start
+		}
+		if !r.OK {
+			h.Errors++
+			t.Errors++
+			if t.LastError == "" {
+				t.LastError = r.Error
+			}
+			continue
+		}
+		if t.LastSuccess == nil {
+			t.LastSuccess = &r.Start
+		}
+		if h.LastSuccess == nil {
+			h.LastSuccess = &r.Start
+		}
+		if liveSuccess == nil && r.Task != TaskIndex && r.Task != TaskStreets {
+			liveSuccess = &r.Start
+		}
+	}
+	for name, t := range h.Tasks {
+		t.AvgMs = totalMs[name] / int64(t.Runs)
+	}
+
+	staleAfter := healthStaleLive
+	switch mode {
+	case AgentPaused, AgentHibernating, AgentDeleted:
+		h.Status = HealthIdle
+		return h
+	case "slow":
+		staleAfter = healthStaleSlow
+	}
+
+	switch {
+	case len(runs) == 0:
+		h.Status = HealthUnknown
+	case liveSuccess != nil && now.Sub(*liveSuccess) > staleAfter,
+		liveSuccess == nil && now.Sub(runs[len(runs)-1].Start) > staleAfter:
+		h.Status = HealthStale
+		h.Reason = "no live data"
+		if liveSuccess != nil {
+			h.Reason += " for " + formatDuration(now.Sub(*liveSuccess))
+		}
+	case h.Errors*100 >= len(runs)*healthFailingPct:
+		h.Status = HealthFailing
+		h.Reason = fmt.Sprintf("%d of %d runs failed", h.Errors, len(runs))
+	case h.Errors > 0:
+		h.Status = HealthDegraded
+		h.Reason = fmt.Sprintf("%d of %d runs failed", h.Errors, len(runs))
+	}
+	return h
+}
+
+// GetAgentHealth rates an agent from its recent task runs
+func GetAgentHealth(agent *Entity) *AgentHealth {
+	return summariseHealth(AgentTaskRuns(agent.ID), AgentMode(agent), time.Now())
+}
@@ -0,0 +1,110 @@
+package spatial
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testHealthAgent is an agent whose task history is forgotten after the test
+func testHealthAgent(t *testing.T) *Entity {
+	agent := &Entity{ID: "agent-health-test", Name: "Health"}
+	t.Cleanup(func() { forgetTaskHistory(agent.ID) })
+	return agent
+}
+
+// TestTaskRunOtherAPIError checks another worker's failure on the same API
+// while a task runs isn't that task's
+func TestTaskRunOtherAPIError(t *testing.T) {
+	agent := testHealthAgent(t)
+	if n := trackTaskRun(agent, "weather", "health-test-api", func() (int, error) {
+		GetStats().RecordError("health-test-api", os.ErrDeadlineExceeded)
+		return 2, nil
+	}); n != 2 {
+		t.Errorf("stored %d", n)
+	}
+	if runs := AgentTaskRuns(agent.ID); len(runs) != 1 || !runs[0].OK || runs[0].Entities != 2 {
+		t.Errorf("runs: %+v", runs)
+	}
+}
+
+// TestTaskRunError checks a task's own error is recorded
+func TestTaskRunError(t *testing.T) {
+	agent := testHealthAgent(t)
+	trackTaskRun(agent, "tfl_bus", "health-test-api", func() (int, error) {
+		return 0, os.ErrDeadlineExceeded
+	})
+	if runs := AgentTaskRuns(agent.ID); len(runs) != 1 || runs[0].OK || runs[0].Error != os.ErrDeadlineExceeded.Error() {
+		t.Errorf("runs: %+v", runs)
+	}
+}
+
+// TestTaskRunPanic checks a panicking task is recorded as failed
+func TestTaskRunPanic(t *testing.T) {
+	agent := testHealthAgent(t)
+	trackTaskRun(agent, "prayer", "", func() (int, error) { panic("boom") })
+	if runs := AgentTaskRuns(agent.ID); len(runs) != 1 || runs[0].Task != "prayer" || runs[0].OK || !strings.Contains(runs[0].Error, "boom") {
+		t.Errorf("runs: %+v", runs)
+	}
+}
+
+// TestTaskRunHistoryPerTask checks one live task running every refresh
+// can't push out the index
+func TestTaskRunHistoryPerTask(t *testing.T) {
+	agent := testHealthAgent(t)
+	trackTaskRun(agent, TaskIndex, "", func() (int, error) { return 40, nil })
+	for i := 0; i < 3*healthHistory; i++ {
+		trackTaskRun(agent, "weather", "", func() (int, error) { return 1, nil })
+	}
+	h := GetAgentHealth(agent)
+	if h.Tasks[TaskIndex] == nil || h.Tasks[TaskIndex].Entities != 40 || h.Tasks["weather"].Runs != healthHistory {
+		t.Errorf("history per task: %+v", h.Tasks)
+	}
+}
+
+// testHealthyRuns is one recent successful run
+func testHealthyRuns(now time.Time) []TaskRun {
+	return []TaskRun{{Task: "weather", Start: now.Add(-time.Minute), OK: true}}
+}
+
+// TestHealthOK checks an agent with fresh live data is ok
+func TestHealthOK(t *testing.T) {
+	now := time.Now()
+	if s := summariseHealth(testHealthyRuns(now), "live", now).Status; s != HealthOK {
+		t.Errorf("fresh agent %s", s)
+	}
+}
+
+// TestHealthStale checks an hour without live data is stale
+func TestHealthStale(t *testing.T) {
+	now := time.Now()
+	if s := summariseHealth(testHealthyRuns(now), "live", now.Add(time.Hour)).Status; s != HealthStale {
+		t.Errorf("hour without live data %s", s)
+	}
+}
+
+// TestHealthIdle checks a hibernating agent is idle, not stale
+func TestHealthIdle(t *testing.T) {
+	now := time.Now()
+	if s := summariseHealth(testHealthyRuns(now), AgentHibernating, now.Add(time.Hour)).Status; s != HealthIdle {
+		t.Errorf("hibernating agent %s", s)
+	}
+}
+
+// TestHealthDegraded checks a recent failure degrades an agent and is shown
+func TestHealthDegraded(t *testing.T) {
+	now := time.Now()
+	ok := testHealthyRuns(now)[0]
+	mixed := []TaskRun{{Task: "tfl_bus", Start: now, Error: "HTTP 500"}, ok, ok}
+	if h := summariseHealth(mixed, "live", now); h.Status != HealthDegraded || h.Tasks["tfl_bus"].LastError != "HTTP 500" {
+		t.Errorf("one failure in three: %+v", h)
+	}
+}
+
+// TestHealthUnknown checks an agent with no runs is unknown
+func TestHealthUnknown(t *testing.T) {
+	if s := summariseHealth(nil, "live", time.Now()).Status; s != HealthUnknown {
+		t.Errorf("no runs %s", s)
+	}
+}
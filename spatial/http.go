@@ -198,6 +198,21 @@ func TfLGet(url string) (*http.Response, error) {
 	return External.Get("tfl", url)
 }
 
+// IrishRailGet makes an Irish Rail realtime API call
+func IrishRailGet(url string) (*http.Response, error) {
+	return External.Get("irishrail", url)
+}
+
+// TfGMGet makes a Transport for Greater Manchester API call with its key
+func TfGMGet(url, key string) (*http.Response, error) {
+	req, err := NewRequest("tfgm", "GET", url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", key)
+	return External.Do(req)
+}
+
 // FoursquareGet makes a Foursquare API call
 func FoursquareGet(url string) (*http.Response, error) {
 	return External.Get("foursquare", url)
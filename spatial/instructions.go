@@ -0,0 +1,328 @@
+package spatial
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Standing instructions. An agent can be told to keep an eye on something
+// ("track the Hampton Court car boot sale", "watch for road closures on the
+// A316"). Each instruction is planned once into tool calls, which the
+// "instructions" task runs periodically; anything new is published on the
+// agent's stream.
+
+const (
+	instructionEvery    = time.Hour
+	instructionMinEvery = 15 * time.Minute
+	instructionMaxEvery = 24 * time.Hour
+	maxInstructions     = 10   // Per agent
+	instructionRadius   = 5000 // Meters searched for nearby disruptions
+)
+
+// InstructionCall is one tool call an instruction runs: news, web, nearby
+// or disruptions, with its query
+type InstructionCall struct {
+	Tool  string `json:"tool"`
+	Query string `json:"query,omitempty"`
+}
+
+// AgentInstruction is a standing instruction, stored on the agent
+type AgentInstruction struct {
+	ID         string            `json:"id"`
+	Prompt     string            `json:"prompt"`
+	Calls      []InstructionCall `json:"calls"`
+	EveryMins  int               `json:"every_mins"`
+	Created    time.Time         `json:"created"`
+	LastRun    *time.Time        `json:"last_run,omitempty"`
+	LastResult string            `json:"last_result,omitempty"`
+}
+
+// Every is how often the instruction runs
+func (in *AgentInstruction) Every() time.Duration {
+	return time.Duration(in.EveryMins) * time.Minute
+}
+
+// due reports whether the instruction should run now
+func (in *AgentInstruction) due(now time.Time) bool {
+	return in.LastRun == nil || now.Sub(*in.LastRun) >= in.Every()
+}
+
+var (
+	// PlanInstruction turns a prompt into tool calls and how often to run
+	// them. main.go swaps in the LLM planner when AI is available
+	PlanInstruction = KeywordPlan
+
+	// InstructionNearby finds places near the agent for a "nearby" call.
+	// Set by main.go, as nearby search lives in the command package
+	InstructionNearby func(placeType string, lat, lon float64) (string, error)
+
+	// PublishAgentFinding posts an instruction's new result on a stream.
+	// Set by main.go to broadcast through the server
+	PublishAgentFinding func(agent *Entity, stream, text string)
+
+	instructionsMu sync.Mutex
+)
+
+var (
+	roadRef         = regexp.MustCompile(`\b[ABM][0-9]{1,4}(\([M]\))?\b`)
+	instructionVerb = regexp.MustCompile(`(?i)^(please\s+)?(keep\s+(an\s+)?eye\s+on|keep\s+track\s+of|watch\s+(out\s+)?(for)?|track|follow|monitor|look\s+out\s+for|tell\s+me\s+about|let\s+me\s+know\s+about)\s+`)
+	disruptionWords = []string{"road", "closure", "closed", "roadworks", "traffic", "disruption", "diversion", "accident"}
+)
+
+// KeywordPlan plans an instruction without the LLM: road words mean nearby
+// disruptions, anything else is a news and web search for the topic
+func KeywordPlan(prompt string) ([]InstructionCall, time.Duration) {
+	lower := strings.ToLower(prompt)
+	for _, w := range disruptionWords {
+		if strings.Contains(lower, w) {
+			// Narrow to the road named, if any: "closures on the A316" -> A316
+			return []InstructionCall{{Tool: "disruptions", Query: roadRef.FindString(strings.ToUpper(prompt))}}, 30 * time.Minute
+		}
+	}
+
+	topic := strings.TrimSpace(instructionVerb.ReplaceAllString(strings.TrimSpace(prompt), ""))
+	topic = strings.TrimRight(strings.TrimPrefix(topic, "the "), ".!? ")
+	if topic == "" {
+		return nil, instructionEvery
+	}
+	return []InstructionCall{{Tool: "news", Query: topic}, {Tool: "web", Query: topic}}, instructionEvery
+}
+
+// clampEvery keeps an instruction's interval within bounds
+func clampEvery(every time.Duration) time.Duration {
+	switch {
+	case every <= 0:
+		return instructionEvery
+	case every < instructionMinEvery:
+		return instructionMinEvery
+	case every > instructionMaxEvery:
+		return instructionMaxEvery
+	}
+	return every
+}
+
+// AddInstruction plans a prompt and stores it on the agent
+func AddInstruction(agent *Entity, prompt string) (*AgentInstruction, error) {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return nil, fmt.Errorf("instruction is empty")
+	}
+	calls, every := PlanInstruction(prompt)
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("don't know what to look up for %q", prompt)
+	}
+
+	hash := sha256.Sum256([]byte(strings.ToLower(prompt)))
+	in := AgentInstruction{
+		ID:        fmt.Sprintf("%x", hash[:4]),
+		Prompt:    prompt,
+		Calls:     calls,
+		EveryMins: int(clampEvery(every) / time.Minute),
+		Created:   time.Now(),
+	}
+	stored, err := storeInstruction(agent, in)
+	if err != nil {
+		return nil, err
+	}
+	// Agents with instructions don't hibernate, so one asleep wakes to run it
+	if AgentState(agent) == AgentHibernating {
+		ControlAgent(agent, "resume", 0, 0)
+	}
+	return stored, nil
+}
+
+// storeInstruction adds or replans an instruction on the agent
+func storeInstruction(agent *Entity, in AgentInstruction) (*AgentInstruction, error) {
+	instructionsMu.Lock()
+	defer instructionsMu.Unlock()
+	// Storing a deleted agent would bring it back
+	if AgentState(agent) == AgentDeleted {
+		return nil, fmt.Errorf("agent is deleted")
+	}
+	agentData := agent.GetAgentData()
+	if agentData == nil {
+		agentData = &AgentEntityData{Radius: AgentRadius, Status: AgentState(agent)}
+	}
+	for i, existing := range agentData.Instructions {
+		if existing.ID == in.ID {
+			// Same prompt again replans it, keeping what was last found
+			in.LastRun, in.LastResult = existing.LastRun, existing.LastResult
+			agentData.Instructions[i] = in
+			agent.Data = agentData
+			Get().Insert(agent)
+			return &in, nil
+		}
+	}
+	if len(agentData.Instructions) >= maxInstructions {
+		return nil, fmt.Errorf("agent already has %d instructions", maxInstructions)
+	}
+	agentData.Instructions = append(agentData.Instructions, in)
+	agent.Data = agentData
+	Get().Insert(agent)
+	log.Printf("[agent] %s instructed: %q -> %v every %dm", agent.Name, in.Prompt, in.Calls, in.EveryMins)
+	return &in, nil
+}
+
+// RemoveInstruction drops an instruction by ID
+func RemoveInstruction(agent *Entity, id string) error {
+	instructionsMu.Lock()
+	defer instructionsMu.Unlock()
+	if AgentState(agent) == AgentDeleted {
+		return fmt.Errorf("agent is deleted")
+	}
+	agentData := agent.GetAgentData()
+	if agentData == nil {
+		return fmt.Errorf("no instruction %s", id)
+	}
+	for i, in := range agentData.Instructions {
+		if in.ID == id {
+			agentData.Instructions = append(agentData.Instructions[:i], agentData.Instructions[i+1:]...)
+			agent.Data = agentData
+			Get().Insert(agent)
+			return nil
+		}
+	}
+	return fmt.Errorf("no instruction %s", id)
+}
+
+// AgentInstructions returns a copy of an agent's standing instructions
+func AgentInstructions(agent *Entity) []AgentInstruction {
+	instructionsMu.Lock()
+	defer instructionsMu.Unlock()
+	agentData := agent.GetAgentData()
+	if agentData == nil {
+		return nil
+	}
+	return append([]AgentInstruction(nil), agentData.Instructions...)
+}
+
+// runInstructions runs an agent's due instructions and publishes anything
+// that changed since the last run. Returns the number published and the
+// last call that failed
+func runInstructions(agent *Entity) (int, error) {
+	now := time.Now()
+	var due []AgentInstruction
+	for _, in := range AgentInstructions(agent) {
+		if in.due(now) {
+			due = append(due, in)
+		}
+	}
+
+	published := 0
+	var lastErr error
+	stop := agentStop(agent)
+	for _, in := range due {
+		if stopped(stop) {
+			break
+		}
+		// Tool calls can be slow, so run them without holding the lock
+		var parts []string
+		for _, call := range in.Calls {
+			result, err := runInstructionCall(agent, call)
+			if err != nil {
+				lastErr = fmt.Errorf("%s %q: %v", call.Tool, call.Query, err)
+			}
+			if result != "" {
+				parts = append(parts, result)
+			}
+		}
+		result := strings.Join(parts, "\n\n")
+		changed := result != "" && result != in.LastResult
+		if !recordInstructionRun(agent, stop, in.ID, now, result) || !changed {
+			continue // Removed or stopped meanwhile, or nothing new
+		}
+
+		text := fmt.Sprintf("🤖 %s: %s\n\n%s", agent.Name, in.Prompt, result)
+		if PublishAgentFinding != nil {
+			PublishAgentFinding(agent, StreamFromLocation(agent.Lat, agent.Lon), text)
+		}
+		log.Printf("[agent] %s has an update for %q", agent.Name, in.Prompt)
+		published++
+	}
+	return published, lastErr
+}
+
+// recordInstructionRun stores a run's time and result, reporting whether the
+// instruction is still there
+func recordInstructionRun(agent *Entity, stop <-chan struct{}, id string, at time.Time, result string) bool {
+	instructionsMu.Lock()
+	defer instructionsMu.Unlock()
+	// Writing this agent back would undo a move or delete
+	if stopped(stop) || Get().GetByID(agent.ID) == nil {
+		return false
+	}
+	agentData := agent.GetAgentData()
+	if agentData == nil {
+		return false
+	}
+	for i := range agentData.Instructions {
+		in := &agentData.Instructions[i]
+		if in.ID != id {
+			continue
+		}
+		in.LastRun = &at
+		if result != "" {
+			in.LastResult = result
+		}
+		Get().Insert(agent)
+		return true
+	}
+	return false
+}
+
+// runInstructionCall runs one tool call around the agent, returning its
+// findings or "" for nothing, and the error if the call failed
+func runInstructionCall(agent *Entity, call InstructionCall) (string, error) {
+	switch call.Tool {
+	case "news":
+		news := SearchNews(call.Query, agent.Lat, agent.Lon, 3)
+		var lines []string
+		for _, e := range news {
+			lines = append(lines, e.Name)
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "web":
+		if !WebSearchEnabled {
+			return "", nil
+		}
+		return WebSearch(call.Query), nil
+
+	case "nearby":
+		if InstructionNearby == nil {
+			return "", nil
+		}
+		result, err := InstructionNearby(call.Query, agent.Lat, agent.Lon)
+		if err != nil {
+			log.Printf("[agent] %s nearby %q: %v", agent.Name, call.Query, err)
+			return "", err
+		}
+		return result, nil
+
+	case "disruptions":
+		query := strings.ToLower(call.Query)
+		var lines []string
+		for _, nd := range GetNearbyDisruptions(agent.Lat, agent.Lon, instructionRadius) {
+			text := strings.ToLower(nd.Data.Summary() + " " + nd.Data.Location + " " + strings.Join(nd.Data.Roads, " "))
+			if query == "" || strings.Contains(text, query) {
+				lines = append(lines, formatNearbyDisruption(nd))
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+	log.Printf("[agent] %s: unknown instruction tool %q", agent.Name, call.Tool)
+	return "", fmt.Errorf("unknown tool %q", call.Tool)
+}
+
+func init() {
+	// Each instruction keeps its own interval; the task just checks them
+	RegisterAgentTask(&LiveTask{
+		TaskName: "instructions",
+		Fn:       runInstructions,
+	})
+}
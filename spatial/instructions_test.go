@@ -0,0 +1,147 @@
+package spatial
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testInstruction is an agent at Hampton Court told to track the car boot
+// sale. Its nearby call finds whatever found holds, and what it publishes
+// goes to published
+type testInstruction struct {
+	agent     *Entity
+	in        *AgentInstruction
+	found     string
+	published []string
+}
+
+func newTestInstruction(t *testing.T) *testInstruction {
+	ti := &testInstruction{found: "🥾 Car boot sale, Hampton Court"}
+	plan := PlanInstruction
+	PlanInstruction = func(string) ([]InstructionCall, time.Duration) {
+		return []InstructionCall{{Tool: "nearby", Query: "car boot sale"}}, time.Minute
+	}
+	InstructionNearby = func(string, float64, float64) (string, error) { return ti.found, nil }
+	PublishAgentFinding = func(_ *Entity, stream, text string) { ti.published = append(ti.published, text) }
+	t.Cleanup(func() { PlanInstruction, InstructionNearby, PublishAgentFinding = plan, nil, nil })
+
+	ti.agent = &Entity{ID: "agent-instructions-test", Type: EntityAgent, Name: "Hampton Court", Lat: 51.4036, Lon: -0.3378,
+		Data: &AgentEntityData{Radius: AgentRadius, Status: AgentActive}}
+	t.Cleanup(func() { Get().Delete(ti.agent.ID) })
+	in, err := AddInstruction(ti.agent, "track the Hampton Court car boot sale")
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	ti.in = in
+	return ti
+}
+
+// due makes the instruction due again, as if it last ran an hour ago
+func (ti *testInstruction) due() {
+	ago := time.Now().Add(-time.Hour)
+	ti.agent.GetAgentData().Instructions[0].LastRun = &ago
+}
+
+// TestKeywordPlanRoad checks a road closure instruction watches disruptions
+func TestKeywordPlanRoad(t *testing.T) {
+	calls, every := KeywordPlan("watch for road closures on the A316")
+	if len(calls) != 1 || calls[0].Tool != "disruptions" || calls[0].Query != "A316" || every != 30*time.Minute {
+		t.Errorf("road plan: %v %v", calls, every)
+	}
+}
+
+// TestKeywordPlanTopic checks a topic instruction searches news first
+func TestKeywordPlanTopic(t *testing.T) {
+	calls, _ := KeywordPlan("Track the Hampton Court car boot sale")
+	if len(calls) != 2 || calls[0].Tool != "news" || calls[0].Query != "Hampton Court car boot sale" {
+		t.Errorf("topic plan: %v", calls)
+	}
+}
+
+// TestAddInstruction checks an instruction's interval is held to the minimum
+func TestAddInstruction(t *testing.T) {
+	if in := newTestInstruction(t).in; in.EveryMins != 15 {
+		t.Errorf("add: %+v", in)
+	}
+}
+
+// TestAddEmptyInstruction checks a blank instruction is refused
+func TestAddEmptyInstruction(t *testing.T) {
+	if _, err := AddInstruction(newTestInstruction(t).agent, "  "); err == nil {
+		t.Error("empty instruction accepted")
+	}
+}
+
+// TestInstructionsRestored checks stored instructions survive a reload
+// through the map fallback
+func TestInstructionsRestored(t *testing.T) {
+	raw, _ := json.Marshal(newTestInstruction(t).agent.Data)
+	var m map[string]interface{}
+	json.Unmarshal(raw, &m)
+	if ad := agentDataFromMap(m); len(ad.Instructions) != 1 || ad.Instructions[0].Calls[0].Query != "car boot sale" {
+		t.Errorf("instructions not restored: %+v", ad.Instructions)
+	}
+}
+
+// TestInstructionRun checks an instruction publishes what it finds, then
+// waits for its interval
+func TestInstructionRun(t *testing.T) {
+	ti := newTestInstruction(t)
+	if n, err := runInstructions(ti.agent); n != 1 || err != nil || len(ti.published) != 1 || !strings.Contains(ti.published[0], ti.found) {
+		t.Fatalf("first run published %d: %v", n, ti.published)
+	}
+	if n, _ := runInstructions(ti.agent); n != 0 {
+		t.Error("ran again before its interval")
+	}
+}
+
+// TestInstructionUnchanged checks the same result again stays quiet
+func TestInstructionUnchanged(t *testing.T) {
+	ti := newTestInstruction(t)
+	runInstructions(ti.agent)
+	ti.due()
+	if n, _ := runInstructions(ti.agent); n != 0 {
+		t.Error("unchanged result published")
+	}
+}
+
+// TestInstructionChanged checks a new result is published
+func TestInstructionChanged(t *testing.T) {
+	ti := newTestInstruction(t)
+	runInstructions(ti.agent)
+	ti.due()
+	ti.found = "🥾 Car boot sale moved to Sunday"
+	if n, _ := runInstructions(ti.agent); n != 1 || len(ti.published) != 2 {
+		t.Errorf("changed result not published: %v", ti.published)
+	}
+}
+
+// TestInstructionsKeepAwake checks an agent with instructions doesn't hibernate
+func TestInstructionsKeepAwake(t *testing.T) {
+	if !agentWatching(newTestInstruction(t).agent) {
+		t.Error("agent with instructions would hibernate")
+	}
+}
+
+// TestInstructionDeletedMidRun checks nothing is published or written back
+// for an agent deleted while its calls ran
+func TestInstructionDeletedMidRun(t *testing.T) {
+	ti := newTestInstruction(t)
+	runInstructions(ti.agent)
+	ti.due()
+	ti.found = "🥾 Car boot sale cancelled"
+	Get().Delete(ti.agent.ID)
+	if n, _ := runInstructions(ti.agent); n != 0 || len(ti.published) != 1 || Get().GetByID(ti.agent.ID) != nil {
+		t.Error("deleted agent's instruction run written back")
+	}
+}
+
+// TestRemoveInstruction checks an instruction is dropped by ID
+func TestRemoveInstruction(t *testing.T) {
+	ti := newTestInstruction(t)
+	if err := RemoveInstruction(ti.agent, ti.in.ID); err != nil || len(AgentInstructions(ti.agent)) != 0 {
+		t.Errorf("remove: %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package spatial
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Irish Rail realtime departures (DART, commuter and intercity) for agents in
+// Dublin. The API is open and XML only.
+
+const (
+	irishRailURL        = "https://api.irishrail.ie/realtime/realtime.asmx"
+	irishRailStationTTL = 24 * time.Hour  // Stations hardly change
+	irishRailRetry      = 5 * time.Minute // After a failed station fetch
+	irishRailRadius     = 2000.0          // Stations worth showing trains for
+	irishRailMaxStops   = 3
+	irishRailWindow     = 90 // Minutes of departures to ask for
+)
+
+type irishRailStation struct {
+	Code string  `xml:"StationCode"`
+	Name string  `xml:"StationDesc"`
+	Lat  float64 `xml:"StationLatitude"`
+	Lon  float64 `xml:"StationLongitude"`
+}
+
+type irishRailTrain struct {
+	Code        string `xml:"Traincode"`
+	Destination string `xml:"Destination"`
+	DueIn       int    `xml:"Duein"` // Minutes
+	Type        string `xml:"Traintype"`
+}
+
+var (
+	irishRailStations  []irishRailStation
+	irishRailFetchedAt time.Time
+	irishRailMu        sync.Mutex
+)
+
+func init() {
+	RegisterAgentTask(&LiveTask{
+		TaskName: "irish_rail",
+		Every:    time.Minute,
+		Regions:  []string{"dublin"},
+		Fn:       func(a *Entity) (int, error) { return countEntities(fetchIrishRail(a.Lat, a.Lon)) },
+		Types:    []EntityType{EntityArrival},
+		API:      "irishrail",
+	})
+}
+
+// irishRailStationList returns all stations, fetched at most once a day,
+// with the error if a fetch due now failed
+func irishRailStationList() ([]irishRailStation, error) {
+	irishRailMu.Lock()
+	defer irishRailMu.Unlock()
+	if time.Since(irishRailFetchedAt) < irishRailStationTTL {
+		return irishRailStations, nil
+	}
+	// A failure is retried after irishRailRetry, not every tick
+	irishRailFetchedAt = time.Now().Add(irishRailRetry - irishRailStationTTL)
+
+	resp, err := IrishRailGet(irishRailURL + "/getAllStationsXML")
+	if err != nil {
+		log.Printf("[irishrail] Stations: %v", err)
+		return irishRailStations, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		log.Printf("[irishrail] Stations: status %d", resp.StatusCode)
+		return irishRailStations, fmt.Errorf("stations: status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Stations []irishRailStation `xml:"objStation"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		log.Printf("[irishrail] Stations: %v", err)
+		return irishRailStations, err
+	}
+	for i := range doc.Stations {
+		doc.Stations[i].Code = strings.TrimSpace(doc.Stations[i].Code) // Padded to five characters
+	}
+	irishRailStations = doc.Stations
+	irishRailFetchedAt = time.Now()
+	log.Printf("[irishrail] Loaded %d stations", len(doc.Stations))
+	return irishRailStations, nil
+}
+
+// fetchIrishRail stores departures for the stations nearest a location,
+// returning the last station error
+func fetchIrishRail(lat, lon float64) ([]*Entity, error) {
+	stations, lastErr := irishRailStationList()
+	var near []irishRailStation
+	for _, st := range stations {
+		if haversineMeters(lat, lon, st.Lat, st.Lon) <= irishRailRadius {
+			near = append(near, st)
+		}
+	}
+	sort.Slice(near, func(i, j int) bool {
+		return haversineMeters(lat, lon, near[i].Lat, near[i].Lon) < haversineMeters(lat, lon, near[j].Lat, near[j].Lon)
+	})
+	if len(near) > irishRailMaxStops {
+		near = near[:irishRailMaxStops]
+	}
+
+	db := Get()
+	var entities []*Entity
+	for _, st := range near {
+		trains, err := fetchIrishRailStation(st.Code)
+		if err != nil {
+			log.Printf("[irishrail] %s: %v", st.Name, err)
+			lastErr = fmt.Errorf("%s: %v", st.Name, err)
+			continue
+		}
+		if len(trains) == 0 {
+			continue
+		}
+		now := time.Now()
+		var arrivals []BusArrival
+		for _, t := range trains {
+			arrivals = append(arrivals, BusArrival{
+				Line:        t.Type,
+				Destination: t.Destination,
+				ArrivalTime: now.Add(time.Duration(t.DueIn) * time.Minute),
+			})
+		}
+		sort.Slice(arrivals, func(i, j int) bool { return arrivals[i].ArrivalTime.Before(arrivals[j].ArrivalTime) })
+
+		expiry := now.Add(arrivalTTL)
+		entity := &Entity{
+			ID:   GenerateID(EntityArrival, st.Lat, st.Lon, "irishrail:"+st.Code),
+			Type: EntityArrival,
+			Name: "🚆 " + st.Name,
+			Lat:  st.Lat,
+			Lon:  st.Lon,
+			Data: &ArrivalData{
+				StopID:   st.Code,
+				StopName: st.Name,
+				StopType: "IrishRailStation",
+				Arrivals: arrivals,
+			},
+			ExpiresAt: &expiry,
+		}
+		db.Insert(entity)
+		entities = append(entities, entity)
+	}
+	return entities, lastErr
+}
+
+// fetchIrishRailStation returns the trains due at a station
+func fetchIrishRailStation(code string) ([]irishRailTrain, error) {
+	url := fmt.Sprintf("%s/getStationDataByCodeXML_WithNumMins?StationCode=%s&NumMins=%d",
+		irishRailURL, strings.ToLower(code), irishRailWindow)
+	resp, err := IrishRailGet(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Trains []irishRailTrain `xml:"objStationData"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc.Trains, nil
+}
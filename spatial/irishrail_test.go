@@ -0,0 +1,17 @@
+package spatial
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+// TestIrishRailStations checks the station list feed parses
+func TestIrishRailStations(t *testing.T) {
+	var stations struct {
+		Stations []irishRailStation `xml:"objStation"`
+	}
+	stationXML := `<ArrayOfObjStation xmlns="http://api.irishrail.ie/realtime/"><objStation><StationDesc>Tara Street</StationDesc><StationLatitude>53.347</StationLatitude><StationLongitude>-6.2545</StationLongitude><StationCode>TARA </StationCode></objStation></ArrayOfObjStation>`
+	if err := xml.Unmarshal([]byte(stationXML), &stations); err != nil || len(stations.Stations) != 1 || stations.Stations[0].Name != "Tara Street" || stations.Stations[0].Lat != 53.347 {
+		t.Errorf("irish rail stations: %+v %v", stations, err)
+	}
+}
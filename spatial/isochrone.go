@@ -0,0 +1,282 @@
+package spatial
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Isochrones: what's reachable from a point within a travel time. Travel
+// times come from the local street graph; points off the graph are asked of
+// OSRM's table service, and failing that estimated from straight-line distance.
+
+const (
+	isochroneSectors  = 72  // Boundary resolution, one point per 5 degrees
+	isochroneDetour   = 1.3 // Street distance over crow-flies when guessing
+	IsochroneMaxMins  = 60  // Longest travel time worked out
+	osrmTableMaxCells = 100 // OSRM demo server rejects bigger tables
+)
+
+// Isochrone is the area reachable from a point within a time budget
+type Isochrone struct {
+	Lat, Lon float64
+	Minutes  int
+	Profile  *Profile
+	Polygon  [][]float64 // [lon, lat] ring, closed
+
+	graph  *StreetGraph
+	reach  map[int]float64 // Node -> network metres from the start
+	budget float64         // metres
+}
+
+// ComputeIsochrone works out the area reachable within minutes over the street graph
+func ComputeIsochrone(lat, lon float64, minutes int, profile *Profile) (*Isochrone, error) {
+	if minutes <= 0 || minutes > IsochroneMaxMins {
+		return nil, fmt.Errorf("minutes must be 1-%d", IsochroneMaxMins)
+	}
+	budget := profile.Speed * float64(minutes) * 60
+	g := streetGraphAround(lat, lon, budget+graphSnapRadius)
+	return isochroneOn(g, lat, lon, minutes, profile)
+}
+
+func isochroneOn(g *StreetGraph, lat, lon float64, minutes int, profile *Profile) (*Isochrone, error) {
+	iso := &Isochrone{
+		Lat:     lat,
+		Lon:     lon,
+		Minutes: minutes,
+		Profile: profile,
+		graph:   g,
+		budget:  profile.Speed * float64(minutes) * 60,
+	}
+	start, gap, ok := g.nearest(lat, lon, graphSnapRadius)
+	if !ok {
+		return nil, fmt.Errorf("no streets indexed here")
+	}
+	iso.reach = g.reachable(start, gap, iso.budget, profile)
+	iso.Polygon = iso.boundary()
+	if len(iso.Polygon) < 4 {
+		return nil, fmt.Errorf("too few streets indexed here")
+	}
+	return iso, nil
+}
+
+// reachable runs Dijkstra from start, stopping at the budget
+// Ways the profile can't use are left out; distances stay in real metres
+func (g *StreetGraph) reachable(start int, startDist, budget float64, profile *Profile) map[int]float64 {
+	dist := map[int]float64{start: startDist}
+	q := &pathQueue{{node: start, score: startDist}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(pathItem)
+		if item.score > dist[item.node] {
+			continue // Stale entry
+		}
+		for _, e := range g.nodes[item.node].edges {
+			if _, ok := e.edgeCost(profile); !ok {
+				continue
+			}
+			d := item.score + e.length
+			if d > budget {
+				continue
+			}
+			if old, ok := dist[e.to]; !ok || d < old {
+				dist[e.to] = d
+				heap.Push(q, pathItem{node: e.to, score: d})
+			}
+		}
+	}
+	return dist
+}
+
+// boundary traces the outline of the reachable network: the farthest point
+// reached in each compass sector, including part-way along edges that run
+// out of budget
+func (iso *Isochrone) boundary() [][]float64 {
+	type point struct{ lat, lon, dist float64 }
+	far := make([]*point, isochroneSectors)
+	consider := func(lat, lon float64) {
+		d := haversineMeters(iso.Lat, iso.Lon, lat, lon)
+		sector := int(bearing(iso.Lat, iso.Lon, lat, lon)/360*isochroneSectors) % isochroneSectors
+		if far[sector] == nil || d > far[sector].dist {
+			far[sector] = &point{lat, lon, d}
+		}
+	}
+
+	for n, d := range iso.reach {
+		node := iso.graph.nodes[n]
+		consider(node.lat, node.lon)
+		for _, e := range node.edges {
+			if _, ok := iso.reach[e.to]; ok || e.length == 0 {
+				continue
+			}
+			if _, ok := e.edgeCost(iso.Profile); !ok {
+				continue
+			}
+			frac := (iso.budget - d) / e.length
+			to := iso.graph.nodes[e.to]
+			consider(node.lat+(to.lat-node.lat)*frac, node.lon+(to.lon-node.lon)*frac)
+		}
+	}
+
+	var ring [][]float64
+	for _, p := range far {
+		if p != nil {
+			ring = append(ring, []float64{p.lon, p.lat})
+		}
+	}
+	if len(ring) > 0 {
+		ring = append(ring, ring[0])
+	}
+	return ring
+}
+
+// TravelTime returns seconds to reach a point, false if it's off the graph or out of budget
+func (iso *Isochrone) TravelTime(lat, lon float64) (float64, bool) {
+	n, gap, ok := iso.graph.nearest(lat, lon, graphSnapRadius)
+	if !ok {
+		return 0, false
+	}
+	d, ok := iso.reach[n]
+	if !ok || d+gap > iso.budget {
+		return 0, false
+	}
+	return (d + gap) / iso.Profile.Speed, true
+}
+
+// Contains reports whether a point is inside the isochrone outline
+func (iso *Isochrone) Contains(lat, lon float64) bool {
+	return pointInPolygon(lat, lon, iso.Polygon)
+}
+
+// GeoJSON returns the isochrone as a GeoJSON Feature
+func (iso *Isochrone) GeoJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "Feature",
+		"geometry": map[string]interface{}{
+			"type":        "Polygon",
+			"coordinates": [][][]float64{iso.Polygon},
+		},
+		"properties": map[string]interface{}{
+			"minutes": iso.Minutes,
+			"mode":    iso.Profile.Name,
+			"center":  []float64{iso.Lon, iso.Lat},
+		},
+	}
+}
+
+// ReachablePlace is an entity and how long it takes to get there
+type ReachablePlace struct {
+	Entity  *Entity
+	Seconds float64
+}
+
+// ReachableWithin filters entities to those reachable within minutes, quickest first
+// Uses the street graph where it can, OSRM for the rest
+func ReachableWithin(lat, lon float64, minutes int, profile *Profile, entities []*Entity) []ReachablePlace {
+	budget := float64(minutes) * 60
+	iso, err := ComputeIsochrone(lat, lon, minutes, profile)
+
+	var result []ReachablePlace
+	var unknown []*Entity
+	for _, e := range entities {
+		if iso != nil {
+			if secs, ok := iso.TravelTime(e.Lat, e.Lon); ok {
+				result = append(result, ReachablePlace{e, secs})
+				continue
+			}
+			// On the graph but out of budget
+			if _, _, ok := iso.graph.nearest(e.Lat, e.Lon, graphSnapRadius); ok {
+				continue
+			}
+		}
+		unknown = append(unknown, e)
+	}
+
+	if len(unknown) > 0 {
+		if err != nil {
+			log.Printf("[isochrone] Graph: %v", err)
+		}
+		for i, secs := range osrmTravelTimes(lat, lon, profile, unknown) {
+			if secs <= budget {
+				result = append(result, ReachablePlace{unknown[i], secs})
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Seconds < result[j].Seconds
+	})
+	return result
+}
+
+// osrmTravelTimes asks OSRM's table service for times to each entity
+// Falls back to a straight-line estimate when OSRM is off or fails
+func osrmTravelTimes(lat, lon float64, profile *Profile, entities []*Entity) []float64 {
+	times := make([]float64, len(entities))
+	for i, e := range entities {
+		times[i] = haversineMeters(lat, lon, e.Lat, e.Lon) * isochroneDetour / profile.Speed
+	}
+	if !OSRMEnabled() {
+		return times
+	}
+
+	for start := 0; start < len(entities); start += osrmTableMaxCells - 1 {
+		end := min(start+osrmTableMaxCells-1, len(entities))
+		coords := []string{fmt.Sprintf("%f,%f", lon, lat)}
+		for _, e := range entities[start:end] {
+			coords = append(coords, fmt.Sprintf("%f,%f", e.Lon, e.Lat))
+		}
+		// Distances, not durations: the demo server times everything at driving speed
+		url := fmt.Sprintf("%s/table/v1/%s/%s?sources=0&annotations=distance",
+			osrmBaseURL, profile.OSRM, strings.Join(coords, ";"))
+		dists, err := osrmTableRow(url)
+		if err == nil && len(dists) == 0 {
+			err = fmt.Errorf("empty table")
+		}
+		if err != nil {
+			log.Printf("[isochrone] OSRM table: %v", err)
+			return times
+		}
+		for i, d := range dists[1:] {
+			if d != nil && start+i < len(times) {
+				times[start+i] = *d / profile.Speed
+			}
+		}
+	}
+	return times
+}
+
+// osrmTableRow fetches the first row of an OSRM distance table
+func osrmTableRow(url string) ([]*float64, error) {
+	resp, err := OSRMGet(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("returned %d", resp.StatusCode)
+	}
+	var data struct {
+		Code      string       `json:"code"`
+		Distances [][]*float64 `json:"distances"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	if data.Code != "Ok" || len(data.Distances) == 0 {
+		return nil, fmt.Errorf("code %s", data.Code)
+	}
+	return data.Distances[0], nil
+}
+
+// FormatTravelTime formats seconds as "4 min walk"
+func FormatTravelTime(seconds float64, profile *Profile) string {
+	mins := int(math.Ceil(seconds / 60))
+	if mins < 1 {
+		mins = 1
+	}
+	return fmt.Sprintf("%d min %s", mins, profile.Noun)
+}
@@ -0,0 +1,74 @@
+package spatial
+
+import "testing"
+
+// Street grid spacing: 0.001 deg lat, lon scaled for 51.5N, both ~111 m
+const isoLonStep = 0.001 / 0.6225
+
+// testStreetGrid is an 11x11 grid of unnamed streets ~111 m apart
+func testStreetGrid() *StreetGraph {
+	g := NewStreetGraph()
+	for i := 0; i <= 10; i++ {
+		var row, col [][]float64
+		for j := 0; j <= 10; j++ {
+			row = append(row, []float64{-0.1 + float64(j)*isoLonStep, 51.5 + float64(i)*0.001})
+			col = append(col, []float64{-0.1 + float64(i)*isoLonStep, 51.5 + float64(j)*0.001})
+		}
+		g.AddStreet(row, "")
+		g.AddStreet(col, "")
+	}
+	return g
+}
+
+// testIsochrone is a 5 minute walk (~417 m) from the middle of the grid
+func testIsochrone(t *testing.T) (iso *Isochrone, centerLon float64) {
+	centerLon = -0.1 + 5*isoLonStep
+	iso, err := isochroneOn(testStreetGrid(), 51.505, centerLon, 5, ProfileFoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return iso, centerLon
+}
+
+// TestIsochroneOutline checks the outline is a closed polygon
+func TestIsochroneOutline(t *testing.T) {
+	iso, _ := testIsochrone(t)
+	if len(iso.Polygon) < 8 || len(iso.GeoJSON()) != 3 {
+		t.Fatalf("polygon has %d points", len(iso.Polygon))
+	}
+}
+
+// TestIsochroneTravelTime checks three blocks north (~333 m) is in reach
+func TestIsochroneTravelTime(t *testing.T) {
+	iso, centerLon := testIsochrone(t)
+	if secs, ok := iso.TravelTime(51.508, centerLon); !ok || secs < 230 || secs > 250 {
+		t.Errorf("north: %.0fs, %v", secs, ok)
+	}
+}
+
+// TestIsochroneNetworkDistance checks reach is by street, not as the crow
+// flies: two blocks east and two north is ~444 m by street, ~314 m direct
+func TestIsochroneNetworkDistance(t *testing.T) {
+	iso, centerLon := testIsochrone(t)
+	if _, ok := iso.TravelTime(51.507, centerLon+2*isoLonStep); ok {
+		t.Error("diagonal should be out of reach by street")
+	}
+}
+
+// TestIsochroneFollowsStreets checks the outline hugs the streets reached
+func TestIsochroneFollowsStreets(t *testing.T) {
+	iso, centerLon := testIsochrone(t)
+	if iso.Contains(51.507, centerLon+2*isoLonStep) {
+		t.Error("outline should follow the streets, not a circle")
+	}
+	if !iso.Contains(51.5065, centerLon) {
+		t.Error("outline should contain points along reachable streets")
+	}
+}
+
+// TestIsochroneOffGraph checks there's no outline away from the streets
+func TestIsochroneOffGraph(t *testing.T) {
+	if _, err := isochroneOn(testStreetGrid(), 51.6, -0.1, 5, ProfileFoot); err == nil {
+		t.Error("expected an error away from the streets")
+	}
+}
@@ -0,0 +1,421 @@
+package spatial
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Walk-plus-transit journey planning. In London itineraries come from the TfL
+// Journey Planner, with the first boarding time corrected from live arrivals
+// the agents already hold. Elsewhere, and when walking is quicker, the
+// walking route is offered instead.
+
+const (
+	tflJourneyURL    = tflBaseURL + "/Journey/JourneyResults/%.6f,%.6f/to/%.6f,%.6f?mode=%s&date=%s&time=%s&timeIs=Departing"
+	tflJourneyModes  = "walking,bus,tube,dlr,overground,elizabeth-line,tram,national-rail"
+	tflStepFree      = "&accessibilityPreference=StepFreeToPlatform"
+	journeyOptions   = 3
+	journeyWalkLimit = 3000.0 // Only compare against walking for trips under 3 km
+	liveStopRadius   = 60.0   // Live arrivals within this of a boarding point belong to it
+)
+
+// RouteLeg is one leg of a journey: a walk or a ride on a single line
+type RouteLeg struct {
+	Mode        string // walking, bus, tube, dlr, overground, national-rail...
+	Line        string // Route name for rides ("185", "Victoria")
+	Towards     string
+	From, To    string
+	FromLat     float64
+	FromLon     float64
+	Depart      time.Time
+	Arrive      time.Time
+	Duration    float64 // seconds
+	Distance    float64 // metres, walking legs only
+	Stops       int
+	Live        bool // Depart comes from live arrivals
+	Instruction string
+}
+
+// IsRide reports whether the leg is on public transport
+func (l *RouteLeg) IsRide() bool {
+	return l.Mode != "walking"
+}
+
+// tflJourneyResponse mirrors the parts of the TfL Journey Planner response we use
+type tflJourneyResponse struct {
+	Journeys []struct {
+		StartDateTime   string `json:"startDateTime"`
+		ArrivalDateTime string `json:"arrivalDateTime"`
+		Duration        int    `json:"duration"` // minutes
+		Legs            []struct {
+			Duration    int     `json:"duration"`
+			Distance    float64 `json:"distance"`
+			Instruction struct {
+				Summary string `json:"summary"`
+			} `json:"instruction"`
+			DepartureTime  string              `json:"departureTime"`
+			ArrivalTime    string              `json:"arrivalTime"`
+			DeparturePoint tflJourneyPt        `json:"departurePoint"`
+			ArrivalPoint   tflJourneyPt        `json:"arrivalPoint"`
+			Mode           struct{ ID string } `json:"mode"`
+			RouteOptions   []struct {
+				Name       string   `json:"name"`
+				Directions []string `json:"directions"`
+			} `json:"routeOptions"`
+			Path struct {
+				LineString string `json:"lineString"` // JSON array of [lat, lon]
+				StopPoints []struct {
+					Name string `json:"name"`
+				} `json:"stopPoints"`
+			} `json:"path"`
+		} `json:"legs"`
+	} `json:"journeys"`
+}
+
+type tflJourneyPt struct {
+	CommonName string  `json:"commonName"`
+	Lat        float64 `json:"lat"`
+	Lon        float64 `json:"lon"`
+}
+
+// PlanJourney returns itineraries from one point to another leaving at depart,
+// quickest first. Each is a Route with Legs set, except a walk-only option.
+// Step-free journeys only use stations with step-free access to the platform
+func PlanJourney(fromLat, fromLon, toLat, toLon float64, depart time.Time, stepFree bool) ([]*Route, error) {
+	var routes []*Route
+	var transitErr error
+	if IsLondon(fromLat, fromLon) && IsLondon(toLat, toLon) {
+		routes, transitErr = fetchTfLJourneys(fromLat, fromLon, toLat, toLon, depart, stepFree)
+		for _, r := range routes {
+			for _, l := range r.Legs {
+				if l.IsRide() {
+					applyLiveDepartures(r, Get().Query(l.FromLat, l.FromLon, 200, EntityArrival, 10), depart)
+					break
+				}
+			}
+		}
+	} else {
+		transitErr = fmt.Errorf("no transit data for this area")
+	}
+
+	// Offer walking when it's close enough to be a real choice
+	if haversineMeters(fromLat, fromLon, toLat, toLon) <= journeyWalkLimit || len(routes) == 0 {
+		profile := ProfileFoot
+		if stepFree {
+			profile = ProfileWheelchair
+		}
+		if walk, err := GetDirections(fromLat, fromLon, toLat, toLon, profile); err == nil {
+			walk.LeaveBy = depart
+			walk.ArriveAt = depart.Add(time.Duration(walk.TotalTime) * time.Second)
+			routes = append(routes, walk)
+		} else if len(routes) == 0 {
+			return nil, fmt.Errorf("%v; walking: %v", transitErr, err)
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].ArriveAt.Before(routes[j].ArriveAt)
+	})
+	if len(routes) > journeyOptions {
+		routes = routes[:journeyOptions]
+	}
+	return routes, nil
+}
+
+// fetchTfLJourneys asks the TfL Journey Planner for itineraries
+func fetchTfLJourneys(fromLat, fromLon, toLat, toLon float64, depart time.Time, stepFree bool) ([]*Route, error) {
+	london := TimezoneAt(fromLat, fromLon)
+	local := depart.In(london)
+	url := fmt.Sprintf(tflJourneyURL, fromLat, fromLon, toLat, toLon, tflJourneyModes,
+		local.Format("20060102"), local.Format("1504"))
+	if stepFree {
+		url += tflStepFree
+	}
+
+	resp, err := TfLGet(url)
+	if err != nil {
+		return nil, fmt.Errorf("journey planner: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("journey planner returned %d", resp.StatusCode)
+	}
+
+	var data tflJourneyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("journey planner decode: %v", err)
+	}
+	routes := parseTfLJourneys(&data, london)
+	log.Printf("[journey] %d TfL itineraries for %.4f,%.4f -> %.4f,%.4f", len(routes), fromLat, fromLon, toLat, toLon)
+	return routes, nil
+}
+
+// parseTfLJourneys converts TfL journeys to routes; TfL times are London local
+func parseTfLJourneys(data *tflJourneyResponse, loc *time.Location) []*Route {
+	parse := func(s string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02T15:04:05", s, loc)
+		return t
+	}
+
+	var routes []*Route
+	for _, j := range data.Journeys {
+		route := &Route{}
+		for _, l := range j.Legs {
+			leg := RouteLeg{
+				Mode:        l.Mode.ID,
+				From:        cleanStopName(l.DeparturePoint.CommonName),
+				To:          cleanStopName(l.ArrivalPoint.CommonName),
+				FromLat:     l.DeparturePoint.Lat,
+				FromLon:     l.DeparturePoint.Lon,
+				Depart:      parse(l.DepartureTime),
+				Arrive:      parse(l.ArrivalTime),
+				Duration:    float64(l.Duration * 60),
+				Instruction: l.Instruction.Summary,
+			}
+			if len(l.RouteOptions) > 0 {
+				leg.Line = l.RouteOptions[0].Name
+				if len(l.RouteOptions[0].Directions) > 0 {
+					leg.Towards = cleanStopName(l.RouteOptions[0].Directions[0])
+				}
+			}
+			if leg.IsRide() {
+				leg.Stops = len(l.Path.StopPoints)
+			} else {
+				leg.Distance = l.Distance
+				route.TotalDist += l.Distance
+			}
+
+			var path [][]float64 // [lat, lon]
+			if json.Unmarshal([]byte(l.Path.LineString), &path) == nil {
+				for _, p := range path {
+					if len(p) == 2 {
+						route.Geometry = append(route.Geometry, []float64{p[1], p[0]})
+					}
+				}
+			}
+
+			route.Legs = append(route.Legs, leg)
+			route.Steps = append(route.Steps, RouteStep{
+				Instruction: formatLeg(&leg),
+				Distance:    leg.Distance,
+				Duration:    leg.Duration,
+				Name:        leg.Line,
+				Lat:         leg.FromLat,
+				Lon:         leg.FromLon,
+			})
+		}
+		if len(route.Legs) == 0 {
+			continue
+		}
+		route.TotalTime = float64(j.Duration * 60)
+		route.ArriveAt = parse(j.ArrivalDateTime)
+		route.updateJourney()
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// applyLiveDepartures corrects the first ride's departure from live arrivals
+// at its boarding stop. The walk to the stop is laid back from the live
+// departure to give leave-by, never before earliest, and the ride and every
+// leg after it move with the departure
+func applyLiveDepartures(route *Route, arrivals []*Entity, earliest time.Time) {
+	for i := range route.Legs {
+		leg := &route.Legs[i]
+		if !leg.IsRide() {
+			continue
+		}
+		// Earliest we can be at the stop
+		ready := route.LeaveBy
+		if ready.Before(earliest) {
+			ready = earliest
+		}
+		for _, prev := range route.Legs[:i] {
+			ready = ready.Add(time.Duration(prev.Duration) * time.Second)
+		}
+		t, ok := liveDeparture(leg, arrivals, ready)
+		if !ok {
+			return
+		}
+
+		shift := t.Sub(leg.Depart)
+		leg.Depart, leg.Live = t, true
+		leg.Arrive = leg.Arrive.Add(shift)
+		for j := i + 1; j < len(route.Legs); j++ {
+			route.Legs[j].Depart = route.Legs[j].Depart.Add(shift)
+			route.Legs[j].Arrive = route.Legs[j].Arrive.Add(shift)
+		}
+		route.ArriveAt = route.ArriveAt.Add(shift)
+
+		// Walk to the stop just in time for it
+		at := t
+		for j := i - 1; j >= 0; j-- {
+			route.Legs[j].Arrive = at
+			at = at.Add(-time.Duration(route.Legs[j].Duration) * time.Second)
+			route.Legs[j].Depart = at
+		}
+		route.LeaveBy = at
+
+		for j := range route.Legs {
+			route.Steps[j].Instruction = formatLeg(&route.Legs[j])
+		}
+		return
+	}
+}
+
+// liveDeparture finds the next live arrival of the leg's line at its boarding stop
+func liveDeparture(leg *RouteLeg, arrivals []*Entity, ready time.Time) (time.Time, bool) {
+	for _, e := range arrivals {
+		if haversineMeters(leg.FromLat, leg.FromLon, e.Lat, e.Lon) > liveStopRadius {
+			continue
+		}
+		ad := e.GetArrivalData()
+		if ad == nil {
+			continue
+		}
+		var best time.Time
+		for _, a := range ad.Arrivals {
+			if !strings.EqualFold(a.Line, leg.Line) || a.ArrivalTime.Before(ready) {
+				continue
+			}
+			if leg.Towards != "" && a.Destination != "" && !sameTowards(a.Destination, leg.Towards) {
+				continue
+			}
+			if best.IsZero() || a.ArrivalTime.Before(best) {
+				best = a.ArrivalTime
+			}
+		}
+		if !best.IsZero() {
+			return best, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sameTowards compares destinations loosely: "Victoria" matches "Victoria Bus Station"
+func sameTowards(a, b string) bool {
+	a, b = strings.ToLower(cleanStopName(a)), strings.ToLower(cleanStopName(b))
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// cleanStopName drops TfL's station suffixes
+func cleanStopName(name string) string {
+	for _, suffix := range []string{" Underground Station", " Rail Station", " DLR Station", " (London)"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
+
+// updateJourney derives leave-by, transfers and the summary from the legs
+func (r *Route) updateJourney() {
+	r.LeaveBy = r.Legs[0].Depart
+	r.Transfers = -1
+	for _, l := range r.Legs {
+		if l.IsRide() {
+			r.Transfers++
+		}
+	}
+	if r.Transfers < 0 {
+		r.Transfers = 0
+	}
+	r.Summary = formatJourneySummary(r)
+}
+
+// legIcon returns a mode icon for a journey leg
+func legIcon(mode string) string {
+	switch mode {
+	case "walking":
+		return "🚶"
+	case "bus", "coach":
+		return "🚌"
+	case "tube", "elizabeth-line":
+		return "🚇"
+	default:
+		return lineIcon(mode)
+	}
+}
+
+// formatLeg describes a leg: "🚇 Victoria line 08:17 from Brixton → Green Park (4 stops, 9 min)"
+func formatLeg(l *RouteLeg) string {
+	mins := int(l.Duration / 60)
+	if !l.IsRide() {
+		to := l.To
+		if to == "" {
+			to = "destination"
+		}
+		return fmt.Sprintf("🚶 Walk %s to %s (%d min)", FormatDistance(l.Distance), to, mins)
+	}
+
+	line := l.Line
+	switch {
+	case l.Mode == "tube" && !strings.Contains(strings.ToLower(line), "line"):
+		line += " line"
+	case l.Mode == "bus":
+		line = "Bus " + line
+	}
+	live := ""
+	if l.Live {
+		live = " (live)"
+	}
+	s := fmt.Sprintf("%s %s %s%s from %s → %s", legIcon(l.Mode), line, l.Depart.Format("15:04"), live, l.From, l.To)
+	if l.Stops > 0 {
+		return s + fmt.Sprintf(" (%s, %d min)", plural(l.Stops, "stop"), mins)
+	}
+	return s + fmt.Sprintf(" (%d min)", mins)
+}
+
+// formatJourneySummary formats "🚇 38 min · leave by 08:12 · 1 change"
+func formatJourneySummary(r *Route) string {
+	icon := "🚶"
+	for _, l := range r.Legs {
+		if l.IsRide() {
+			icon = legIcon(l.Mode)
+			break
+		}
+	}
+	mins := int(r.ArriveAt.Sub(r.LeaveBy).Minutes())
+	s := fmt.Sprintf("%s %d min · leave by %s", icon, mins, r.LeaveBy.Format("15:04"))
+	switch r.Transfers {
+	case 0:
+		s += " · direct"
+	case 1:
+		s += " · 1 change"
+	default:
+		s += fmt.Sprintf(" · %d changes", r.Transfers)
+	}
+	return s
+}
+
+// formatJourney renders a walk-plus-transit itinerary
+func formatJourney(route *Route, fromLat, fromLon, toLat, toLon float64) string {
+	route.Summary = formatJourneySummary(route)
+	lines := []string{route.Summary, ""}
+	for i, step := range route.Steps {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, step.Instruction))
+	}
+	lines = append(lines, "", "🏁 Arrive "+route.ArriveAt.Format("15:04"))
+
+	if fromLat != 0 && toLat != 0 {
+		lines = append(lines, "", fmt.Sprintf("🗺️ https://www.google.com/maps/dir/?api=1&origin=%.6f,%.6f&destination=%.6f,%.6f&travelmode=transit",
+			fromLat, fromLon, toLat, toLon))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatJourneyOption is a one-line summary of an itinerary for listing alternatives
+func FormatJourneyOption(route *Route) string {
+	if len(route.Legs) == 0 {
+		return route.Summary
+	}
+	var rides []string
+	for _, l := range route.Legs {
+		if l.IsRide() {
+			rides = append(rides, legIcon(l.Mode)+" "+l.Line)
+		}
+	}
+	return fmt.Sprintf("%s · %s", formatJourneySummary(route), strings.Join(rides, " → "))
+}
@@ -0,0 +1,109 @@
+package spatial
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testJourney parses a recorded TfL itinerary from Brixton to Kensington:
+// walk, Victoria line, bus 9, walk
+func testJourney(t *testing.T) *Route {
+	b, err := os.ReadFile("testdata/tfl/journey.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data tflJourneyResponse
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+	routes := parseTfLJourneys(&data, TimezoneAt(51.5, -0.12))
+	if len(routes) != 1 {
+		t.Fatalf("got %d routes", len(routes))
+	}
+	return routes[0]
+}
+
+// journeyTime is a London time on the day of the recorded journey
+func journeyTime(hhmm string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02 15:04", "2026-10-19 "+hhmm, TimezoneAt(51.5, -0.12))
+	return t
+}
+
+// testBrixtonArrivals is the Victoria line due at Brixton at 08:15 and 08:20
+func testBrixtonArrivals() []*Entity {
+	return []*Entity{{
+		Type: EntityArrival, Lat: 51.4626, Lon: -0.1146,
+		Data: &ArrivalData{StopName: "Brixton", Arrivals: []BusArrival{
+			{Line: "Victoria", Destination: "Walthamstow Central", ArrivalTime: journeyTime("08:15")},
+			{Line: "Victoria", Destination: "Walthamstow Central", ArrivalTime: journeyTime("08:20")},
+		}},
+	}}
+}
+
+// TestParseTfLJourney checks legs, changes, geometry and wording of a TfL itinerary
+func TestParseTfLJourney(t *testing.T) {
+	r := testJourney(t)
+	if len(r.Legs) != 4 || r.Transfers != 1 || r.TotalDist != 670 || len(r.Geometry) != 13 {
+		t.Fatalf("legs=%d transfers=%d dist=%.0f geometry=%d", len(r.Legs), r.Transfers, r.TotalDist, len(r.Geometry))
+	}
+	if got := r.Summary; got != "🚇 38 min · leave by 08:12 · 1 change" {
+		t.Errorf("summary = %q", got)
+	}
+	if got := r.Steps[1].Instruction; got != "🚇 Victoria line 08:17 from Brixton → Green Park (4 stops, 9 min)" {
+		t.Errorf("tube leg = %q", got)
+	}
+}
+
+// TestLiveDepartures checks a journey moves to the next live train that can
+// be caught: the 08:15 leaves before we get there, so it's the 08:20
+func TestLiveDepartures(t *testing.T) {
+	r := testJourney(t)
+	applyLiveDepartures(r, testBrixtonArrivals(), journeyTime("08:00"))
+	if !r.Legs[1].Live || r.LeaveBy.Format("15:04") != "08:15" || r.ArriveAt.Format("15:04") != "08:53" {
+		t.Errorf("live: leave by %s, arrive %s", r.LeaveBy.Format("15:04"), r.ArriveAt.Format("15:04"))
+	}
+}
+
+// TestFormatJourney checks the directions text for a live-corrected journey
+func TestFormatJourney(t *testing.T) {
+	r := testJourney(t)
+	applyLiveDepartures(r, testBrixtonArrivals(), journeyTime("08:00"))
+	out := FormatDirections(r)
+	for _, want := range []string{
+		"leave by 08:15",
+		"1. 🚶 Walk 380 m to Brixton (5 min)",
+		"2. 🚇 Victoria line 08:20 (live) from Brixton → Green Park",
+		"3. 🚌 Bus 9 08:33 from Green Park Station → Kensington High Street (4 stops, 16 min)",
+		"4. 🚶 Walk 290 m to destination (4 min)",
+		"🏁 Arrive 08:53",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+// TestLiveEarlierBus checks a live bus earlier than the timetabled one pulls
+// leave-by in, never into the past: 5 min walk, bus due 09:12 but live at 09:06
+func TestLiveEarlierBus(t *testing.T) {
+	at := journeyTime
+	bus := &Route{
+		LeaveBy:  at("09:00"),
+		ArriveAt: at("09:30"),
+		Legs: []RouteLeg{
+			{Mode: "walking", To: "High Street", Depart: at("09:00"), Arrive: at("09:05"), Duration: 300},
+			{Mode: "bus", Line: "285", From: "High Street", FromLat: 51.4626, FromLon: -0.1146, Depart: at("09:12"), Arrive: at("09:30"), Duration: 1080},
+		},
+		Steps: make([]RouteStep, 2),
+	}
+	arrivals := testBrixtonArrivals()
+	arrivals[0].Data = &ArrivalData{Arrivals: []BusArrival{{Line: "285", ArrivalTime: at("09:06")}}}
+	applyLiveDepartures(bus, arrivals, at("09:00"))
+	if bus.LeaveBy.Format("15:04") != "09:01" || bus.Legs[0].Arrive.Format("15:04") != "09:06" || bus.ArriveAt.Format("15:04") != "09:24" {
+		t.Errorf("earlier bus: leave by %s, at stop %s, arrive %s",
+			bus.LeaveBy.Format("15:04"), bus.Legs[0].Arrive.Format("15:04"), bus.ArriveAt.Format("15:04"))
+	}
+}
@@ -0,0 +1,299 @@
+package spatial
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Agent lifecycle. Each agent is in one state, changed only through
+// ControlAgent, and the state decides what the scheduler runs for it:
+//
+//	active      -> paused, refreshing, hibernating, deleted
+//	paused      -> active, refreshing, deleted
+//	refreshing  -> back to where it was once live data is fetched, or any of the above
+//	hibernating -> active, paused, refreshing, deleted
+//	deleted     -> nothing
+//
+// Each agent also has a stop channel, closed when it is paused, moved or
+// deleted, so work already running for it gives up at the next step instead
+// of writing stale data or bringing a deleted agent back. A deleted agent's
+// control stays behind as a tombstone, its channel closed, so work a worker
+// took just before the delete sees it too.
+
+// Agent states, stored in AgentEntityData.Status
+const (
+	AgentActive      = "active"
+	AgentPaused      = "paused"
+	AgentRefreshing  = "refreshing"
+	AgentHibernating = "hibernating"
+	AgentDeleted     = "deleted"
+)
+
+// Which states each state may move to
+var agentTransitions = map[string][]string{
+	AgentActive:      {AgentPaused, AgentRefreshing, AgentHibernating, AgentDeleted},
+	AgentPaused:      {AgentActive, AgentRefreshing, AgentDeleted},
+	AgentRefreshing:  {AgentActive, AgentPaused, AgentRefreshing, AgentHibernating, AgentDeleted},
+	AgentHibernating: {AgentActive, AgentPaused, AgentRefreshing, AgentDeleted},
+}
+
+// agentControl is an agent's lifecycle state and stop channel
+type agentControl struct {
+	state string
+	after string        // State to return to once a refresh is done
+	stop  chan struct{} // Closed to abandon in-flight work
+}
+
+var (
+	agentControls   = make(map[string]*agentControl)
+	agentControlsMu sync.Mutex
+)
+
+// control returns an agent's control, picking up a state persisted before
+// a restart. Called with agentControlsMu held
+func control(agent *Entity) *agentControl {
+	if c, ok := agentControls[agent.ID]; ok {
+		return c
+	}
+	c := &agentControl{state: AgentActive, stop: make(chan struct{})}
+	if ad := agent.GetAgentData(); ad != nil && (ad.Status == AgentPaused || ad.Status == AgentHibernating) {
+		c.state = ad.Status
+	}
+	agentControls[agent.ID] = c
+	return c
+}
+
+// AgentState returns an agent's lifecycle state
+func AgentState(agent *Entity) string {
+	agentControlsMu.Lock()
+	defer agentControlsMu.Unlock()
+	return control(agent).state
+}
+
+// agentStop returns the channel closed when the agent's current work should stop
+func agentStop(agent *Entity) <-chan struct{} {
+	agentControlsMu.Lock()
+	defer agentControlsMu.Unlock()
+	return control(agent).stop
+}
+
+// stopped reports whether a stop channel has been closed
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// agentRuns reports whether the scheduler should run an agent's work
+func agentRuns(agent *Entity) bool {
+	switch AgentState(agent) {
+	case AgentActive, AgentRefreshing:
+		return true
+	}
+	return false
+}
+
+// transition moves an agent to a new state, persisting it
+// Returns the state it was in
+func transition(agent *Entity, to string) (string, error) {
+	agentControlsMu.Lock()
+	c := control(agent)
+	from := c.state
+	allowed := false
+	for _, s := range agentTransitions[from] {
+		if s == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		agentControlsMu.Unlock()
+		return from, fmt.Errorf("agent is %s, can't go %s", from, to)
+	}
+	switch to {
+	case AgentRefreshing:
+		if from != AgentRefreshing {
+			c.after = from
+		}
+	case AgentPaused:
+		close(c.stop)
+		c.stop = make(chan struct{})
+	case AgentDeleted:
+		close(c.stop) // Left closed for work that starts late
+	}
+	c.state = to
+	agentControlsMu.Unlock()
+
+	if to != AgentDeleted {
+		setAgentStatus(agent, to)
+	}
+	log.Printf("[agent] %s %s -> %s", agent.Name, from, to)
+	return from, nil
+}
+
+// clearDeleted drops a deleted agent's tombstone, so a new agent made in
+// the same place, which gets the same ID, starts afresh
+func clearDeleted(id string) {
+	agentControlsMu.Lock()
+	defer agentControlsMu.Unlock()
+	if c, ok := agentControls[id]; ok && c.state == AgentDeleted {
+		delete(agentControls, id)
+	}
+}
+
+// setAgentStatus stores the state on the agent so /agents and a restart see it
+func setAgentStatus(agent *Entity, status string) {
+	agentData := agent.GetAgentData()
+	if agentData == nil {
+		agentData = &AgentEntityData{Radius: AgentRadius}
+	}
+	agentData.Status = status
+	agent.Data = agentData
+	Get().Insert(agent)
+}
+
+// finishRefresh returns a refreshing agent to its state before the refresh
+func finishRefresh(agent *Entity) {
+	agentControlsMu.Lock()
+	c := control(agent)
+	if c.state != AgentRefreshing {
+		agentControlsMu.Unlock()
+		return
+	}
+	after := c.after
+	c.state = after
+	agentControlsMu.Unlock()
+
+	if after != AgentActive {
+		// A one-off refresh, nothing should repeat
+		AgentScheduler().Remove(agent.ID)
+	}
+
+	if current := Get().GetByID(agent.ID); current != nil {
+		setAgentStatus(current, after)
+	}
+}
+
+// hibernateAgent puts an agent nobody has been near to sleep until the next
+// nearby ping. The scheduler calls it with its lock held and drops the
+// agent's tasks itself
+func hibernateAgent(agent *Entity) bool {
+	_, err := transition(agent, AgentHibernating)
+	return err == nil // Paused or deleted meanwhile
+}
+
+// agentWatching reports whether an agent has standing instructions to run,
+// which keeps it from hibernating
+func agentWatching(agent *Entity) bool {
+	if current := Get().GetByID(agent.ID); current != nil {
+		agent = current
+	}
+	return len(AgentInstructions(agent)) > 0
+}
+
+// AgentVisited records demand from a user pinging inside an agent's area:
+// a hibernating agent wakes and a slowed one refreshes now
+func AgentVisited(agent *Entity) {
+	if agent == nil {
+		return
+	}
+	sched := AgentScheduler()
+	idle := sched.Visit(agent)
+	switch AgentState(agent) {
+	case AgentHibernating:
+		log.Printf("[agent] %s woken by a nearby user", agent.Name)
+		ControlAgent(agent, "resume", 0, 0)
+	case AgentActive:
+		if idle >= slowAfter {
+			sched.Schedule(agent, TaskLive, time.Now(), true)
+		}
+	}
+}
+
+// AgentMode is how often an agent works: live, slow, hibernating or paused
+func AgentMode(agent *Entity) string {
+	switch state := AgentState(agent); state {
+	case AgentActive, AgentRefreshing:
+		if AgentScheduler().Idle(agent.ID) >= slowAfter {
+			return "slow"
+		}
+		return "live"
+	default:
+		return state
+	}
+}
+
+// ControlAgent applies a lifecycle action to an agent:
+// pause, resume, refresh, move (to lat, lon) or delete
+func ControlAgent(agent *Entity, action string, lat, lon float64) error {
+	sched := AgentScheduler()
+	now := time.Now()
+
+	switch action {
+	case "pause":
+		if _, err := transition(agent, AgentPaused); err != nil {
+			return err
+		}
+		sched.Remove(agent.ID)
+
+	case "resume":
+		if _, err := transition(agent, AgentActive); err != nil {
+			return err
+		}
+		sched.Schedule(agent, TaskLive, now, true)
+		sched.Schedule(agent, TaskIndex, now, true)
+
+	case "refresh":
+		from, err := transition(agent, AgentRefreshing)
+		if err != nil {
+			return err
+		}
+		// Brings the active loop's next fetch forward, or runs one fetch
+		// for an agent that is otherwise idle
+		sched.Schedule(agent, TaskLive, now, from == AgentActive)
+
+	case "move":
+		if lat == 0 && lon == 0 {
+			return fmt.Errorf("move needs lat and lon")
+		}
+		// Leaving its tile needs somewhere no other agent covers
+		if err := retile(agent, lat, lon); err != nil {
+			return err
+		}
+		state := AgentState(agent)
+		agentControlsMu.Lock()
+		c := control(agent)
+		close(c.stop)
+		c.stop = make(chan struct{})
+		agentControlsMu.Unlock()
+		sched.Remove(agent.ID)
+
+		log.Printf("[agent] %s moved to %.4f,%.4f", agent.Name, lat, lon)
+		agent.Lat, agent.Lon = lat, lon
+		Get().Insert(agent)
+		if state == AgentActive || state == AgentRefreshing {
+			sched.Schedule(agent, TaskLive, now, true)
+			sched.Schedule(agent, TaskIndex, now, true)
+			IndexStreetsAsync(agent)
+		}
+
+	case "delete":
+		if _, err := transition(agent, AgentDeleted); err != nil {
+			return err
+		}
+		sched.Remove(agent.ID)
+		forgetTaskRuns(agent.ID)
+		forgetTaskHistory(agent.ID)
+		releaseTerritory(agent.ID)
+		Get().Delete(agent.ID)
+
+	default:
+		return fmt.Errorf("unknown action %q, want pause, resume, refresh, move or delete", action)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package spatial
+
+import "testing"
+
+// testLifecycleAgent stores an active agent, removed with its control after the test
+func testLifecycleAgent(t *testing.T, id string) *Entity {
+	agent := &Entity{
+		ID:   id,
+		Type: EntityAgent,
+		Name: "Lifecycle",
+		Lat:  51.5007,
+		Lon:  -0.1246,
+		Data: &AgentEntityData{Radius: AgentRadius, Status: AgentActive},
+	}
+	Get().Insert(agent)
+	t.Cleanup(func() {
+		Get().Delete(id)
+		agentControlsMu.Lock()
+		delete(agentControls, id)
+		agentControlsMu.Unlock()
+	})
+	return agent
+}
+
+// TestPauseStopsWork checks pausing stops in-flight work and is stored
+func TestPauseStopsWork(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-pause")
+	stop := agentStop(agent)
+	if err := ControlAgent(agent, "pause", 0, 0); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if !stopped(stop) || agentRuns(agent) {
+		t.Error("paused agent's work not stopped")
+	}
+	if ad := Get().GetByID(agent.ID).GetAgentData(); ad.Status != AgentPaused {
+		t.Errorf("stored status %q, want paused", ad.Status)
+	}
+}
+
+// TestPauseTwice checks pausing a paused agent fails
+func TestPauseTwice(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-pause-twice")
+	ControlAgent(agent, "pause", 0, 0)
+	if err := ControlAgent(agent, "pause", 0, 0); err == nil {
+		t.Error("pausing a paused agent should fail")
+	}
+}
+
+// TestControlUnknown checks an unknown action fails
+func TestControlUnknown(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-unknown")
+	if err := ControlAgent(agent, "dance", 0, 0); err == nil {
+		t.Error("unknown action should fail")
+	}
+}
+
+// TestRefreshWhilePaused checks a refresh runs once, then the agent goes
+// back to sleep
+func TestRefreshWhilePaused(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-refresh")
+	ControlAgent(agent, "pause", 0, 0)
+	if _, err := transition(agent, AgentRefreshing); err != nil || !agentRuns(agent) {
+		t.Fatalf("refresh from paused: %v", err)
+	}
+	finishRefresh(agent)
+	if state := AgentState(agent); state != AgentPaused {
+		t.Errorf("after refresh %s, want paused", state)
+	}
+}
+
+// TestStateAfterRestart checks the stored status decides after a restart
+func TestStateAfterRestart(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-restart")
+	ControlAgent(agent, "pause", 0, 0)
+	agentControlsMu.Lock()
+	delete(agentControls, agent.ID)
+	agentControlsMu.Unlock()
+	if state := AgentState(Get().GetByID(agent.ID)); state != AgentPaused {
+		t.Errorf("after restart %s, want paused", state)
+	}
+}
+
+// TestDeleteStopsWork checks deleting stops in-flight work and unstores the agent
+func TestDeleteStopsWork(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-delete")
+	stop := agentStop(agent)
+	if err := ControlAgent(agent, "delete", 0, 0); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !stopped(stop) || Get().GetByID(agent.ID) != nil {
+		t.Error("deleted agent still running or stored")
+	}
+}
+
+// TestDeleteLateTask checks a live task a worker took just before the
+// delete doesn't bring the agent back
+func TestDeleteLateTask(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-late-task")
+	ControlAgent(agent, "delete", 0, 0)
+	runAgentTask(agent, TaskLive)
+	if Get().GetByID(agent.ID) != nil || AgentState(agent) != AgentDeleted || !stopped(agentStop(agent)) {
+		t.Error("deleted agent brought back by a late task")
+	}
+}
+
+// TestResumeDeleted checks a deleted agent can't be resumed
+func TestResumeDeleted(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-resume-deleted")
+	ControlAgent(agent, "delete", 0, 0)
+	if err := ControlAgent(agent, "resume", 0, 0); err == nil {
+		t.Error("resumed a deleted agent")
+	}
+}
+
+// TestClearDeleted checks a new agent in a deleted one's place starts afresh
+func TestClearDeleted(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-clear-deleted")
+	ControlAgent(agent, "delete", 0, 0)
+	clearDeleted(agent.ID)
+	if AgentState(&Entity{ID: agent.ID, Type: EntityAgent}) != AgentActive {
+		t.Error("tombstone kept for a new agent")
+	}
+}
+
+// TestDeletedAgentTasks checks setting a deleted agent's tasks doesn't store it again
+func TestDeletedAgentTasks(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-deleted-tasks")
+	ControlAgent(agent, "delete", 0, 0)
+	if err := SetAgentTasks(agent, nil); err == nil || Get().GetByID(agent.ID) != nil {
+		t.Errorf("deleted agent stored again: %v", err)
+	}
+}
+
+// TestDeletedAgentInstruction checks instructing a deleted agent doesn't store it again
+func TestDeletedAgentInstruction(t *testing.T) {
+	agent := testLifecycleAgent(t, "test-agent-deleted-instruction")
+	ControlAgent(agent, "delete", 0, 0)
+	if _, err := storeInstruction(agent, AgentInstruction{ID: "weather", Prompt: "weather"}); err == nil || Get().GetByID(agent.ID) != nil {
+		t.Errorf("deleted agent stored again: %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package spatial
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tflLineStatusURL  = tflBaseURL + "/Line/Mode/tube,overground,dlr,elizabeth-line,tram,national-rail/Status"
+	lineStatusTTL     = 5 * time.Minute
+	lineFetchInterval = 2 * time.Minute
+	lineStopRadius    = 800.0 // Stops within walking distance link their lines
+
+	// TfL statusSeverity values that mean the line is running normally
+	severityGoodService = 10
+	severityNoIssues    = 18
+	severityInformation = 19
+)
+
+// One fetch covers every London line, shared by all agents
+var (
+	lineFetchMu   sync.Mutex
+	lineFetchedAt time.Time
+)
+
+// servedStop is a stop and the lines calling there, from TfL StopPoint search
+type servedStop struct {
+	Lat, Lon float64
+	Lines    []string // TfL line IDs
+}
+
+// stopLines indexes stops seen by agents, including stops with no arrivals -
+// those are the ones a suspended line leaves empty
+var (
+	stopLines   = make(map[string]servedStop) // NaPTAN ID -> stop
+	stopLinesMu sync.RWMutex
+)
+
+// tflLine mirrors the TfL Line Status response
+type tflLine struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	ModeName     string `json:"modeName"`
+	LineStatuses []struct {
+		StatusSeverity            int    `json:"statusSeverity"`
+		StatusSeverityDescription string `json:"statusSeverityDescription"`
+		Reason                    string `json:"reason"`
+		ValidityPeriods           []struct {
+			FromDate string `json:"fromDate"`
+			ToDate   string `json:"toDate"`
+			IsNow    bool   `json:"isNow"`
+		} `json:"validityPeriods"`
+	} `json:"lineStatuses"`
+}
+
+// recordStopLines notes which lines serve a stop
+func recordStopLines(naptanID string, lat, lon float64, lines []string) {
+	if naptanID == "" || len(lines) == 0 {
+		return
+	}
+	stopLinesMu.Lock()
+	stopLines[naptanID] = servedStop{Lat: lat, Lon: lon, Lines: lines}
+	stopLinesMu.Unlock()
+}
+
+// LinesNear returns IDs of lines serving stops within radius
+func LinesNear(lat, lon, radiusMeters float64) []string {
+	stopLinesMu.RLock()
+	defer stopLinesMu.RUnlock()
+
+	seen := make(map[string]bool)
+	var lines []string
+	for _, stop := range stopLines {
+		if haversineMeters(lat, lon, stop.Lat, stop.Lon) > radiusMeters {
+			continue
+		}
+		for _, id := range stop.Lines {
+			if !seen[id] {
+				seen[id] = true
+				lines = append(lines, id)
+			}
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// fetchLineStatus fetches TfL rail and tube line status, one entity per line
+// Returns the stored entities, or nil if skipped (outside London or fetched recently)
+func fetchLineStatus(lat, lon float64) ([]*Entity, error) {
+	if !IsLondon(lat, lon) {
+		return nil, nil
+	}
+
+	lineFetchMu.Lock()
+	if time.Since(lineFetchedAt) < lineFetchInterval {
+		lineFetchMu.Unlock()
+		return nil, nil
+	}
+	lineFetchedAt = time.Now()
+	lineFetchMu.Unlock()
+
+	resp, err := TfLGet(tflLineStatusURL)
+	if err != nil {
+		log.Printf("[lines] TfL API error: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		log.Printf("[lines] TfL API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("tfl returned %d", resp.StatusCode)
+	}
+
+	var lines []tflLine
+	if err := json.NewDecoder(resp.Body).Decode(&lines); err != nil {
+		log.Printf("[lines] Decode error: %v", err)
+		return nil, err
+	}
+
+	db := Get()
+	now := time.Now()
+	expiry := now.Add(lineStatusTTL)
+	var entities []*Entity
+	for _, l := range lines {
+		entity := lineStatusEntity(l, now)
+		entity.ExpiresAt = &expiry
+		db.Insert(entity)
+		entities = append(entities, entity)
+	}
+	log.Printf("[lines] Stored status for %d lines", len(entities))
+	return entities, nil
+}
+
+// lineStatusEntity picks the worst status in force now
+// Planned statuses whose validity hasn't started are ignored
+func lineStatusEntity(l tflLine, now time.Time) *Entity {
+	ld := &LineStatusData{
+		LineID:   l.ID,
+		LineName: l.Name,
+		Mode:     l.ModeName,
+		Severity: severityGoodService,
+		Status:   "Good Service",
+	}
+
+	for _, st := range l.LineStatuses {
+		var from, to *time.Time
+		current := len(st.ValidityPeriods) == 0
+		for _, vp := range st.ValidityPeriods {
+			f, ferr := time.Parse(time.RFC3339, vp.FromDate)
+			t, terr := time.Parse(time.RFC3339, vp.ToDate)
+			if vp.IsNow || (ferr == nil && terr == nil && !now.Before(f) && now.Before(t)) {
+				current = true
+				if ferr == nil {
+					from = &f
+				}
+				if terr == nil {
+					to = &t
+				}
+				break
+			}
+		}
+		if !current || severityRankLine(st.StatusSeverity) >= severityRankLine(ld.Severity) {
+			continue
+		}
+		ld.Severity = st.StatusSeverity
+		ld.Status = st.StatusSeverityDescription
+		ld.Reason = cleanLineReason(st.Reason, l.Name)
+		ld.ValidFrom, ld.ValidTo = from, to
+	}
+
+	return &Entity{
+		ID:   lineStatusID(l.ID),
+		Type: EntityLineStatus,
+		Name: l.Name,
+		// Lines span the city - stored at the centre, looked up by ID
+		Lat:  51.5074,
+		Lon:  -0.1278,
+		Data: ld,
+	}
+}
+
+func lineStatusID(lineID string) string {
+	return GenerateID(EntityLineStatus, 0, 0, "tfl:"+lineID)
+}
+
+// severityRankLine orders TfL severities, lower is worse
+// Good service and informational statuses rank last
+func severityRankLine(severity int) int {
+	switch severity {
+	case severityGoodService, severityNoIssues, severityInformation:
+		return 100
+	case 20: // Service Closed (outside operating hours)
+		return 50
+	}
+	return severity
+}
+
+// cleanLineReason drops TfL's "District Line: " prefix and collapses whitespace
+func cleanLineReason(reason, lineName string) string {
+	reason = strings.Join(strings.Fields(reason), " ")
+	for _, prefix := range []string{lineName + " Line: ", lineName + " line: ", lineName + ": "} {
+		reason = strings.TrimPrefix(reason, prefix)
+	}
+	return reason
+}
+
+// IsGood reports whether the line is running normally
+func (ld *LineStatusData) IsGood() bool {
+	return severityRankLine(ld.Severity) == 100
+}
+
+// lineIcon returns a mode icon
+func lineIcon(mode string) string {
+	switch mode {
+	case "tube":
+		return "🚇"
+	case "tram":
+		return "🚊"
+	case "dlr":
+		return "🚈"
+	default:
+		return "🚆"
+	}
+}
+
+// FormatLineStatus formats a line status: "🚇 District: Minor Delays - signal failure at Earl's Court"
+func FormatLineStatus(ld *LineStatusData) string {
+	s := fmt.Sprintf("%s %s: %s", lineIcon(ld.Mode), ld.LineName, ld.Status)
+	if ld.Reason != "" && !ld.IsGood() {
+		reason := ld.Reason
+		if len(reason) > 120 {
+			reason = reason[:117] + "..."
+		}
+		s += " - " + reason
+	}
+	return s
+}
+
+// GetNearbyLineStatus returns status for lines serving stops near a location
+// Worst first, then by name
+func GetNearbyLineStatus(lat, lon float64) []*LineStatusData {
+	db := Get()
+	var result []*LineStatusData
+	for _, id := range LinesNear(lat, lon, lineStopRadius) {
+		e := db.GetByID(lineStatusID(id))
+		if e == nil {
+			continue
+		}
+		if ld := e.GetLineStatusData(); ld != nil {
+			result = append(result, ld)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ri, rj := severityRankLine(result[i].Severity), severityRankLine(result[j].Severity)
+		if ri != rj {
+			return ri < rj
+		}
+		return result[i].LineName < result[j].LineName
+	})
+	return result
+}
@@ -0,0 +1,55 @@
+package spatial
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDistrictStatus is the District line with minor delays now and a
+// closure planned for the weekend
+func testDistrictStatus(t *testing.T) *LineStatusData {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	var line tflLine
+	raw := `{"id": "district", "name": "District", "modeName": "tube", "lineStatuses": [
+		{"statusSeverity": 4, "statusSeverityDescription": "Planned Closure", "reason": "District Line: No service this weekend",
+		 "validityPeriods": [{"fromDate": "2026-10-24T04:30:00Z", "toDate": "2026-10-26T00:30:00Z", "isNow": false}]},
+		{"statusSeverity": 9, "statusSeverityDescription": "Minor Delays", "reason": "District Line: Minor delays due to an earlier signal failure at Earl's Court.",
+		 "validityPeriods": [{"fromDate": "2026-10-18T08:10:00Z", "toDate": "2026-10-18T10:00:00Z", "isNow": true}]}
+	]}`
+	if err := json.Unmarshal([]byte(raw), &line); err != nil {
+		t.Fatal(err)
+	}
+	return lineStatusEntity(line, now).GetLineStatusData()
+}
+
+// TestLineStatusCurrent checks the status in force now is picked, with its
+// reason and end time
+func TestLineStatusCurrent(t *testing.T) {
+	ld := testDistrictStatus(t)
+	if ld.Severity != 9 || ld.Status != "Minor Delays" || ld.IsGood() {
+		t.Fatalf("status = %+v, want current minor delays", ld)
+	}
+	if !strings.HasPrefix(ld.Reason, "Minor delays due to") {
+		t.Errorf("reason = %q", ld.Reason)
+	}
+	if ld.ValidTo == nil || ld.ValidTo.Hour() != 10 {
+		t.Errorf("valid to = %v", ld.ValidTo)
+	}
+}
+
+// TestLineStatusChanges checks a line going from good service to delays is
+// reported once
+func TestLineStatusChanges(t *testing.T) {
+	ld := testDistrictStatus(t)
+	old := &ContextData{Lines: []LineStatusInfo{{ID: "district", Name: "District", Severity: 10, Good: true}}}
+	new := &ContextData{Lines: []LineStatusInfo{{ID: "district", Name: "District", Severity: 9, Display: FormatLineStatus(ld)}}}
+	changes := DetectChanges(old, new)
+	if len(changes.LineChanges) != 1 || !strings.Contains(changes.LineChanges[0], "District: Minor Delays") {
+		t.Errorf("LineChanges = %v", changes.LineChanges)
+	}
+	if changes := DetectChanges(new, new); len(changes.LineChanges) != 0 {
+		t.Errorf("unchanged status reported: %v", changes.LineChanges)
+	}
+}
@@ -2,7 +2,6 @@ package spatial
 
 import (
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"log"
 	"math"
@@ -30,21 +29,24 @@ const (
 
 // Live data fetch functions - called by agent loops
 
-func fetchWeather(lat, lon float64) *Entity {
+func fetchWeather(lat, lon float64) (*Entity, error) {
 	// Check spatial cache first - weather valid for ~5km
 	db := Get()
 	cached := db.Query(lat, lon, 5000, EntityWeather, 1)
 	if len(cached) > 0 && cached[0].ExpiresAt != nil && time.Now().Before(*cached[0].ExpiresAt) {
-		return nil // Already have fresh data nearby
+		return nil, nil // Already have fresh data nearby
 	}
 
 	url := fmt.Sprintf("%s?latitude=%.2f&longitude=%.2f&current=temperature_2m,weather_code&hourly=precipitation_probability&timezone=auto&forecast_hours=6",
 		weatherURL, lat, lon)
 	resp, err := WeatherGet(url)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("weather returned %d", resp.StatusCode)
+	}
 
 	var data struct {
 		Current struct {
@@ -57,7 +59,7 @@ func fetchWeather(lat, lon float64) *Entity {
 		} `json:"hourly"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil
+		return nil, err
 	}
 
 	// Check rain forecast
@@ -99,7 +101,7 @@ func fetchWeather(lat, lon float64) *Entity {
 	}
 	// Insert under lock to prevent race
 	db.Insert(entity)
-	return entity
+	return entity, nil
 }
 
 // computePrayerDisplay calculates current/next prayer from stored timings at query time
@@ -107,6 +109,13 @@ func computePrayerDisplay(e *Entity) string {
 	if e == nil {
 		return ""
 	}
+	return prayerDisplayAt(e, time.Now())
+}
+
+// prayerDisplayAt works out current/next prayer at a given time
+// Aladhan timings are local to the location, so now is converted to its zone
+func prayerDisplayAt(e *Entity, now time.Time) string {
+	now = LocalTime(now, e.Lat, e.Lon)
 
 	prayerData := e.GetPrayerData()
 	if prayerData == nil || len(prayerData.Timings) == 0 {
@@ -118,7 +127,7 @@ func computePrayerDisplay(e *Entity) string {
 
 	// Prayer times in order (Sunrise is not a prayer but marks end of Fajr)
 	prayers := []string{"Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"}
-	nowStr := time.Now().Format("15:04")
+	nowStr := now.Format("15:04")
 
 	var current, next, nextTime string
 	for i, p := range prayers {
@@ -167,7 +176,6 @@ func computePrayerDisplay(e *Entity) string {
 			}
 			// Fajr ends before sunrise - calculate end time (10 min before sunrise)
 			if sunriseTime, err := time.Parse("15:04", sunrise); err == nil {
-				now := time.Now()
 				sunriseToday := time.Date(now.Year(), now.Month(), now.Day(), sunriseTime.Hour(), sunriseTime.Minute(), 0, 0, now.Location())
 				fajrEnd := sunriseToday.Add(-10 * time.Minute) // Fajr ends 10 min before sunrise
 				fajrEndStr := fajrEnd.Format("15:04")
@@ -184,27 +192,27 @@ func computePrayerDisplay(e *Entity) string {
 	return fmt.Sprintf("🕌 %s %s", next, nextTime)
 }
 
-func fetchPrayerTimes(lat, lon float64) *Entity {
+func fetchPrayerTimes(lat, lon float64) (*Entity, error) {
 	// Check spatial cache first - prayer times valid for ~50km (same city)
 	db := Get()
 	cached := db.Query(lat, lon, 50000, EntityPrayer, 1)
 	if len(cached) > 0 && cached[0].ExpiresAt != nil && time.Now().Before(*cached[0].ExpiresAt) {
-		return nil // Already have fresh data nearby
+		return nil, nil // Already have fresh data nearby
 	}
 
 	log.Printf("[prayer] Fetching prayer times for %.4f,%.4f", lat, lon)
-	now := time.Now()
+	now := LocalTime(time.Now(), lat, lon)
 	url := fmt.Sprintf("%s/%s?latitude=%.2f&longitude=%.2f&method=2",
 		prayerTimesURL, now.Format("02-01-2006"), lat, lon)
 
 	resp, err := PrayerGet(url)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil
+		return nil, fmt.Errorf("prayer times returned %d", resp.StatusCode)
 	}
 
 	var data struct {
@@ -213,7 +221,7 @@ func fetchPrayerTimes(lat, lon float64) *Entity {
 		} `json:"data"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil
+		return nil, err
 	}
 
 	// Calculate current and next prayer
@@ -269,12 +277,13 @@ func fetchPrayerTimes(lat, lon float64) *Entity {
 	}
 	// Insert under lock to prevent race
 	db.Insert(entity)
-	return entity
+	return entity, nil
 }
 
 // fetchBusArrivals is deprecated, use fetchTransportArrivals
 func fetchBusArrivals(lat, lon float64) []*Entity {
-	return fetchTransportArrivals(lat, lon, "NaptanPublicBusCoachTram", "🚌")
+	arrivals, _ := fetchTransportArrivals(lat, lon, "NaptanPublicBusCoachTram", "🚌")
+	return arrivals
 }
 
 // fetchTransportArrivals gets arrivals for any TfL stop type
@@ -284,10 +293,10 @@ func fetchBusArrivals(lat, lon float64) []*Entity {
 //   - slice of entities if new arrivals were fetched
 //   - empty slice if API returned no arrivals (caller should extend TTL)
 //   - nil if skipped because fresh cache exists (caller should not extend TTL)
-func fetchTransportArrivals(lat, lon float64, stopType, icon string) []*Entity {
+func fetchTransportArrivals(lat, lon float64, stopType, icon string) ([]*Entity, error) {
 	// TfL only works for London - check region
 	if !IsLondon(lat, lon) {
-		return nil // Outside London - don't query TfL
+		return nil, nil // Outside London - don't query TfL
 	}
 
 	// Check if we have fresh arrivals in this area already
@@ -301,7 +310,7 @@ func fetchTransportArrivals(lat, lon float64, stopType, icon string) []*Entity {
 	}
 	if freshCount >= 2 {
 		log.Printf("[transport] Skipping fetch for %.4f,%.4f - have %d fresh arrivals", lat, lon, freshCount)
-		return nil // nil = skipped, don't extend TTL
+		return nil, nil // nil = skipped, don't extend TTL
 	}
 	log.Printf("[transport] Fetching %s arrivals for %.4f,%.4f (cached fresh: %d)", stopType, lat, lon, freshCount)
 
@@ -312,17 +321,17 @@ func fetchTransportArrivals(lat, lon float64, stopType, icon string) []*Entity {
 	resp, err := TfLGet(url)
 	if err != nil {
 		log.Printf("[transport] API error for %s: %v", stopType, err)
-		return []*Entity{} // empty = extend TTL
+		return []*Entity{}, err // empty = extend TTL
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 429 {
 		log.Printf("[transport] Rate limited (429) for %s", stopType)
-		return []*Entity{} // empty = extend TTL
+		return []*Entity{}, fmt.Errorf("tfl rate limited") // empty = extend TTL
 	}
 	if resp.StatusCode != 200 {
 		log.Printf("[transport] API returned %d for %s", resp.StatusCode, stopType)
-		return []*Entity{} // empty = extend TTL
+		return []*Entity{}, fmt.Errorf("tfl returned %d", resp.StatusCode) // empty = extend TTL
 	}
 
 	var stops struct {
@@ -331,10 +340,23 @@ func fetchTransportArrivals(lat, lon float64, stopType, icon string) []*Entity {
 			CommonName string  `json:"commonName"`
 			Lat        float64 `json:"lat"`
 			Lon        float64 `json:"lon"`
+			Lines      []struct {
+				ID string `json:"id"`
+			} `json:"lines"`
 		} `json:"stopPoints"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&stops); err != nil {
-		return nil
+		return nil, err
+	}
+
+	// Remember which lines serve each stop, even ones without arrivals,
+	// so line status can be linked to the area
+	for _, stop := range stops.StopPoints {
+		var lines []string
+		for _, l := range stop.Lines {
+			lines = append(lines, l.ID)
+		}
+		recordStopLines(stop.NaptanID, stop.Lat, stop.Lon, lines)
 	}
 
 	var entities []*Entity
@@ -390,7 +412,13 @@ func fetchTransportArrivals(lat, lon float64, stopType, icon string) []*Entity {
 		entities = append(entities, entity)
 	}
 
-	return entities
+	return entities, nil
+}
+
+// isTfLStop reports whether arrivals came from TfL and can be refetched there
+// Irish Rail and Metrolink tasks refresh their own stops
+func isTfLStop(ad *ArrivalData) bool {
+	return ad.StopType == "" || strings.HasPrefix(ad.StopType, "Naptan")
 }
 
 // toBusArrivals converts internal busArrival to typed BusArrival
@@ -499,73 +527,83 @@ func haversine(lat1, lon1, lat2, lon2 float64) float64 {
 	return R * c
 }
 
-// fetchLocation reverse geocodes and returns an entity for caching
 // fetchLocation reverse geocodes the given coordinates and stores a point
+// Indexed data is tried first (see geocoder.go); Nominatim is the fallback
 // The caller should check if a nearby point already exists before calling
-func fetchLocation(lat, lon float64) *Entity {
+func fetchLocation(lat, lon float64) (*Entity, error) {
 	// Check if already exists
 	db := Get()
 	if existing := db.GetNearestLocation(lat, lon, 10); existing != nil {
-		return existing
+		return existing, nil
+	}
+
+	if local := localLocation(lat, lon); local != nil {
+		GetCacheStats().RecordLocationLocal()
+		return local, nil
 	}
 
 	url := fmt.Sprintf("https://nominatim.openstreetmap.org/reverse?lat=%f&lon=%f&format=json&zoom=18",
 		lat, lon)
 
+	GetCacheStats().RecordLocationNominatim()
 	resp, err := LocationGet(url)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("nominatim returned %d", resp.StatusCode)
+	}
 
 	var data struct {
 		Address struct {
 			Road     string `json:"road"`
 			Suburb   string `json:"suburb"`
 			Town     string `json:"town"`
+			City     string `json:"city"`
 			Postcode string `json:"postcode"`
 		} `json:"address"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil
+		return nil, err
 	}
 
-	road := data.Address.Road
-	area := data.Address.Postcode
-	if area == "" {
-		area = data.Address.Suburb
-	}
-	if area == "" {
-		area = data.Address.Town
+	suburb := data.Address.Suburb
+	if suburb == "" {
+		suburb = data.Address.Town
 	}
-
-	var name string
-	if road != "" && area != "" {
-		name = road + ", " + area
-	} else if road != "" {
-		name = road
-	} else {
-		name = area
+	addr := &Address{
+		Street:   data.Address.Road,
+		Postcode: data.Address.Postcode,
+		Area:     suburb,
 	}
 
+	name := addr.Name()
 	if name == "" {
-		return nil
+		return nil, nil
 	}
 
 	// Cache for 1 hour - locations don't change
+	// Suburb and city are kept so the offline geocoder can name areas
 	expiry := time.Now().Add(1 * time.Hour)
 	entity := &Entity{
-		ID:        GenerateID(EntityLocation, lat, lon, "location"),
-		Type:      EntityLocation,
-		Name:      name,
-		Lat:       lat,
-		Lon:       lon,
-		Data:      map[string]interface{}{"road": road, "area": area, "postcode": data.Address.Postcode},
+		ID:   GenerateID(EntityLocation, lat, lon, "location"),
+		Type: EntityLocation,
+		Name: name,
+		Lat:  lat,
+		Lon:  lon,
+		Data: &LocationData{
+			Street:   addr.Street,
+			Postcode: addr.Postcode,
+			Suburb:   suburb,
+			City:     data.Address.City,
+			Source:   "nominatim",
+		},
 		ExpiresAt: &expiry,
 	}
 	// Insert under lock to prevent race
 	db.Insert(entity)
-	return entity
+	return entity, nil
 }
 
 // getNearestStopWithArrivals returns the nearest stop and its arrivals
@@ -626,7 +664,7 @@ func getNearestStopWithArrivals(lat, lon float64) string {
 		}
 
 		// If we used stale data, try to refresh in background
-		if isStale && !hasFresh {
+		if isStale && !hasFresh && isTfLStop(arrData) {
 			go func(stopID, stopName string, stopLat, stopLon float64) {
 				if arrs := fetchStopArrivals(stopID); len(arrs) > 0 {
 					expiry := time.Now().Add(arrivalTTL)
@@ -927,117 +965,6 @@ func formatPlaceData(e *Entity) string {
 	return strings.Join(parts, "|")
 }
 
-// getTrafficDisruptions returns cached disruptions or fetches new ones
-func getTrafficDisruptions(lat, lon float64) string {
-	db := Get()
-
-	// Cache only - agents handle fetching
-	cached := db.Query(lat, lon, 10000, EntityDisruption, 1)
-	for _, d := range cached {
-		if d.ExpiresAt != nil && time.Now().Before(*d.ExpiresAt) {
-			return d.Name
-		}
-	}
-
-	return ""
-}
-
-// fetchTrafficDisruptions gets nearby road disruptions from TfL and caches them
-func fetchTrafficDisruptions(lat, lon float64) string {
-	db := Get()
-	disruptionUrl := "https://api.tfl.gov.uk/Road/all/Disruption"
-
-	resp, err := TfLGet(disruptionUrl)
-	if err != nil {
-		log.Printf("[disruption] TfL API error: %v", err)
-		return ""
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		log.Printf("[disruption] TfL API returned status %d", resp.StatusCode)
-		return ""
-	}
-
-	var disruptions []struct {
-		Severity  string `json:"severity"`
-		Category  string `json:"category"`
-		Comments  string `json:"comments"`
-		Location  string `json:"location"`
-		Geography struct {
-			Type        string    `json:"type"`
-			Coordinates []float64 `json:"coordinates"`
-		} `json:"geography"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&disruptions); err != nil {
-		log.Printf("[disruption] Decode error: %v", err)
-		return ""
-	}
-
-	// Find disruptions within 5km, prioritize serious ones
-	type nearby struct {
-		dist     float64
-		severity string
-		text     string
-		lat      float64
-		lon      float64
-	}
-	var found []nearby
-
-	for _, d := range disruptions {
-		if d.Geography.Type != "Point" || len(d.Geography.Coordinates) < 2 {
-			continue
-		}
-		dlon, dlat := d.Geography.Coordinates[0], d.Geography.Coordinates[1]
-		dist := haversine(lat, lon, dlat, dlon)
-
-		if dist < 5 { // within 5km
-			// Only show serious/moderate or collisions
-			if d.Severity == "Serious" || d.Severity == "Moderate" || d.Category == "Collisions" {
-				text := d.Comments
-				if len(text) > 80 {
-					text = text[:80] + "..."
-				}
-				found = append(found, nearby{dist: dist, severity: d.Severity, text: text, lat: dlat, lon: dlon})
-			}
-		}
-	}
-
-	if len(found) == 0 {
-		return ""
-	}
-
-	// Return closest serious one
-	var best nearby
-	for _, f := range found {
-		if best.text == "" || f.dist < best.dist {
-			best = f
-		}
-	}
-
-	icon := "🚧"
-	if strings.Contains(strings.ToLower(best.text), "collision") || strings.Contains(strings.ToLower(best.text), "accident") {
-		icon = "🚨"
-	}
-
-	result := fmt.Sprintf("%s %.1fkm: %s", icon, best.dist, best.text)
-
-	// Cache this disruption
-	expiry := time.Now().Add(disruptionTTL)
-	db.Insert(&Entity{
-		ID:        GenerateID(EntityDisruption, best.lat, best.lon, best.text[:min(20, len(best.text))]),
-		Type:      EntityDisruption,
-		Name:      result,
-		Lat:       best.lat,
-		Lon:       best.lon,
-		Data:      map[string]interface{}{"severity": best.severity, "text": best.text},
-		ExpiresAt: &expiry,
-	})
-
-	return result
-}
-
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -1045,70 +972,6 @@ func min(a, b int) int {
 	return b
 }
 
-// fetchBreakingNews gets top UK headline from BBC RSS
-// GetBreakingNews returns the top UK headline (cached 30min)
-func GetBreakingNews() string {
-	if news := fetchBreakingNews(); news != nil {
-		Get().Insert(news)
-		return news.Name
-	}
-	return ""
-}
-
-func fetchBreakingNews() *Entity {
-	// Check cache first (global, not location-based)
-	db := Get()
-	news := db.Query(0, 0, 1000000, EntityNews, 1) // Global query
-	for _, n := range news {
-		if n.ExpiresAt != nil && time.Now().Before(*n.ExpiresAt) {
-			return n
-		}
-	}
-
-	resp, err := NewsGet(bbcUKRSS)
-	if err != nil {
-		return nil
-	}
-	defer resp.Body.Close()
-
-	var rss struct {
-		Channel struct {
-			Items []struct {
-				Title   string `xml:"title"`
-				Link    string `xml:"link"`
-				PubDate string `xml:"pubDate"`
-			} `xml:"item"`
-		} `xml:"channel"`
-	}
-
-	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
-		return nil
-	}
-
-	if len(rss.Channel.Items) == 0 {
-		return nil
-	}
-
-	top := rss.Channel.Items[0]
-	expiry := time.Now().Add(newsTTL)
-
-	// Clean up the link (remove tracking params)
-	link := top.Link
-	if idx := strings.Index(link, "?"); idx > 0 {
-		link = link[:idx]
-	}
-
-	return &Entity{
-		ID:        "news-uk-headline",
-		Type:      EntityNews,
-		Name:      "📰 " + top.Title + "\n" + link,
-		Lat:       0, // Global
-		Lon:       0,
-		Data:      map[string]interface{}{"source": "BBC UK", "pubDate": top.PubDate, "link": link},
-		ExpiresAt: &expiry,
-	}
-}
-
 // BusArrivalInfo contains structured bus arrival data
 type BusArrivalInfo struct {
 	StopName string
@@ -1156,7 +1019,7 @@ func GetNearestBusArrivals(lat, lon float64) *BusArrivalInfo {
 
 		// If we have few valid arrivals left, trigger background refresh
 		// This ensures we fetch new data before running out completely
-		if validCount <= 1 && arrData.StopID != "" {
+		if validCount <= 1 && arrData.StopID != "" && isTfLStop(arrData) {
 			go func(sid, sname string, slat, slon float64, cnt int) {
 				log.Printf("[bus] Low arrivals for %s (%d left), fetching fresh data", sname, cnt)
 				if arrs := fetchStopArrivals(sid); len(arrs) > 0 {
@@ -393,3 +393,15 @@ func TestRadiusForDifferentEntityTypes(t *testing.T) {
 		}
 	}
 }
+
+// TestPrayerDisplayLocalTime checks prayer times compare against the
+// location's clock, not the server's
+func TestPrayerDisplayLocalTime(t *testing.T) {
+	prayer := &Entity{Type: EntityPrayer, Lat: 24.861, Lon: 67.010, Data: &PrayerData{Timings: map[string]string{
+		"Fajr": "05:00", "Sunrise": "06:30", "Dhuhr": "12:30", "Asr": "16:00", "Maghrib": "18:00", "Isha": "19:30",
+	}}}
+	noonUTC := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC) // 13:00 in Karachi
+	if got := prayerDisplayAt(prayer, noonUTC); got != "🕌 Dhuhr now · Asr 16:00" {
+		t.Errorf("prayer display = %q", got)
+	}
+}
@@ -0,0 +1,237 @@
+package spatial
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrolink tram departures for agents in Manchester, from TfGM's open data
+// API. One call returns every platform in the network, so it is shared by
+// all agents. The API has no stop coordinates, so stops are placed by
+// matching names against OSM tram stops.
+
+const (
+	tfgmMetrolinkURL     = "https://api.tfgm.com/odata/Metrolinks"
+	metrolinkFetchEvery  = time.Minute
+	metrolinkStopTTL     = 24 * time.Hour
+	metrolinkRadius      = 1500.0 // Stops worth showing trams for
+	metrolinkMaxStops    = 3
+	metrolinkOverpassBox = "53.3,-2.5,53.6,-2.0" // Manchester region bounds
+)
+
+var TfGMAPIKey = os.Getenv("TFGM_API_KEY")
+
+// metrolinkPlatform is one row of the TfGM feed: up to four trams due
+type metrolinkPlatform struct {
+	TLAREF          string // Stop code, shared by its platforms
+	StationLocation string
+	Dest0, Wait0    string
+	Dest1, Wait1    string
+	Dest2, Wait2    string
+	Dest3, Wait3    string
+}
+
+type metrolinkStop struct {
+	lat, lon float64
+}
+
+var (
+	metrolinkMu        sync.Mutex
+	metrolinkPlatforms []metrolinkPlatform
+	metrolinkFetchedAt time.Time
+	metrolinkStops     map[string]metrolinkStop // Normalised name -> location
+	metrolinkStopsAt   time.Time
+)
+
+func init() {
+	RegisterAgentTask(&LiveTask{
+		TaskName: "metrolink",
+		Every:    metrolinkFetchEvery,
+		Regions:  []string{"manchester"},
+		Fn:       func(a *Entity) (int, error) { return countEntities(fetchMetrolink(a.Lat, a.Lon)) },
+		Types:    []EntityType{EntityArrival},
+		API:      "tfgm",
+	})
+}
+
+// metrolinkName normalises a stop name for matching TfGM against OSM
+// "St Peter's Square" and "St. Peters Square" both become "stpeterssquare"
+func metrolinkName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, " Metrolink"))
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// metrolinkNetwork returns the latest platforms and stop locations,
+// refetching each when stale, with the error from a refetch that failed.
+// Failures wait for the next interval too
+func metrolinkNetwork() ([]metrolinkPlatform, map[string]metrolinkStop, error) {
+	metrolinkMu.Lock()
+	defer metrolinkMu.Unlock()
+
+	var lastErr error
+	if time.Since(metrolinkStopsAt) >= metrolinkStopTTL {
+		metrolinkStopsAt = time.Now()
+		if stops, err := fetchMetrolinkStops(); err != nil {
+			log.Printf("[metrolink] Stops: %v", err)
+			lastErr = fmt.Errorf("stops: %v", err)
+		} else {
+			metrolinkStops = stops
+			log.Printf("[metrolink] Located %d tram stops", len(stops))
+		}
+	}
+	if time.Since(metrolinkFetchedAt) >= metrolinkFetchEvery {
+		metrolinkFetchedAt = time.Now()
+		if platforms, err := fetchMetrolinkPlatforms(); err != nil {
+			log.Printf("[metrolink] Departures: %v", err)
+			lastErr = fmt.Errorf("departures: %v", err)
+		} else {
+			metrolinkPlatforms = platforms
+		}
+	}
+	return metrolinkPlatforms, metrolinkStops, lastErr
+}
+
+// fetchMetrolinkPlatforms gets the whole network's departure boards
+func fetchMetrolinkPlatforms() ([]metrolinkPlatform, error) {
+	resp, err := TfGMGet(tfgmMetrolinkURL, TfGMAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var doc struct {
+		Value []metrolinkPlatform `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc.Value, nil
+}
+
+// fetchMetrolinkStops locates tram stops from OSM
+func fetchMetrolinkStops() (map[string]metrolinkStop, error) {
+	query := fmt.Sprintf(`[out:json][timeout:25];
+node[railway=tram_stop](%s);
+out;`, metrolinkOverpassBox)
+	resp, err := OSMPost(OverpassURL, query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var data struct {
+		Elements []struct {
+			Lat  float64           `json:"lat"`
+			Lon  float64           `json:"lon"`
+			Tags map[string]string `json:"tags"`
+		} `json:"elements"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	stops := make(map[string]metrolinkStop)
+	for _, el := range data.Elements {
+		if name := metrolinkName(el.Tags["name"]); name != "" {
+			// Platforms share a name; the first one will do
+			if _, ok := stops[name]; !ok {
+				stops[name] = metrolinkStop{el.Lat, el.Lon}
+			}
+		}
+	}
+	return stops, nil
+}
+
+// metrolinkDepartures collects the trams due at each stop, keyed by stop code
+func metrolinkDepartures(platforms []metrolinkPlatform, now time.Time) (map[string][]BusArrival, map[string]string) {
+	departures := make(map[string][]BusArrival)
+	names := make(map[string]string)
+	for _, p := range platforms {
+		names[p.TLAREF] = p.StationLocation
+		for _, d := range [][2]string{{p.Dest0, p.Wait0}, {p.Dest1, p.Wait1}, {p.Dest2, p.Wait2}, {p.Dest3, p.Wait3}} {
+			wait, err := strconv.Atoi(d[1])
+			if d[0] == "" || err != nil {
+				continue
+			}
+			departures[p.TLAREF] = append(departures[p.TLAREF], BusArrival{
+				Line:        "Metrolink",
+				Destination: d[0],
+				ArrivalTime: now.Add(time.Duration(wait) * time.Minute),
+			})
+		}
+	}
+	for code := range departures {
+		arr := departures[code]
+		sort.Slice(arr, func(i, j int) bool { return arr[i].ArrivalTime.Before(arr[j].ArrivalTime) })
+	}
+	return departures, names
+}
+
+// fetchMetrolink stores departures for the tram stops nearest a location
+func fetchMetrolink(lat, lon float64) ([]*Entity, error) {
+	if TfGMAPIKey == "" {
+		return nil, nil // No key, no trams
+	}
+	platforms, stops, err := metrolinkNetwork()
+	now := time.Now()
+	departures, names := metrolinkDepartures(platforms, now)
+
+	type nearStop struct {
+		code, name string
+		lat, lon   float64
+		dist       float64
+	}
+	var near []nearStop
+	for code, name := range names {
+		loc, ok := stops[metrolinkName(name)]
+		if !ok || len(departures[code]) == 0 {
+			continue
+		}
+		if d := haversineMeters(lat, lon, loc.lat, loc.lon); d <= metrolinkRadius {
+			near = append(near, nearStop{code, name, loc.lat, loc.lon, d})
+		}
+	}
+	sort.Slice(near, func(i, j int) bool { return near[i].dist < near[j].dist })
+	if len(near) > metrolinkMaxStops {
+		near = near[:metrolinkMaxStops]
+	}
+
+	db := Get()
+	var entities []*Entity
+	expiry := now.Add(arrivalTTL)
+	for _, s := range near {
+		entity := &Entity{
+			ID:   GenerateID(EntityArrival, s.lat, s.lon, "metrolink:"+s.code),
+			Type: EntityArrival,
+			Name: "🚊 " + s.name,
+			Lat:  s.lat,
+			Lon:  s.lon,
+			Data: &ArrivalData{
+				StopID:   s.code,
+				StopName: s.name,
+				StopType: "MetrolinkStop",
+				Arrivals: departures[s.code],
+			},
+			ExpiresAt: &expiry,
+		}
+		db.Insert(entity)
+		entities = append(entities, entity)
+	}
+	return entities, err
+}
@@ -0,0 +1,26 @@
+package spatial
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMetrolinkName checks stop names match however they're written
+func TestMetrolinkName(t *testing.T) {
+	if a, b := metrolinkName("St Peter's Square"), metrolinkName("St. Peters Square Metrolink"); a != b || a != "stpeterssquare" {
+		t.Errorf("metrolink names %q %q", a, b)
+	}
+}
+
+// TestMetrolinkDepartures checks platform boards merge into one stop's
+// departures, soonest first
+func TestMetrolinkDepartures(t *testing.T) {
+	departures, names := metrolinkDepartures([]metrolinkPlatform{
+		{TLAREF: "SPS", StationLocation: "St Peter's Square", Dest0: "Altrincham", Wait0: "7", Dest1: "Bury", Wait1: "2"},
+		{TLAREF: "SPS", StationLocation: "St Peter's Square", Dest0: "Eccles", Wait0: "4", Dest1: "", Wait1: ""},
+	}, time.Now())
+	got := departures["SPS"]
+	if names["SPS"] != "St Peter's Square" || len(got) != 3 || got[0].Destination != "Bury" || got[2].Destination != "Altrincham" {
+		t.Errorf("metrolink departures: %+v", got)
+	}
+}
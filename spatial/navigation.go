@@ -0,0 +1,204 @@
+package spatial
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Live navigation. Directions asked for in a session start a navigation that
+// follows the user's pings: the next instruction is pushed as they approach
+// each maneuver, and they're rerouted when they wander off the route.
+
+const (
+	NavOffRouteDistance = 40.0 // Metres from the route before a ping counts as off route
+	navOffRoutePings    = 2    // Consecutive off-route pings before rerouting, GPS jumps about
+	navRerouteInterval  = 30 * time.Second
+	navArriveRadius     = 30.0
+	navApproachTime     = 20.0 // Seconds of travel before a maneuver to announce it
+	navApproachMin      = 30.0 // Metres
+	navTTL              = 3 * time.Hour
+)
+
+// Navigation is a session following a route
+type Navigation struct {
+	Route     *Route
+	Profile   *Profile
+	DestName  string
+	ToLat     float64
+	ToLon     float64
+	Next      int // Index of the next step to announce
+	Reroutes  int
+	StartedAt time.Time
+
+	mu           sync.Mutex
+	offRoute     int
+	rerouteAt    time.Time
+	offRouteSent bool
+}
+
+var (
+	navSessions   = make(map[string]*Navigation)
+	navSessionsMu sync.Mutex
+
+	// rerouteFunc plans the new route, replaced in tests
+	rerouteFunc = GetDirections
+)
+
+// StartNavigation starts following a route for a session, replacing any current one
+// The departure step has already been shown with the directions, so the first
+// announcement is the maneuver after it
+func StartNavigation(session string, route *Route, destName string, toLat, toLon float64) *Navigation {
+	if session == "" || route == nil || len(route.Geometry) == 0 {
+		return nil
+	}
+	nav := &Navigation{
+		Route:     route,
+		Profile:   route.mode(),
+		DestName:  destName,
+		ToLat:     toLat,
+		ToLon:     toLon,
+		Next:      1,
+		StartedAt: time.Now(),
+	}
+	navSessionsMu.Lock()
+	navSessions[session] = nav
+	navSessionsMu.Unlock()
+	log.Printf("[nav] %s navigating to %s, %d steps", shortSession(session), destName, len(route.Steps))
+	return nav
+}
+
+// StopNavigation ends a session's navigation, reporting whether there was one
+func StopNavigation(session string) bool {
+	navSessionsMu.Lock()
+	defer navSessionsMu.Unlock()
+	_, ok := navSessions[session]
+	delete(navSessions, session)
+	return ok
+}
+
+// GetNavigation returns a session's navigation, nil if not navigating
+func GetNavigation(session string) *Navigation {
+	navSessionsMu.Lock()
+	defer navSessionsMu.Unlock()
+	return navSessions[session]
+}
+
+// UpdateNavigation moves a session's navigation on to a new location
+// Returns messages to push: the next instruction, a reroute or arrival
+func UpdateNavigation(session string, lat, lon float64) []string {
+	nav := GetNavigation(session)
+	if nav == nil {
+		return nil
+	}
+
+	// Rerouting can be slow, so only this navigation is locked meanwhile
+	nav.mu.Lock()
+	var messages []string
+	done := time.Since(nav.StartedAt) > navTTL
+	if !done {
+		messages, done = nav.update(lat, lon, time.Now())
+	}
+	nav.mu.Unlock()
+
+	if done {
+		navSessionsMu.Lock()
+		if navSessions[session] == nav {
+			delete(navSessions, session)
+		}
+		navSessionsMu.Unlock()
+		log.Printf("[nav] %s finished navigating to %s", shortSession(session), nav.DestName)
+	}
+	return messages
+}
+
+// update advances the navigation, done once the destination is reached
+func (n *Navigation) update(lat, lon float64, now time.Time) ([]string, bool) {
+	if haversineMeters(lat, lon, n.ToLat, n.ToLon) < navArriveRadius {
+		return []string{fmt.Sprintf("🏁 You've arrived at %s", n.DestName)}, true
+	}
+
+	// Off route: reroute once it's clearly not GPS noise
+	if pointToPolylineMeters(lat, lon, n.Route.Geometry) > NavOffRouteDistance {
+		n.offRoute++
+		if n.offRoute < navOffRoutePings || now.Sub(n.rerouteAt) < navRerouteInterval {
+			return nil, false
+		}
+		n.rerouteAt = now
+		route, err := rerouteFunc(lat, lon, n.ToLat, n.ToLon, n.Profile)
+		if err != nil {
+			log.Printf("[nav] Reroute to %s failed: %v", n.DestName, err)
+			if n.offRouteSent {
+				return nil, false
+			}
+			n.offRouteSent = true
+			return []string{fmt.Sprintf("⚠️ You're off route to %s and I couldn't find a new one", n.DestName)}, false
+		}
+		n.Route, n.Next, n.offRoute, n.offRouteSent = route, 1, 0, false
+		n.Reroutes++
+		msg := fmt.Sprintf("🔀 Off route, rerouting to %s\n%s", n.DestName, route.Summary)
+		if len(route.Steps) > 0 {
+			msg += "\n" + route.Steps[0].Instruction
+		}
+		return []string{msg}, false
+	}
+	n.offRoute = 0
+
+	// Announce the next maneuver once it's close. Maneuvers already behind
+	// (announcements missed between pings) are skipped rather than replayed
+	approach := n.Profile.Speed * navApproachTime
+	if approach < navApproachMin {
+		approach = navApproachMin
+	}
+	for i := len(n.Route.Steps) - 1; i >= n.Next; i-- {
+		step := n.Route.Steps[i]
+		d := haversineMeters(lat, lon, step.Lat, step.Lon)
+		if d > approach {
+			continue
+		}
+		n.Next = i + 1
+		if d < 10 {
+			return []string{step.Instruction}, false
+		}
+		return []string{fmt.Sprintf("In %s: %s", FormatDistance(roundTo(d, 10)), step.Instruction)}, false
+	}
+	return nil, false
+}
+
+// Remaining returns the distance left to the destination, along the route
+func (n *Navigation) Remaining(lat, lon float64) float64 {
+	dist := 0.0
+	for _, step := range n.Route.Steps[min(n.Next, len(n.Route.Steps)):] {
+		dist += step.Distance
+	}
+	if n.Next < len(n.Route.Steps) {
+		next := n.Route.Steps[n.Next]
+		return dist + haversineMeters(lat, lon, next.Lat, next.Lon)
+	}
+	return haversineMeters(lat, lon, n.ToLat, n.ToLon)
+}
+
+// FormatNavigation describes where a navigation is up to
+func FormatNavigation(n *Navigation, lat, lon float64) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	remaining := n.Remaining(lat, lon)
+	mins := int(remaining / n.Profile.Speed / 60)
+	s := fmt.Sprintf("%s To %s · %s · %d min left", n.Profile.Icon, n.DestName, FormatDistance(remaining), mins)
+	if n.Next < len(n.Route.Steps) {
+		s += "\nNext: " + n.Route.Steps[n.Next].Instruction
+	}
+	return s
+}
+
+func roundTo(v, step float64) float64 {
+	return float64(int(v/step+0.5)) * step
+}
+
+func shortSession(session string) string {
+	if len(session) > 8 {
+		return session[:8]
+	}
+	return session
+}
@@ -0,0 +1,78 @@
+package spatial
+
+import (
+	"strings"
+	"testing"
+)
+
+// testNavigation follows High Street north and right onto Church Road to
+// St Mary's; reroutes from halfway up High Street are counted in rerouted
+func testNavigation(t *testing.T, session string) (rerouted *int) {
+	g := NewStreetGraph()
+	g.AddStreet([][]float64{{-0.1, 51.5}, {-0.1, 51.5027}}, "High Street")
+	g.AddStreet([][]float64{{-0.1, 51.5027}, {-0.0971, 51.5027}}, "Church Road")
+	route, err := g.Route(51.5, -0.1, 51.5027, -0.0971, ProfileFoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rerouted = new(int)
+	rerouteFunc = func(fromLat, fromLon, toLat, toLon float64, p *Profile) (*Route, error) {
+		*rerouted++
+		return g.Route(51.5013, -0.1, toLat, toLon, p)
+	}
+	t.Cleanup(func() { rerouteFunc = GetDirections })
+
+	if StartNavigation(session, route, "St Mary's", 51.5027, -0.0971) == nil {
+		t.Fatal("navigation not started")
+	}
+	t.Cleanup(func() { StopNavigation(session) })
+	return rerouted
+}
+
+// TestNavigationTurn checks a turn is announced on approach, not before
+func TestNavigationTurn(t *testing.T) {
+	session := "nav-test-turn"
+	testNavigation(t, session)
+	if msgs := UpdateNavigation(session, 51.5013, -0.1); len(msgs) != 0 {
+		t.Errorf("midway: %v", msgs)
+	}
+	if msgs := UpdateNavigation(session, 51.50252, -0.1); len(msgs) != 1 || msgs[0] != "In 20 m: ➡️ Turn right onto Church Road" {
+		t.Errorf("approach: %v", msgs)
+	}
+}
+
+// TestNavigationTurnOnce checks a turn is announced once only
+func TestNavigationTurnOnce(t *testing.T) {
+	session := "nav-test-once"
+	testNavigation(t, session)
+	UpdateNavigation(session, 51.50252, -0.1)
+	if msgs := UpdateNavigation(session, 51.5027, -0.1); len(msgs) != 0 {
+		t.Errorf("repeat: %v", msgs)
+	}
+}
+
+// TestNavigationReroute checks one stray ping is GPS noise and two are off route
+func TestNavigationReroute(t *testing.T) {
+	session := "nav-test-reroute"
+	rerouted := testNavigation(t, session)
+	if msgs := UpdateNavigation(session, 51.5015, -0.0985); len(msgs) != 0 || *rerouted != 0 {
+		t.Errorf("first off-route ping: %v", msgs)
+	}
+	msgs := UpdateNavigation(session, 51.5015, -0.0985)
+	if *rerouted != 1 || len(msgs) != 1 || !strings.HasPrefix(msgs[0], "🔀 Off route, rerouting to St Mary's") {
+		t.Errorf("reroute: %d, %v", *rerouted, msgs)
+	}
+}
+
+// TestNavigationArrive checks navigation ends on arrival
+func TestNavigationArrive(t *testing.T) {
+	session := "nav-test-arrive"
+	testNavigation(t, session)
+	if msgs := UpdateNavigation(session, 51.5027, -0.0972); len(msgs) != 1 || msgs[0] != "🏁 You've arrived at St Mary's" {
+		t.Errorf("arrive: %v", msgs)
+	}
+	if GetNavigation(session) != nil {
+		t.Error("navigation should end on arrival")
+	}
+}
@@ -0,0 +1,518 @@
+package spatial
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	newsItemTTL     = 24 * time.Hour // How long a headline stays around
+	localNewsRadius = 15000.0        // Geotagged items within 15km count as local
+)
+
+// NewsFeed is an RSS or Atom feed, optionally bound to an area
+// A feed with no region or point binding is national
+type NewsFeed struct {
+	Name     string  `json:"name"`
+	URL      string  `json:"url"`
+	Region   string  `json:"region,omitempty"`    // Known region name (see regions.go)
+	Lat      float64 `json:"lat,omitempty"`       // Or a point binding for local papers
+	Lon      float64 `json:"lon,omitempty"`       //
+	RadiusKm float64 `json:"radius_km,omitempty"` // Radius of the point binding
+}
+
+type newsFeedConfig struct {
+	Updated string     `json:"updated"`
+	Feeds   []NewsFeed `json:"feeds"`
+}
+
+var (
+	newsFeeds     = []NewsFeed{{Name: "BBC UK", URL: bbcUKRSS}}
+	newsFetchMu   sync.Mutex
+	newsFetchedAt = make(map[string]time.Time) // Feed URL -> last fetch
+)
+
+func init() {
+	loadNewsFeeds()
+}
+
+func loadNewsFeeds() {
+	// Find data directory relative to source file
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		return
+	}
+	feedFile := filepath.Join(filepath.Dir(filename), "..", "data", "news_feeds.json")
+
+	data, err := os.ReadFile(feedFile)
+	if err != nil {
+		log.Printf("[news] Could not load news_feeds.json, using BBC UK only: %v", err)
+		return
+	}
+
+	var cfg newsFeedConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		log.Printf("[news] Could not parse news_feeds.json: %v", err)
+		return
+	}
+	if len(cfg.Feeds) > 0 {
+		newsFeeds = cfg.Feeds
+	}
+	log.Printf("[news] Loaded %d feeds (updated %s)", len(newsFeeds), cfg.Updated)
+}
+
+// IsNational returns true if the feed is not bound to an area
+func (f NewsFeed) IsNational() bool {
+	return f.Region == "" && f.RadiusKm == 0
+}
+
+// Covers returns true if the feed is relevant at the given location
+func (f NewsFeed) Covers(lat, lon float64) bool {
+	switch {
+	case f.Region != "":
+		r := GetRegion(lat, lon)
+		return r != nil && r.Name == f.Region
+	case f.RadiusKm > 0:
+		return haversine(lat, lon, f.Lat, f.Lon) <= f.RadiusKm
+	default:
+		return true
+	}
+}
+
+// bounds returns the centre and radius (meters) of the feed's area
+func (f NewsFeed) bounds() (lat, lon, radius float64, ok bool) {
+	if f.Region != "" {
+		for _, r := range regions {
+			if r.Name == f.Region {
+				lat, lon = (r.MinLat+r.MaxLat)/2, (r.MinLon+r.MaxLon)/2
+				return lat, lon, haversineMeters(lat, lon, r.MaxLat, r.MaxLon), true
+			}
+		}
+		return 0, 0, 0, false
+	}
+	if f.RadiusKm > 0 {
+		return f.Lat, f.Lon, f.RadiusKm * 1000, true
+	}
+	return 0, 0, 0, false
+}
+
+// fetchNewsFeeds refreshes every feed covering the location that is due
+// National feeds are always covered. Returns the number of new items stored
+// and the last feed error, if any
+func fetchNewsFeeds(lat, lon float64) (int, error) {
+	var due []NewsFeed
+	newsFetchMu.Lock()
+	for _, f := range newsFeeds {
+		if !f.Covers(lat, lon) || time.Since(newsFetchedAt[f.URL]) < newsTTL {
+			continue
+		}
+		newsFetchedAt[f.URL] = time.Now()
+		due = append(due, f)
+	}
+	newsFetchMu.Unlock()
+
+	var added int
+	var lastErr error
+	for _, f := range due {
+		items, err := fetchNewsFeed(f)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", f.Name, err)
+		}
+		added += len(items)
+	}
+	return added, lastErr
+}
+
+// fetchNewsFeed fetches one feed and stores items not seen before
+func fetchNewsFeed(feed NewsFeed) ([]*Entity, error) {
+	resp, err := NewsGet(feed.URL)
+	if err != nil {
+		log.Printf("[news] %s fetch error: %v", feed.Name, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		log.Printf("[news] %s returned status %d", feed.Name, resp.StatusCode)
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := parseNewsFeed(body)
+	if err != nil {
+		log.Printf("[news] %s parse error: %v", feed.Name, err)
+		return nil, err
+	}
+
+	db := Get()
+	places := newsGazetteer(feed)
+	centerLat, centerLon, _, bound := feed.bounds()
+	expiry := time.Now().Add(newsItemTTL)
+
+	var entities []*Entity
+	for _, item := range items {
+		if item.Link == "" || item.Title == "" {
+			continue
+		}
+
+		// Dedup by link - the same story often appears in several feeds
+		id := GenerateID(EntityNews, 0, 0, item.Link)
+		if db.GetByID(id) != nil {
+			continue
+		}
+
+		nd := &NewsData{
+			Source:    feed.Name,
+			Link:      item.Link,
+			Summary:   item.Summary,
+			Published: item.Published,
+			Region:    feed.Region,
+		}
+
+		// Geotag to a known place, else the feed's area, else national (0,0)
+		var lat, lon float64
+		if p := matchPlace(item.Title+" "+item.Summary, places); p != nil {
+			nd.Place = p.name
+			lat, lon = p.lat, p.lon
+		} else if bound {
+			lat, lon = centerLat, centerLon
+		}
+
+		entity := &Entity{
+			ID:        id,
+			Type:      EntityNews,
+			Name:      "📰 " + item.Title,
+			Lat:       lat,
+			Lon:       lon,
+			Data:      nd,
+			ExpiresAt: &expiry,
+		}
+		db.Insert(entity)
+		entities = append(entities, entity)
+	}
+
+	log.Printf("[news] %s: %d new of %d items", feed.Name, len(entities), len(items))
+	return entities, nil
+}
+
+// newsItem is a parsed feed entry
+type newsItem struct {
+	Title     string
+	Link      string
+	Summary   string
+	Published *time.Time
+}
+
+// rawNewsFeed decodes both RSS (<rss><channel><item>) and Atom (<feed><entry>)
+type rawNewsFeed struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// parseNewsFeed parses an RSS or Atom document into items
+func parseNewsFeed(body []byte) ([]newsItem, error) {
+	var raw rawNewsFeed
+	if err := xml.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+
+	var items []newsItem
+	for _, it := range raw.Channel.Items {
+		items = append(items, newsItem{
+			Title:     cleanNewsText(it.Title),
+			Link:      cleanNewsLink(it.Link),
+			Summary:   cleanNewsText(it.Description),
+			Published: parseNewsTime(it.PubDate),
+		})
+	}
+	for _, e := range raw.Entries {
+		var link string
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		summary := e.Summary
+		if summary == "" {
+			summary = e.Content
+		}
+		published := e.Published
+		if published == "" {
+			published = e.Updated
+		}
+		items = append(items, newsItem{
+			Title:     cleanNewsText(e.Title),
+			Link:      cleanNewsLink(link),
+			Summary:   cleanNewsText(summary),
+			Published: parseNewsTime(published),
+		})
+	}
+	return items, nil
+}
+
+func cleanNewsText(s string) string {
+	s = html.UnescapeString(htmlTagRe.ReplaceAllString(s, ""))
+	s = strings.Join(strings.Fields(s), " ")
+	return truncate(s, 200)
+}
+
+// cleanNewsLink removes tracking params so the same story dedups across feeds.
+// Other params stay: CMS links like news.php?id=123 are one story per id
+func cleanNewsLink(link string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil || u.RawQuery == "" {
+		return link
+	}
+	query := u.Query()
+	tracked := false
+	for key := range query {
+		if newsTrackingParam(key) {
+			query.Del(key)
+			tracked = true
+		}
+	}
+	if !tracked {
+		return link
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// newsTrackingParam reports whether a query param only tracks the click
+func newsTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasPrefix(key, "utm_") || strings.HasPrefix(key, "at_") ||
+		key == "fbclid" || key == "gclid"
+}
+
+func parseNewsTime(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// gazetteerEntry is a place name news items can be geotagged to
+type gazetteerEntry struct {
+	name     string
+	lat, lon float64
+}
+
+// newsGazetteer builds the place names a feed's items can be tagged with
+// Area names come from agents; bound feeds also match street names in their area
+func newsGazetteer(feed NewsFeed) []gazetteerEntry {
+	db := Get()
+	centerLat, centerLon, radius, bound := feed.bounds()
+	seen := make(map[string]bool)
+	var places []gazetteerEntry
+
+	add := func(name string, lat, lon float64) {
+		name = strings.TrimSpace(name)
+		if len(name) < 4 || seen[name] {
+			return
+		}
+		seen[name] = true
+		places = append(places, gazetteerEntry{name: name, lat: lat, lon: lon})
+	}
+
+	for _, a := range db.ListAgents() {
+		if bound && haversineMeters(centerLat, centerLon, a.Lat, a.Lon) > radius {
+			continue
+		}
+		// "Hampton, London" -> "Hampton"
+		add(strings.Split(a.Name, ",")[0], a.Lat, a.Lon)
+	}
+
+	if bound {
+		for _, s := range db.Query(centerLat, centerLon, radius, EntityStreet, 5000) {
+			// Single word street names are too ambiguous
+			if len(strings.Fields(s.Name)) >= 2 {
+				add(s.Name, s.Lat, s.Lon)
+			}
+		}
+	}
+
+	// Longest first so "Hampton Hill" wins over "Hampton"
+	sort.Slice(places, func(i, j int) bool {
+		return len(places[i].name) > len(places[j].name)
+	})
+	return places
+}
+
+// matchPlace returns the first gazetteer place mentioned as a whole word in text
+func matchPlace(text string, places []gazetteerEntry) *gazetteerEntry {
+	for i := range places {
+		name := places[i].name
+		for start := 0; ; {
+			idx := strings.Index(text[start:], name)
+			if idx < 0 {
+				break
+			}
+			idx += start
+			end := idx + len(name)
+			if isWordBoundary(text, idx-1) && isWordBoundary(text, end) {
+				return &places[i]
+			}
+			start = end
+		}
+	}
+	return nil
+}
+
+func isWordBoundary(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+	c := s[i]
+	return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
+
+// GetLocalNews returns headlines relevant to a location, most local first
+// Items geotagged nearby come first, then items from feeds covering the area
+func GetLocalNews(lat, lon float64, limit int) []*Entity {
+	fetchNewsFeeds(lat, lon)
+	db := Get()
+
+	seen := make(map[string]bool)
+	var tagged, regional []*Entity
+
+	for _, e := range db.Query(lat, lon, localNewsRadius, EntityNews, 200) {
+		if nd := e.GetNewsData(); nd != nil && nd.Place != "" {
+			seen[e.ID] = true
+			tagged = append(tagged, e)
+		}
+	}
+
+	for _, f := range newsFeeds {
+		if f.IsNational() || !f.Covers(lat, lon) {
+			continue
+		}
+		centerLat, centerLon, _, ok := f.bounds()
+		if !ok {
+			continue
+		}
+		// Untagged items sit at the feed centre
+		for _, e := range db.Query(centerLat, centerLon, 100, EntityNews, 200) {
+			nd := e.GetNewsData()
+			if nd == nil || nd.Source != f.Name || seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			regional = append(regional, e)
+		}
+	}
+
+	sortNewsByDate(tagged)
+	sortNewsByDate(regional)
+	result := append(tagged, regional...)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// GetNationalNews returns the latest national headlines
+func GetNationalNews(limit int) []*Entity {
+	fetchNewsFeeds(0, 0)
+	news := Get().Query(0, 0, 1000, EntityNews, 500)
+	sortNewsByDate(news)
+	if len(news) > limit {
+		news = news[:limit]
+	}
+	return news
+}
+
+// SearchNews returns headlines mentioning the query, national and near the location
+func SearchNews(query string, lat, lon float64, limit int) []*Entity {
+	query = strings.ToLower(strings.TrimSpace(query))
+	candidates := GetNationalNews(500)
+	if lat != 0 || lon != 0 {
+		candidates = append(candidates, GetLocalNews(lat, lon, 500)...)
+	}
+
+	seen := make(map[string]bool)
+	var result []*Entity
+	for _, e := range candidates {
+		if seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+		text := strings.ToLower(e.Name)
+		if nd := e.GetNewsData(); nd != nil {
+			text += " " + strings.ToLower(nd.Summary)
+		}
+		if strings.Contains(text, query) {
+			result = append(result, e)
+		}
+	}
+	sortNewsByDate(result)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// GetBreakingNews returns the top national headline with its link
+func GetBreakingNews() string {
+	news := GetNationalNews(1)
+	if len(news) == 0 {
+		return ""
+	}
+	if nd := news[0].GetNewsData(); nd != nil && nd.Link != "" {
+		return news[0].Name + "\n" + nd.Link
+	}
+	return news[0].Name
+}
+
+func sortNewsByDate(news []*Entity) {
+	published := func(e *Entity) time.Time {
+		if nd := e.GetNewsData(); nd != nil && nd.Published != nil {
+			return *nd.Published
+		}
+		return e.CreatedAt
+	}
+	sort.SliceStable(news, func(i, j int) bool {
+		return published(news[i]).After(published(news[j]))
+	})
+}
@@ -0,0 +1,88 @@
+package spatial
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestParseRSS checks RSS items parse with their links and summaries cleaned
+func TestParseRSS(t *testing.T) {
+	rss := []byte(`<rss><channel>
+		<item><title>Flooding closes Hampton Hill High Street</title>
+		<link>https://example.com/a?at_medium=RSS</link>
+		<description>&lt;p&gt;Council warns residents&lt;/p&gt;</description>
+		<pubDate>Sat, 17 Oct 2026 09:00:00 +0100</pubDate></item>
+	</channel></rss>`)
+
+	items, err := parseNewsFeed(rss)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("RSS parse: items=%d err=%v", len(items), err)
+	}
+	if items[0].Link != "https://example.com/a" || items[0].Summary != "Council warns residents" || items[0].Published == nil {
+		t.Errorf("RSS item not cleaned: %+v", items[0])
+	}
+}
+
+// TestParseAtom checks Atom entries parse with their alternate link
+func TestParseAtom(t *testing.T) {
+	atom := []byte(`<feed><entry><title>Market returns to Kingston</title>
+		<link rel="alternate" href="https://example.com/b"/>
+		<summary>Weekly stalls</summary>
+		<updated>2026-10-17T10:00:00Z</updated></entry></feed>`)
+
+	items, err := parseNewsFeed(atom)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Atom parse: items=%d err=%v", len(items), err)
+	}
+	if items[0].Link != "https://example.com/b" || items[0].Published == nil {
+		t.Errorf("Atom item not parsed: %+v", items[0])
+	}
+}
+
+// TestCleanNewsLink checks only tracking params come off links, so stories
+// told apart by their query string stay apart
+func TestCleanNewsLink(t *testing.T) {
+	for link, want := range map[string]string{
+		"https://council.gov.uk/news.php?id=123&utm_source=rss": "https://council.gov.uk/news.php?id=123",
+		"https://council.gov.uk/news.php?id=124":                "https://council.gov.uk/news.php?id=124",
+		"https://example.com/c?at_campaign=x&at_medium=RSS":     "https://example.com/c",
+	} {
+		if got := cleanNewsLink(link); got != want {
+			t.Errorf("cleanNewsLink(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+// TestCleanNewsText checks long summaries are cut between characters
+func TestCleanNewsText(t *testing.T) {
+	if got := cleanNewsText(strings.Repeat("“quote” ", 40)); !utf8.ValidString(got) {
+		t.Errorf("summary cut inside a character: %q", got)
+	}
+}
+
+// TestMatchPlace checks news text geotags to the longest whole place name
+func TestMatchPlace(t *testing.T) {
+	places := []gazetteerEntry{
+		{name: "Hampton Hill", lat: 51.428, lon: -0.355},
+		{name: "Hampton", lat: 51.416, lon: -0.368},
+	}
+	testCases := []struct {
+		text string
+		want string
+	}{
+		{"Flooding closes Hampton Hill High Street", "Hampton Hill"},
+		{"Hampton station reopens", "Hampton"},
+		{"Hamptons holiday homes", ""},
+		{"Nothing local here", ""},
+	}
+	for _, tc := range testCases {
+		got := ""
+		if p := matchPlace(tc.text, places); p != nil {
+			got = p.name
+		}
+		if got != tc.want {
+			t.Errorf("matchPlace(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
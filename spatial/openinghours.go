@@ -0,0 +1,905 @@
+package spatial
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpeningHours is a parsed OSM opening_hours value
+// See https://wiki.openstreetmap.org/wiki/Key:opening_hours/specification
+//
+// Supported: rule sequences (";", "," additional, "||" fallback), 24/7,
+// years, months and dates ("Dec 24-26", "Nov-Mar"), ISO weeks, weekday
+// ranges with nth-of-month ("Mo[1]"), PH (UK bank holidays), SH (never
+// matches), time spans past midnight, open ends ("17:00+"), sunrise/sunset/
+// dawn/dusk with offsets, and open/closed/off/unknown modifiers.
+type OpeningHours struct {
+	Raw   string
+	rules []ohRule
+}
+
+const (
+	ohNormal = iota
+	ohAdditional
+	ohFallback
+)
+
+// ohTime is a time of day: minutes from midnight, or an offset from a sun event
+type ohTime struct {
+	event string // "", "sunrise", "sunset", "dawn", "dusk"
+	mins  int
+}
+
+type ohSpan struct {
+	start, end ohTime
+	openEnd    bool
+}
+
+type ohWeekday struct {
+	from, to time.Weekday
+	nth      []int // Occurrence in month, negative counts from the end
+}
+
+type ohDateRange struct {
+	fromMonth, fromDay int // Day 0 = whole month
+	toMonth, toDay     int
+}
+
+type ohRule struct {
+	kind     int
+	years    [][2]int
+	weeks    [][2]int
+	dates    []ohDateRange
+	weekdays []ohWeekday
+	holiday  bool // PH
+	school   bool // SH - school holidays, not known so never matches
+	spans    []ohSpan
+	closed   bool // off, closed or unknown
+}
+
+// interval is a resolved span in minutes from the day's midnight
+type interval struct{ start, end int }
+
+// Parsed values by raw string. Places share a few common values, so the
+// cache is simply emptied if the odd ones fill it past ohCacheMax
+const ohCacheMax = 5000
+
+var (
+	ohCache   = make(map[string]*OpeningHours)
+	ohCacheMu sync.RWMutex
+)
+
+// ParseOpeningHours parses an opening_hours value, caching by string
+func ParseOpeningHours(raw string) (*OpeningHours, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("empty opening_hours")
+	}
+
+	ohCacheMu.RLock()
+	oh, ok := ohCache[raw]
+	ohCacheMu.RUnlock()
+	if ok {
+		if oh == nil {
+			return nil, fmt.Errorf("unsupported opening_hours %q", raw)
+		}
+		return oh, nil
+	}
+
+	oh, err := parseOpeningHours(raw)
+	ohCacheMu.Lock()
+	if len(ohCache) >= ohCacheMax {
+		ohCache = make(map[string]*OpeningHours)
+	}
+	ohCache[raw] = oh // nil caches the failure too
+	ohCacheMu.Unlock()
+	return oh, err
+}
+
+var (
+	ohCommentRe = regexp.MustCompile(`"[^"]*"`)
+	ohParenRe   = regexp.MustCompile(`\(\s*([a-z]+)\s*([+-])\s*([0-9:]+)\s*\)`)
+	ohYearRe    = regexp.MustCompile(`^(\d{4})(?:-(\d{4}))?$`)
+	ohTimeRe    = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+)
+
+var ohWeekdays = map[string]time.Weekday{
+	"mo": time.Monday, "tu": time.Tuesday, "we": time.Wednesday, "th": time.Thursday,
+	"fr": time.Friday, "sa": time.Saturday, "su": time.Sunday,
+}
+
+var ohMonths = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+func parseOpeningHours(raw string) (*OpeningHours, error) {
+	text := ohCommentRe.ReplaceAllString(raw, "")
+	text = strings.ToLower(text)
+	// "(sunrise + 01:00)" -> "(sunrise+01:00)" so spans stay one token
+	text = ohParenRe.ReplaceAllString(text, "($1$2$3)")
+
+	oh := &OpeningHours{Raw: raw}
+	for g, group := range strings.Split(text, "||") {
+		for _, seq := range strings.Split(group, ";") {
+			for i, part := range splitAdditional(seq) {
+				part = strings.TrimSpace(part)
+				if part == "" {
+					continue
+				}
+				rule, err := parseOHRule(part)
+				if err != nil {
+					return nil, err
+				}
+				switch {
+				case i > 0:
+					rule.kind = ohAdditional
+				case g > 0:
+					rule.kind = ohFallback
+				default:
+					rule.kind = ohNormal
+				}
+				oh.rules = append(oh.rules, rule)
+			}
+		}
+	}
+	if len(oh.rules) == 0 {
+		return nil, fmt.Errorf("no rules in %q", raw)
+	}
+	return oh, nil
+}
+
+// splitAdditional splits a rule on commas that start an additional rule:
+// the text so far has a time or modifier and the next part starts with a selector
+// "Mo-Fr 08:00-12:00, Sa 09:00-11:00" splits, "Mo,We 10:00-12:00" doesn't
+func splitAdditional(seq string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(seq); i++ {
+		if seq[i] != ',' {
+			continue
+		}
+		before := strings.TrimSpace(seq[start:i])
+		after := strings.TrimSpace(seq[i+1:])
+		if ohHasTimeOrModifier(before) && ohStartsWithSelector(after) {
+			parts = append(parts, seq[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, seq[start:])
+}
+
+func ohHasTimeOrModifier(s string) bool {
+	if strings.Contains(s, ":") || strings.Contains(s, "24/7") {
+		return true
+	}
+	for _, m := range []string{"off", "closed", "open", "unknown", "sunrise", "sunset", "dawn", "dusk"} {
+		if strings.HasSuffix(s, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func ohStartsWithSelector(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	if _, ok := ohWeekdays[s[:2]]; ok {
+		return true
+	}
+	if strings.HasPrefix(s, "ph") || strings.HasPrefix(s, "sh") || strings.HasPrefix(s, "week") {
+		return true
+	}
+	if len(s) >= 3 {
+		if _, ok := ohMonths[s[:3]]; ok {
+			return true
+		}
+	}
+	return ohYearRe.MatchString(strings.Fields(s)[0])
+}
+
+func parseOHRule(text string) (ohRule, error) {
+	var rule ohRule
+	tokens := strings.Fields(text)
+
+	for i := 0; i < len(tokens); i++ {
+		tok := strings.TrimSuffix(tokens[i], ":")
+		switch {
+		case tok == "":
+			continue
+		case tok == "24/7":
+			rule.spans = append(rule.spans, ohSpan{start: ohTime{mins: 0}, end: ohTime{mins: 1440}})
+		case tok == "off" || tok == "closed" || tok == "unknown":
+			rule.closed = true
+		case tok == "open":
+			rule.closed = false
+		case tok == "week":
+			if i+1 >= len(tokens) {
+				return rule, fmt.Errorf("week without numbers")
+			}
+			i++
+			weeks, err := parseOHNumberRanges(tokens[i])
+			if err != nil {
+				return rule, err
+			}
+			rule.weeks = append(rule.weeks, weeks...)
+		case ohYearRe.MatchString(tok):
+			m := ohYearRe.FindStringSubmatch(tok)
+			from, _ := strconv.Atoi(m[1])
+			to := from
+			if m[2] != "" {
+				to, _ = strconv.Atoi(m[2])
+			}
+			rule.years = append(rule.years, [2]int{from, to})
+		case len(tok) >= 3 && ohMonths[tok[:3]] != 0:
+			// Dates can span tokens: "dec 24-26", "dec 24-jan 02"
+			sel := tok
+			for i+1 < len(tokens) && ohIsDateContinuation(tokens[i+1], sel) {
+				i++
+				sel += " " + strings.TrimSuffix(tokens[i], ":")
+			}
+			dates, err := parseOHDates(sel)
+			if err != nil {
+				return rule, err
+			}
+			rule.dates = append(rule.dates, dates...)
+		case strings.ContainsAny(tok, ":") || strings.Contains(tok, "sun") || strings.Contains(tok, "dawn") || strings.Contains(tok, "dusk"):
+			spans, err := parseOHSpans(tok)
+			if err != nil {
+				return rule, err
+			}
+			rule.spans = append(rule.spans, spans...)
+		default:
+			if err := parseOHWeekdays(tok, &rule); err != nil {
+				return rule, err
+			}
+		}
+	}
+	return rule, nil
+}
+
+// ohIsDateContinuation reports whether next continues a date selector
+func ohIsDateContinuation(next, sel string) bool {
+	next = strings.TrimSuffix(next, ":")
+	if next == "" || strings.Contains(next, ":") {
+		return false
+	}
+	if next[0] >= '0' && next[0] <= '9' && !ohYearRe.MatchString(next) {
+		return true
+	}
+	// "dec 24-" + "jan 02"
+	if strings.HasSuffix(sel, "-") && len(next) >= 3 && ohMonths[next[:3]] != 0 {
+		return true
+	}
+	return false
+}
+
+func parseOHNumberRanges(s string) ([][2]int, error) {
+	var out [][2]int
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", part)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("bad number %q", part)
+			}
+		}
+		out = append(out, [2]int{from, to})
+	}
+	return out, nil
+}
+
+// parseOHDates parses "jan-mar", "dec 25", "dec 24-26", "dec 24-jan 02", "jan,feb"
+func parseOHDates(sel string) ([]ohDateRange, error) {
+	var out []ohDateRange
+	for _, part := range strings.Split(sel, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var from, to string
+		if idx := strings.Index(part, "-"); idx >= 0 {
+			from, to = strings.TrimSpace(part[:idx]), strings.TrimSpace(part[idx+1:])
+		} else {
+			from, to = part, ""
+		}
+
+		fm, fd, err := parseOHMonthDay(from, 0)
+		if err != nil {
+			return nil, err
+		}
+		r := ohDateRange{fromMonth: fm, fromDay: fd, toMonth: fm, toDay: fd}
+		if to != "" {
+			// "dec 24-26" - bare day keeps the month
+			if n, err := strconv.Atoi(to); err == nil {
+				r.toDay = n
+			} else if r.toMonth, r.toDay, err = parseOHMonthDay(to, fm); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func parseOHMonthDay(s string, defaultMonth int) (int, int, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("empty date")
+	}
+	month := defaultMonth
+	if len(fields[0]) >= 3 && ohMonths[fields[0][:3]] != 0 {
+		month = ohMonths[fields[0][:3]]
+		fields = fields[1:]
+	}
+	if month == 0 {
+		return 0, 0, fmt.Errorf("bad date %q", s)
+	}
+	day := 0
+	if len(fields) > 0 {
+		d, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return 0, 0, fmt.Errorf("bad date %q", s)
+		}
+		day = d
+	}
+	return month, day, nil
+}
+
+// parseOHWeekdays parses "mo-fr", "sa,su,ph", "mo[1]", "fr[-1]"
+func parseOHWeekdays(tok string, rule *ohRule) error {
+	for _, part := range strings.Split(tok, ",") {
+		if part == "" {
+			continue
+		}
+		switch part {
+		case "ph":
+			rule.holiday = true
+			continue
+		case "sh":
+			rule.school = true
+			continue
+		}
+
+		var nth []int
+		if idx := strings.Index(part, "["); idx >= 0 {
+			end := strings.Index(part, "]")
+			if end < idx {
+				return fmt.Errorf("bad weekday %q", part)
+			}
+			for _, r := range strings.Split(part[idx+1:end], ",") {
+				n, err := strconv.Atoi(r)
+				if err != nil {
+					return fmt.Errorf("bad weekday %q", part)
+				}
+				nth = append(nth, n)
+			}
+			part = part[:idx]
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		from, ok := ohWeekdays[bounds[0]]
+		if !ok {
+			return fmt.Errorf("unknown selector %q", part)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, ok = ohWeekdays[bounds[1]]; !ok {
+				return fmt.Errorf("unknown selector %q", part)
+			}
+		}
+		rule.weekdays = append(rule.weekdays, ohWeekday{from: from, to: to, nth: nth})
+	}
+	return nil
+}
+
+// parseOHSpans parses "08:00-12:00,13:00-17:30", "22:00-02:00", "17:00+",
+// "sunrise-sunset", "(sunrise+01:00)-(sunset-00:30)"
+func parseOHSpans(tok string) ([]ohSpan, error) {
+	var out []ohSpan
+	for _, part := range strings.Split(tok, ",") {
+		if part == "" {
+			continue
+		}
+		var span ohSpan
+		if strings.HasSuffix(part, "+") {
+			span.openEnd = true
+			part = strings.TrimSuffix(part, "+")
+		}
+
+		from, to := part, ""
+		if idx := ohSpanDash(part); idx >= 0 {
+			from, to = part[:idx], part[idx+1:]
+		}
+
+		var err error
+		if span.start, err = parseOHTime(from); err != nil {
+			return nil, err
+		}
+		switch {
+		case to != "":
+			if span.end, err = parseOHTime(to); err != nil {
+				return nil, err
+			}
+		case span.openEnd:
+			// No closing time given - assume a few hours
+			span.end = ohTime{event: span.start.event, mins: span.start.mins + 4*60}
+		default:
+			return nil, fmt.Errorf("time without range %q", part)
+		}
+		out = append(out, span)
+	}
+	return out, nil
+}
+
+// ohSpanDash finds the dash separating a span, skipping dashes inside parentheses
+func ohSpanDash(s string) int {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '-':
+			if depth == 0 && i > 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseOHTime(s string) (ohTime, error) {
+	s = strings.TrimSpace(s)
+	if m := ohTimeRe.FindStringSubmatch(s); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		mm, _ := strconv.Atoi(m[2])
+		return ohTime{mins: h*60 + mm}, nil
+	}
+
+	s = strings.Trim(s, "()")
+	for _, event := range []string{"sunrise", "sunset", "dawn", "dusk"} {
+		if !strings.HasPrefix(s, event) {
+			continue
+		}
+		rest := s[len(event):]
+		if rest == "" {
+			return ohTime{event: event}, nil
+		}
+		sign := 1
+		if rest[0] == '-' {
+			sign = -1
+		} else if rest[0] != '+' {
+			break
+		}
+		m := ohTimeRe.FindStringSubmatch(rest[1:])
+		if m == nil {
+			break
+		}
+		h, _ := strconv.Atoi(m[1])
+		mm, _ := strconv.Atoi(m[2])
+		return ohTime{event: event, mins: sign * (h*60 + mm)}, nil
+	}
+	return ohTime{}, fmt.Errorf("bad time %q", s)
+}
+
+// =============================================================================
+// Evaluation
+// =============================================================================
+
+// matches reports whether the rule's selectors include the given local day
+func (r *ohRule) matches(day time.Time) bool {
+	if len(r.years) > 0 && !ohInRanges(day.Year(), r.years) {
+		return false
+	}
+	if len(r.weeks) > 0 {
+		_, week := day.ISOWeek()
+		if !ohInRanges(week, r.weeks) {
+			return false
+		}
+	}
+	if len(r.dates) > 0 {
+		md := int(day.Month())*100 + day.Day()
+		found := false
+		for _, d := range r.dates {
+			from := d.fromMonth*100 + max(d.fromDay, 1)
+			toDay := d.toDay
+			if toDay == 0 {
+				toDay = 31
+			}
+			to := d.toMonth*100 + toDay
+			if (from <= to && md >= from && md <= to) || (from > to && (md >= from || md <= to)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Weekdays and holidays are alternatives: "Mo-Fr,PH" matches either
+	if len(r.weekdays) == 0 && !r.holiday && !r.school {
+		return true
+	}
+	if r.holiday && isPublicHoliday(day) {
+		return true
+	}
+	for _, wd := range r.weekdays {
+		if wd.includes(day) {
+			return true
+		}
+	}
+	return false
+}
+
+func (wd ohWeekday) includes(day time.Time) bool {
+	w := day.Weekday()
+	inRange := false
+	if wd.from <= wd.to {
+		inRange = w >= wd.from && w <= wd.to
+	} else {
+		// Wraps the week: "fr-mo"
+		inRange = w >= wd.from || w <= wd.to
+	}
+	if !inRange {
+		return false
+	}
+	if len(wd.nth) == 0 {
+		return true
+	}
+	fromStart := (day.Day()-1)/7 + 1
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	fromEnd := -((daysInMonth-day.Day())/7 + 1)
+	for _, n := range wd.nth {
+		if n == fromStart || n == fromEnd {
+			return true
+		}
+	}
+	return false
+}
+
+func ohInRanges(v int, ranges [][2]int) bool {
+	for _, r := range ranges {
+		if v >= r[0] && v <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve turns the rule's spans into minute intervals for a day
+func (r *ohRule) resolve(day time.Time, lat, lon float64) []interval {
+	if len(r.spans) == 0 {
+		return []interval{{0, 1440}}
+	}
+	var out []interval
+	for _, s := range r.spans {
+		start := resolveOHTime(s.start, day, lat, lon)
+		end := resolveOHTime(s.end, day, lat, lon)
+		if end <= start {
+			end += 1440 // Runs past midnight
+		}
+		out = append(out, interval{start, end})
+	}
+	return out
+}
+
+func resolveOHTime(t ohTime, day time.Time, lat, lon float64) int {
+	if t.event == "" {
+		return t.mins
+	}
+	return sunEvent(t.event, day, lat, lon) + t.mins
+}
+
+// daySpans returns the open intervals starting on a day, in minutes from its midnight
+// Intervals may end after 1440 when they run past midnight
+func (oh *OpeningHours) daySpans(day time.Time, lat, lon float64) []interval {
+	var current []interval
+	matched := false
+	for _, r := range oh.rules {
+		if r.kind == ohFallback && matched {
+			continue
+		}
+		if !r.matches(day) {
+			continue
+		}
+		spans := r.resolve(day, lat, lon)
+
+		switch {
+		case r.kind == ohAdditional && r.closed:
+			current = subtractIntervals(current, spans)
+		case r.kind == ohAdditional:
+			current = mergeIntervals(append(current, spans...))
+		case r.closed && len(r.spans) > 0:
+			// "We 12:00-14:00 off" closes part of the day
+			current = subtractIntervals(current, spans)
+		case r.closed:
+			current = nil
+		default:
+			// A later normal rule replaces earlier ones for this day
+			current = mergeIntervals(spans)
+		}
+		matched = true
+	}
+	return current
+}
+
+// Intervals returns absolute open periods overlapping [from, to]
+func (oh *OpeningHours) Intervals(from, to time.Time, lat, lon float64) [][2]time.Time {
+	loc := from.Location()
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, -1)
+
+	var out [][2]time.Time
+	for !day.After(to) {
+		for _, iv := range oh.daySpans(day, lat, lon) {
+			start := time.Date(day.Year(), day.Month(), day.Day(), 0, iv.start, 0, 0, loc)
+			end := time.Date(day.Year(), day.Month(), day.Day(), 0, iv.end, 0, 0, loc)
+			if end.After(from) && start.Before(to) {
+				out = append(out, [2]time.Time{start, end})
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	// Merge touching periods across midnight ("Mo-Su 00:00-24:00")
+	sort.Slice(out, func(i, j int) bool { return out[i][0].Before(out[j][0]) })
+	var merged [][2]time.Time
+	for _, p := range out {
+		if n := len(merged); n > 0 && !p[0].After(merged[n-1][1]) {
+			if p[1].After(merged[n-1][1]) {
+				merged[n-1][1] = p[1]
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// IsOpen reports whether the place is open at t (in the place's timezone)
+func (oh *OpeningHours) IsOpen(t time.Time, lat, lon float64) bool {
+	for _, p := range oh.Intervals(t, t.Add(time.Minute), lat, lon) {
+		if !t.Before(p[0]) && t.Before(p[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextChange returns whether the place is open at t and when that next changes
+// ok is false if there's no change within a week (always open or always closed)
+func (oh *OpeningHours) NextChange(t time.Time, lat, lon float64) (open bool, change time.Time, ok bool) {
+	for _, p := range oh.Intervals(t, t.AddDate(0, 0, 8), lat, lon) {
+		if !t.Before(p[0]) && t.Before(p[1]) {
+			// Open - closes at the end of this period unless it runs all week
+			return true, p[1], p[1].Before(t.AddDate(0, 0, 7))
+		}
+		if p[0].After(t) {
+			return false, p[0], true
+		}
+	}
+	return false, time.Time{}, false
+}
+
+func mergeIntervals(ivs []interval) []interval {
+	if len(ivs) == 0 {
+		return nil
+	}
+	sorted := append([]interval(nil), ivs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+	out := []interval{sorted[0]}
+	for _, iv := range sorted[1:] {
+		last := &out[len(out)-1]
+		if iv.start <= last.end {
+			if iv.end > last.end {
+				last.end = iv.end
+			}
+			continue
+		}
+		out = append(out, iv)
+	}
+	return out
+}
+
+func subtractIntervals(ivs, remove []interval) []interval {
+	out := ivs
+	for _, r := range remove {
+		var next []interval
+		for _, iv := range out {
+			if r.end <= iv.start || r.start >= iv.end {
+				next = append(next, iv)
+				continue
+			}
+			if r.start > iv.start {
+				next = append(next, interval{iv.start, r.start})
+			}
+			if r.end < iv.end {
+				next = append(next, interval{r.end, iv.end})
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+// =============================================================================
+// Public holidays (England & Wales bank holidays)
+// =============================================================================
+
+// isPublicHoliday reports whether the local day is a bank holiday
+func isPublicHoliday(day time.Time) bool {
+	y, m, d := day.Date()
+	for _, h := range bankHolidays(y) {
+		if h.Month() == m && h.Day() == d {
+			return true
+		}
+	}
+	return false
+}
+
+// bankHolidays returns England & Wales bank holidays with weekend substitutes
+func bankHolidays(year int) []time.Time {
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(year, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	// Weekend fixed holidays move to the next weekday
+	substitute := func(t time.Time) time.Time {
+		for t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+	firstMonday := func(m time.Month) time.Time {
+		t := date(m, 1)
+		for t.Weekday() != time.Monday {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+	lastMonday := func(m time.Month) time.Time {
+		t := date(m+1, 0)
+		for t.Weekday() != time.Monday {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t
+	}
+
+	easter := easterSunday(year)
+	christmas := substitute(date(time.December, 25))
+	boxing := date(time.December, 26)
+	if boxing.Weekday() == time.Saturday || boxing.Weekday() == time.Sunday || !boxing.After(christmas) {
+		boxing = substitute(christmas.AddDate(0, 0, 1))
+	}
+
+	return []time.Time{
+		substitute(date(time.January, 1)),
+		easter.AddDate(0, 0, -2), // Good Friday
+		easter.AddDate(0, 0, 1),  // Easter Monday
+		firstMonday(time.May),
+		lastMonday(time.May),
+		lastMonday(time.August),
+		christmas,
+		boxing,
+	}
+}
+
+// easterSunday computes Easter using the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// =============================================================================
+// Place open state
+// =============================================================================
+
+// OpenState is a place's opening status at a moment
+type OpenState struct {
+	Known    bool      // opening_hours parsed
+	Open     bool      // open right now
+	ClosesAt time.Time // zero if open all week or closed
+	OpensAt  time.Time // zero if open or no opening within a week
+}
+
+// ClosingWithin reports whether an open place closes within d of now
+func (s OpenState) ClosingWithin(now time.Time, d time.Duration) bool {
+	return s.Known && s.Open && !s.ClosesAt.IsZero() && s.ClosesAt.Sub(now) <= d
+}
+
+// Label returns a short status like "🟢 Open · closes 22:00"
+func (s OpenState) Label() string {
+	switch {
+	case !s.Known:
+		return ""
+	case s.Open && s.ClosesAt.IsZero():
+		return "🟢 Open 24h"
+	case s.Open:
+		return "🟢 Open · closes " + s.ClosesAt.Format("15:04")
+	case !s.OpensAt.IsZero():
+		return "🔴 Closed · opens " + ohDayLabel(s.OpensAt)
+	default:
+		return "🔴 Closed"
+	}
+}
+
+// ohDayLabel formats an opening time, adding the weekday if not today
+func ohDayLabel(t time.Time) string {
+	now := time.Now().In(t.Location())
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format("15:04")
+	}
+	return t.Format("Mon 15:04")
+}
+
+// PlaceTags returns an entity's OSM tags from typed data or the legacy map
+func PlaceTags(e *Entity) map[string]string {
+	if placeData := e.GetPlaceData(); placeData != nil {
+		return placeData.Tags
+	}
+	m, ok := e.Data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	tagsRaw, ok := m["tags"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	tags := make(map[string]string)
+	for k, v := range tagsRaw {
+		if s, ok := v.(string); ok {
+			tags[k] = s
+		}
+	}
+	return tags
+}
+
+// HoursOpenState evaluates an opening_hours value at now for a location
+func HoursOpenState(hours string, lat, lon float64, now time.Time) OpenState {
+	oh, err := ParseOpeningHours(hours)
+	if err != nil {
+		return OpenState{}
+	}
+	local := LocalTime(now, lat, lon)
+	open, change, ok := oh.NextChange(local, lat, lon)
+	state := OpenState{Known: true, Open: open}
+	if ok && open {
+		state.ClosesAt = change
+	} else if ok {
+		state.OpensAt = change
+	}
+	return state
+}
+
+// PlaceOpenState evaluates a place's opening_hours tag at now
+func PlaceOpenState(e *Entity, now time.Time) OpenState {
+	hours := PlaceTags(e)["opening_hours"]
+	if hours == "" {
+		return OpenState{}
+	}
+	return HoursOpenState(hours, e.Lat, e.Lon, now)
+}
@@ -0,0 +1,102 @@
+package spatial
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Twickenham, where sunrise and sunset are worked out for
+const ohTestLat, ohTestLon = 51.4470, -0.3370
+
+// ohTestTime is a time in 2025 London local time
+func ohTestTime(t *testing.T, month time.Month, day, hour, min int) time.Time {
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Date(2025, month, day, hour, min, 0, 0, loc)
+}
+
+// TestOpeningHours checks opening_hours evaluation in local time
+func TestOpeningHours(t *testing.T) {
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return ohTestTime(t, month, day, hour, min)
+	}
+	testCases := []struct {
+		hours string
+		at    time.Time
+		open  bool
+	}{
+		{"24/7", at(time.June, 16, 3, 0), true},
+		{"Mo-Fr 09:00-17:30", at(time.June, 16, 9, 0), true},    // Monday
+		{"Mo-Fr 09:00-17:30", at(time.June, 16, 17, 30), false}, // Closes on the minute
+		{"Mo-Fr 09:00-17:30", at(time.June, 14, 12, 0), false},  // Saturday
+		{"Mo-Sa 08:00-18:00; Su 10:00-16:00", at(time.June, 15, 9, 0), false},
+		{"Mo-Sa 08:00-18:00; Su 10:00-16:00", at(time.June, 15, 11, 0), true},
+		{"Mo-Fr 12:00-14:30,18:00-23:00", at(time.June, 16, 15, 0), false},
+		{"Fr-Sa 18:00-02:00", at(time.June, 14, 1, 0), true},             // Friday night into Saturday
+		{"Fr-Sa 18:00-02:00", at(time.June, 16, 1, 0), false},            // Sunday night into Monday
+		{"Mo-Su 09:00-18:00; PH off", at(time.August, 25, 12, 0), false}, // Bank holiday
+		{"Mo-Su 09:00-18:00; PH off", at(time.August, 26, 12, 0), true},
+		{"Mo-Fr 09:00-17:00; Dec 25 off", at(time.December, 25, 12, 0), false},
+		{"Mo-Fr 09:00-17:00; We 12:00-13:00 off", at(time.June, 18, 12, 30), false},
+		{"Mo-Fr 09:00-17:00, Sa 10:00-12:00", at(time.June, 14, 11, 0), true},
+		{"Apr-Sep Mo-Su 10:00-20:00; Oct-Mar Mo-Su 10:00-16:00", at(time.January, 15, 17, 0), false},
+		{"Apr-Sep Mo-Su 10:00-20:00; Oct-Mar Mo-Su 10:00-16:00", at(time.June, 15, 17, 0), true},
+		{"sunrise-sunset", at(time.June, 16, 12, 0), true},
+		{"sunrise-sunset", at(time.June, 16, 23, 0), false},
+		{"(sunrise+01:00)-(sunset-01:00)", at(time.December, 16, 8, 30), false}, // Sunrise ~08:00
+		{`Mo-Fr 08:00-18:00 "by appointment"`, at(time.June, 16, 8, 0), true},
+		{"Mo[1] 10:00-12:00", at(time.June, 2, 11, 0), true},
+		{"Mo[1] 10:00-12:00", at(time.June, 9, 11, 0), false},
+	}
+	for _, tc := range testCases {
+		oh, err := ParseOpeningHours(tc.hours)
+		if err != nil {
+			t.Errorf("ParseOpeningHours(%q): %v", tc.hours, err)
+			continue
+		}
+		if got := oh.IsOpen(tc.at, ohTestLat, ohTestLon); got != tc.open {
+			t.Errorf("%q at %s: open = %v, want %v", tc.hours, tc.at.Format("Mon 2 Jan 15:04"), got, tc.open)
+		}
+	}
+}
+
+// TestOpeningHoursNextChange checks a closing time past midnight is found
+func TestOpeningHoursNextChange(t *testing.T) {
+	oh, _ := ParseOpeningHours("Mo-Su 18:00-01:00")
+	open, change, ok := oh.NextChange(ohTestTime(t, time.June, 16, 23, 40), ohTestLat, ohTestLon)
+	if !open || !ok || !change.Equal(ohTestTime(t, time.June, 17, 1, 0)) {
+		t.Errorf("NextChange = %v %v %v, want open until 01:00", open, change, ok)
+	}
+}
+
+// TestHoursClosingSoon checks a place closing shortly says so
+func TestHoursClosingSoon(t *testing.T) {
+	now := ohTestTime(t, time.June, 16, 17, 10)
+	state := HoursOpenState("Mo-Fr 09:00-17:30", ohTestLat, ohTestLon, now)
+	if !state.ClosingWithin(now, 30*time.Minute) {
+		t.Errorf("expected closing within 30 min, got %+v", state)
+	}
+}
+
+// TestOpeningHoursFreeText checks hours that aren't the OSM syntax are rejected
+func TestOpeningHoursFreeText(t *testing.T) {
+	if _, err := ParseOpeningHours("whenever we feel like it"); err == nil {
+		t.Error("expected error for free text hours")
+	}
+}
+
+// TestOpeningHoursCache checks the parse cache doesn't grow past its cap
+func TestOpeningHoursCache(t *testing.T) {
+	for i := 0; i < ohCacheMax+10; i++ {
+		ParseOpeningHours(fmt.Sprintf("Mo-Fr 09:00-17:%02d; PH off %d", i%60, i))
+	}
+	ohCacheMu.RLock()
+	size := len(ohCache)
+	ohCacheMu.RUnlock()
+	if size > ohCacheMax {
+		t.Errorf("cache holds %d values, cap %d", size, ohCacheMax)
+	}
+}
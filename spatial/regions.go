@@ -4,6 +4,7 @@ package spatial
 type Region struct {
 	Name      string
 	Transport []string // Available transport APIs
+	Timezone  string   // IANA zone for local times (opening hours)
 	// Bounding box
 	MinLat, MaxLat float64
 	MinLon, MaxLon float64
@@ -13,31 +14,36 @@ type Region struct {
 var regions = []Region{
 	{
 		Name:      "london",
+		Timezone:  "Europe/London",
 		Transport: []string{"tfl"},
 		MinLat:    51.2, MaxLat: 51.7,
 		MinLon: -0.6, MaxLon: 0.3,
 	},
 	{
 		Name:      "manchester",
-		Transport: []string{"tfgm"}, // Transport for Greater Manchester (TODO)
+		Timezone:  "Europe/London",
+		Transport: []string{"tfgm"}, // Transport for Greater Manchester: Metrolink trams
 		MinLat:    53.3, MaxLat: 53.6,
 		MinLon: -2.5, MaxLon: -2.0,
 	},
 	{
 		Name:      "edinburgh",
+		Timezone:  "Europe/London",
 		Transport: []string{"edinburgh_trams"}, // TODO
 		MinLat:    55.85, MaxLat: 56.0,
 		MinLon: -3.4, MaxLon: -3.0,
 	},
 	{
 		Name:      "cardiff",
+		Timezone:  "Europe/London",
 		Transport: []string{"tfw"}, // Transport for Wales (TODO)
 		MinLat:    51.4, MaxLat: 51.6,
 		MinLon: -3.3, MaxLon: -3.0,
 	},
 	{
 		Name:      "dublin",
-		Transport: []string{"dublin_bus", "irish_rail"}, // TODO
+		Timezone:  "Europe/Dublin",
+		Transport: []string{"dublin_bus", "irish_rail"}, // Irish Rail; Dublin Bus TODO
 		MinLat:    53.2, MaxLat: 53.5,
 		MinLon: -6.5, MaxLon: -6.0,
 	},
@@ -80,10 +86,10 @@ Regional Data Sources Summary:
 | Region     | Transport                    | Weather    | Prayer | POIs          |
 |------------|------------------------------|------------|--------|---------------|
 | London     | TfL ✓                        | Open-Meteo | Aladhan| OSM/Foursquare|
-| Manchester | TfGM (TODO)                  | Open-Meteo | Aladhan| OSM/Foursquare|
+| Manchester | Metrolink ✓, TfGM buses TODO | Open-Meteo | Aladhan| OSM/Foursquare|
 | Edinburgh  | Edinburgh Trams (TODO)       | Open-Meteo | Aladhan| OSM/Foursquare|
 | Cardiff    | Transport for Wales (TODO)   | Open-Meteo | Aladhan| OSM/Foursquare|
-| Dublin     | Dublin Bus/Irish Rail (TODO) | Open-Meteo | Aladhan| OSM/Foursquare|
+| Dublin     | Irish Rail ✓, Dublin Bus TODO| Open-Meteo | Aladhan| OSM/Foursquare|
 | Other UK   | National Rail (TODO)         | Open-Meteo | Aladhan| OSM/Foursquare|
 | France     | SNCF (TODO)                  | Open-Meteo | Aladhan| OSM/Foursquare|
 | USA        | Various (TODO)               | Open-Meteo | Aladhan| OSM/Foursquare|
@@ -97,5 +103,7 @@ APIs to integrate:
 - National Rail: https://opendata.nationalrail.co.uk/
 - Edinburgh Trams: https://tfeapidocs.edinburgh.gov.uk/
 
-For now: Only TfL is implemented. Other regions get weather/prayer/POIs but no live transport.
+Live transport runs as agent tasks (tasks.go): TfL in London, Irish Rail in
+Dublin, Metrolink in Manchester (needs TFGM_API_KEY). Other regions get
+weather/prayer/POIs but no live transport.
 */
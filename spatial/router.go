@@ -0,0 +1,760 @@
+package spatial
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Local routing over the street graph. Street geometry comes from indexed
+// street entities (OSRM routes, Overpass ways, user tracks) and from GeoJSON
+// files imported into data/streets. Routes come back as the same Route and
+// RouteGeometry types as OSRM, which is only asked when the local graph has
+// no good answer.
+
+const (
+	graphSnapRadius   = 150.0 // Max metres from a point to the nearest graph node
+	graphStitchRadius = 15.0  // Dangling ends this close to another node are joined
+	graphMargin       = 1500.0
+	graphStreetReach  = 2000.0 // Street entities are indexed by one point, so query wider
+	graphCacheTTL     = 10 * time.Minute
+	graphMaxDetour    = 2.5 // Local routes longer than this times the crow-flies distance are suspect
+	graphCellDeg      = 0.001
+	graphKeyScale     = 1e5 // Coordinates rounded to ~1 m merge into one node
+)
+
+// Profile describes a travel mode: how fast it moves and how it's worded
+type Profile struct {
+	Name     string  // walk, cycle, drive, wheelchair
+	OSRM     string  // OSRM profile asked when the local graph can't route
+	Speed    float64 // metres per second
+	Icon     string
+	Verb     string // "Start walking", "12 min walk"
+	Noun     string
+	MapMode  string // Google Maps travelmode
+	Local    bool   // Route over the local street graph first
+	StepFree bool   // Avoid steps and steep or inaccessible ways
+}
+
+// Travel modes. Walking speeds match the 5 km/h used for walk summaries;
+// driving is an urban average, though OSRM's own estimate wins when it routes.
+// The street graph includes footpaths and user tracks, so cars ask OSRM first
+var (
+	ProfileFoot       = &Profile{Name: "walk", OSRM: "foot", Speed: 5000.0 / 3600, Icon: "🚶", Verb: "walking", Noun: "walk", MapMode: "walking", Local: true}
+	ProfileCycle      = &Profile{Name: "cycle", OSRM: "bike", Speed: 15000.0 / 3600, Icon: "🚲", Verb: "cycling", Noun: "ride", MapMode: "bicycling"}
+	ProfileDrive      = &Profile{Name: "drive", OSRM: "car", Speed: 30000.0 / 3600, Icon: "🚗", Verb: "driving", Noun: "drive", MapMode: "driving"}
+	ProfileWheelchair = &Profile{Name: "wheelchair", OSRM: "foot", Speed: 3500.0 / 3600, Icon: "♿", Verb: "rolling", Noun: "by wheelchair", MapMode: "walking", Local: true, StepFree: true}
+)
+
+// ProfileFor returns the profile for a travel mode word, nil if unknown
+func ProfileFor(mode string) *Profile {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "walk", "walking", "foot", "on foot":
+		return ProfileFoot
+	case "cycle", "cycling", "bike", "bicycle", "ride":
+		return ProfileCycle
+	case "drive", "driving", "car":
+		return ProfileDrive
+	case "wheelchair", "wheel", "roll", "accessible", "step-free", "step free", "stepfree", "pram", "buggy", "pushchair":
+		return ProfileWheelchair
+	}
+	return nil
+}
+
+type graphEdge struct {
+	to     int
+	length float64 // metres
+	name   string
+	access float64 // Step-free cost per metre, 0 for plain streets, +Inf where impassable
+	tagged bool    // Some way along it has OSM tags, so access is known rather than assumed
+}
+
+type graphNode struct {
+	lat, lon float64
+	edges    []graphEdge
+}
+
+// StreetGraph is a node/edge graph built from street polylines
+type StreetGraph struct {
+	nodes   []graphNode
+	index   map[[2]int64]int // Rounded coordinate -> node
+	cells   map[[2]int][]int
+	builtAt time.Time
+}
+
+// NewStreetGraph returns an empty graph
+func NewStreetGraph() *StreetGraph {
+	return &StreetGraph{
+		index: make(map[[2]int64]int),
+		cells: make(map[[2]int][]int),
+	}
+}
+
+// Size returns the number of nodes
+func (g *StreetGraph) Size() int {
+	return len(g.nodes)
+}
+
+// AddStreet adds a [lon, lat] polyline as walkable edges in both directions
+func (g *StreetGraph) AddStreet(points [][]float64, name string) {
+	g.AddWay(points, name, nil)
+}
+
+// AddWay adds a street with its OSM tags, which decide step-free access
+func (g *StreetGraph) AddWay(points [][]float64, name string, tags map[string]string) {
+	access := stepFreeAccess(tags)
+	tagged := len(tags) > 0
+	prev := -1
+	for _, pt := range points {
+		if len(pt) < 2 {
+			continue
+		}
+		n := g.node(pt[1], pt[0])
+		if prev >= 0 && prev != n {
+			g.addEdge(prev, n, name, access, tagged)
+			g.addEdge(n, prev, name, access, tagged)
+		}
+		prev = n
+	}
+}
+
+func (g *StreetGraph) node(lat, lon float64) int {
+	key := [2]int64{int64(math.Round(lat * graphKeyScale)), int64(math.Round(lon * graphKeyScale))}
+	if n, ok := g.index[key]; ok {
+		return n
+	}
+	n := len(g.nodes)
+	g.nodes = append(g.nodes, graphNode{lat: lat, lon: lon})
+	g.index[key] = n
+	cell := graphCell(lat, lon)
+	g.cells[cell] = append(g.cells[cell], n)
+	return n
+}
+
+// addEdge adds a directed edge, keeping one edge per node pair
+// A named edge replaces an unnamed one so route polylines don't hide street names,
+// and the worst access wins since untagged polylines often trace tagged ways
+func (g *StreetGraph) addEdge(from, to int, name string, access float64, tagged bool) {
+	for i, e := range g.nodes[from].edges {
+		if e.to == to {
+			if e.name == "" && name != "" {
+				g.nodes[from].edges[i].name = name
+			}
+			if access > e.access {
+				g.nodes[from].edges[i].access = access
+			}
+			if tagged {
+				g.nodes[from].edges[i].tagged = true
+			}
+			return
+		}
+	}
+	a, b := g.nodes[from], g.nodes[to]
+	g.nodes[from].edges = append(g.nodes[from].edges, graphEdge{
+		to:     to,
+		length: haversineMeters(a.lat, a.lon, b.lat, b.lon),
+		name:   name,
+		access: access,
+		tagged: tagged,
+	})
+}
+
+// Step-free limits, as percentage gradients
+const (
+	stepFreeMaxIncline   = 10.0 // Steeper is impassable
+	stepFreeSteepIncline = 6.0  // Steeper is avoided where possible
+)
+
+// stepFreeAccess rates a way for step-free travel from its OSM tags:
+// 0 for nothing known against it, a cost multiplier for ways best avoided,
+// +Inf for steps and anything tagged inaccessible
+func stepFreeAccess(tags map[string]string) float64 {
+	if len(tags) == 0 {
+		return 0
+	}
+	ramp := tags["ramp"] == "yes" || tags["ramp:wheelchair"] == "yes" || tags["ramp:stroller"] == "yes"
+	switch {
+	case tags["wheelchair"] == "no":
+		return math.Inf(1)
+	case tags["highway"] == "steps" && !ramp:
+		return math.Inf(1)
+	}
+
+	access := 0.0
+	worse := func(f float64) {
+		if f > access {
+			access = f
+		}
+	}
+	if tags["highway"] == "steps" || tags["wheelchair"] == "limited" {
+		worse(1.5)
+	}
+	if tags["kerb"] == "raised" {
+		worse(2)
+	}
+	if incline := tags["incline"]; incline != "" {
+		if pct, ok := parseIncline(incline); ok {
+			switch {
+			case pct > stepFreeMaxIncline:
+				return math.Inf(1)
+			case pct > stepFreeSteepIncline:
+				worse(2)
+			}
+		} else if incline == "up" || incline == "down" || incline == "yes" {
+			worse(1.5) // Steep enough to tag, size unknown
+		}
+	}
+	return access
+}
+
+// parseIncline reads an OSM incline as an absolute percentage: "8%", "-12%", "5°"
+func parseIncline(v string) (float64, bool) {
+	v = strings.TrimSpace(v)
+	degrees := strings.HasSuffix(v, "°")
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "%"), "°")
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return 0, false
+	}
+	if degrees {
+		f = math.Tan(f*math.Pi/180) * 100
+	}
+	return math.Abs(f), true
+}
+
+// edgeCost is what an edge costs a profile to travel, false if it can't
+func (e graphEdge) edgeCost(profile *Profile) (float64, bool) {
+	if profile == nil || !profile.StepFree || e.access == 0 {
+		return e.length, true
+	}
+	if math.IsInf(e.access, 1) {
+		return 0, false
+	}
+	return e.length * e.access, true
+}
+
+func graphCell(lat, lon float64) [2]int {
+	return [2]int{int(math.Floor(lat / graphCellDeg)), int(math.Floor(lon / graphCellDeg))}
+}
+
+// nearest finds the closest node within maxDist metres
+func (g *StreetGraph) nearest(lat, lon, maxDist float64) (int, float64, bool) {
+	c := graphCell(lat, lon)
+	// A cell is ~111 m tall and at least ~60 m wide at UK latitudes
+	reach := int(math.Ceil(maxDist/(graphCellDeg*111000*math.Cos(lat*math.Pi/180)))) + 1
+	best, bestDist := -1, maxDist
+	for dy := -reach; dy <= reach; dy++ {
+		for dx := -reach; dx <= reach; dx++ {
+			for _, n := range g.cells[[2]int{c[0] + dy, c[1] + dx}] {
+				if d := haversineMeters(lat, lon, g.nodes[n].lat, g.nodes[n].lon); d <= bestDist {
+					best, bestDist = n, d
+				}
+			}
+		}
+	}
+	return best, bestDist, best >= 0
+}
+
+// stitch joins dangling polyline ends to nearby nodes
+// Tracks and routes from different sources rarely share exact vertices
+func (g *StreetGraph) stitch() {
+	for n := range g.nodes {
+		if len(g.nodes[n].edges) != 1 {
+			continue
+		}
+		node := g.nodes[n]
+		c := graphCell(node.lat, node.lon)
+		best, bestDist := -1, graphStitchRadius
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				for _, m := range g.cells[[2]int{c[0] + dy, c[1] + dx}] {
+					if m == n || m == node.edges[0].to {
+						continue
+					}
+					if d := haversineMeters(node.lat, node.lon, g.nodes[m].lat, g.nodes[m].lon); d < bestDist {
+						best, bestDist = m, d
+					}
+				}
+			}
+		}
+		if best >= 0 {
+			// The gap may be the rest of a way the ends don't share, steps
+			// included, so it's no easier than the hardest way it joins
+			access := math.Max(g.worstAccess(n), g.worstAccess(best))
+			g.addEdge(n, best, "", access, false)
+			g.addEdge(best, n, "", access, false)
+		}
+	}
+}
+
+// worstAccess is the hardest step-free access of the edges at a node
+func (g *StreetGraph) worstAccess(n int) float64 {
+	worst := 0.0
+	for _, e := range g.nodes[n].edges {
+		worst = math.Max(worst, e.access)
+	}
+	return worst
+}
+
+// pathItem is an A* frontier entry
+type pathItem struct {
+	node  int
+	score float64 // cost so far + heuristic
+}
+
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].score < q[j].score }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// shortestPath runs A* with a straight-line heuristic, skipping edges the
+// profile can't use. Returns node indices from start to goal and the path
+// length in metres
+func (g *StreetGraph) shortestPath(start, goal int, profile *Profile) ([]int, float64, bool) {
+	goalNode := g.nodes[goal]
+	dist := map[int]float64{start: 0}
+	prev := make(map[int]int)
+	done := make(map[int]bool)
+
+	q := &pathQueue{{node: start}}
+	for q.Len() > 0 {
+		cur := heap.Pop(q).(pathItem).node
+		if cur == goal {
+			path := []int{goal}
+			for n := goal; n != start; {
+				n = prev[n]
+				path = append(path, n)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path, g.pathLength(path), true
+		}
+		if done[cur] {
+			continue
+		}
+		done[cur] = true
+
+		for _, e := range g.nodes[cur].edges {
+			cost, ok := e.edgeCost(profile)
+			if !ok {
+				continue
+			}
+			d := dist[cur] + cost
+			if old, ok := dist[e.to]; ok && d >= old {
+				continue
+			}
+			dist[e.to] = d
+			prev[e.to] = cur
+			n := g.nodes[e.to]
+			heap.Push(q, pathItem{node: e.to, score: d + haversineMeters(n.lat, n.lon, goalNode.lat, goalNode.lon)})
+		}
+	}
+	return nil, 0, false
+}
+
+// pathLength returns the length of a path in metres
+func (g *StreetGraph) pathLength(path []int) float64 {
+	total := 0.0
+	for i := 0; i+1 < len(path); i++ {
+		for _, e := range g.nodes[path[i]].edges {
+			if e.to == path[i+1] {
+				total += e.length
+				break
+			}
+		}
+	}
+	return total
+}
+
+// edgeName returns the street name of the edge between two nodes
+func (g *StreetGraph) edgeName(from, to int) string {
+	for _, e := range g.nodes[from].edges {
+		if e.to == to {
+			return e.name
+		}
+	}
+	return ""
+}
+
+// Route finds the shortest path between two points for a profile
+func (g *StreetGraph) Route(fromLat, fromLon, toLat, toLon float64, profile *Profile) (*Route, error) {
+	start, startGap, ok := g.nearest(fromLat, fromLon, graphSnapRadius)
+	if !ok {
+		return nil, fmt.Errorf("start is off the street graph")
+	}
+	goal, goalGap, ok := g.nearest(toLat, toLon, graphSnapRadius)
+	if !ok {
+		return nil, fmt.Errorf("destination is off the street graph")
+	}
+	path, length, ok := g.shortestPath(start, goal, profile)
+	if !ok {
+		if profile.StepFree {
+			return nil, fmt.Errorf("no step-free route")
+		}
+		return nil, fmt.Errorf("no connected route")
+	}
+
+	total := startGap + length + goalGap
+	geometry := [][]float64{{fromLon, fromLat}}
+	for _, n := range path {
+		geometry = append(geometry, []float64{g.nodes[n].lon, g.nodes[n].lat})
+	}
+	geometry = append(geometry, []float64{toLon, toLat})
+
+	route := &Route{
+		Steps:     g.routeSteps(path, profile),
+		TotalDist: total,
+		TotalTime: total / profile.Speed,
+		Geometry:  geometry,
+		Profile:   profile,
+		StartGap:  startGap,
+		EndGap:    goalGap,
+	}
+	route.Summary = formatRouteSummary(profile, route.TotalDist, route.TotalTime)
+	// Courier routes and user tracks carry no tags, so nothing is known of
+	// steps along them
+	if profile.StepFree && !g.pathTagged(path) {
+		route.Summary += " · ⚠️ may include steps"
+	}
+	return route, nil
+}
+
+// pathTagged reports whether every edge along a path has OSM tags
+func (g *StreetGraph) pathTagged(path []int) bool {
+	for i := 0; i+1 < len(path); i++ {
+		for _, e := range g.nodes[path[i]].edges {
+			if e.to == path[i+1] && !e.tagged {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// routeStepTurn is the bearing change that splits an unnamed stretch into a new step
+const routeStepTurn = 50.0
+
+// routeSteps groups path edges into turn-by-turn steps
+// A new step starts when the street name changes, or on a sharp turn along unnamed paths
+func (g *StreetGraph) routeSteps(path []int, profile *Profile) []RouteStep {
+	type leg struct {
+		name                  string
+		length                float64
+		inBearing, outBearing float64
+		lat, lon              float64 // Where the leg starts
+	}
+	var legs []leg
+	for i := 0; i+1 < len(path); i++ {
+		a, b := g.nodes[path[i]], g.nodes[path[i+1]]
+		name := g.edgeName(path[i], path[i+1])
+		length := haversineMeters(a.lat, a.lon, b.lat, b.lon)
+		brg := bearing(a.lat, a.lon, b.lat, b.lon)
+		if n := len(legs); n > 0 && legs[n-1].name == name &&
+			(name != "" || math.Abs(turnAngle(legs[n-1].outBearing, brg)) < routeStepTurn) {
+			legs[n-1].length += length
+			legs[n-1].outBearing = brg
+			continue
+		}
+		legs = append(legs, leg{name: name, length: length, inBearing: brg, outBearing: brg, lat: a.lat, lon: a.lon})
+	}
+
+	var steps []RouteStep
+	for i, l := range legs {
+		maneuver, modifier := "depart", ""
+		if i > 0 {
+			maneuver = "turn"
+			modifier = turnModifier(turnAngle(legs[i-1].outBearing, l.inBearing))
+		}
+		// Tiny legs are noise from stitching, except the departure
+		if i > 0 && l.length < 5 {
+			continue
+		}
+		steps = append(steps, RouteStep{
+			Instruction: formatInstruction(profile, maneuver, modifier, l.name),
+			Distance:    l.length,
+			Duration:    l.length / profile.Speed,
+			Name:        l.name,
+			Lat:         l.lat,
+			Lon:         l.lon,
+		})
+	}
+	return steps
+}
+
+// bearing returns the initial compass bearing from one point to another, in degrees
+func bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// turnAngle returns the change from one bearing to another, -180..180, positive is right
+func turnAngle(from, to float64) float64 {
+	return math.Mod(to-from+540, 360) - 180
+}
+
+// turnModifier maps a turn angle to an OSRM maneuver modifier
+func turnModifier(angle float64) string {
+	a := math.Abs(angle)
+	side := "right"
+	if angle < 0 {
+		side = "left"
+	}
+	switch {
+	case a < 20:
+		return "straight"
+	case a < 60:
+		return "slight " + side
+	case a < 135:
+		return side
+	case a < 170:
+		return "sharp " + side
+	default:
+		return "uturn"
+	}
+}
+
+// graphBuild is a graph being built, which other callers for the same
+// area wait on rather than building it again
+type graphBuild struct {
+	done chan struct{}
+	g    *StreetGraph
+}
+
+// graphCache holds graphs built for recent route areas. Builds run outside
+// the lock so one slow area doesn't hold up routing everywhere else
+var (
+	graphCache   = make(map[string]*StreetGraph)
+	graphBuilds  = make(map[string]*graphBuild)
+	graphCacheMu sync.Mutex
+)
+
+// streetGraphFor returns a graph covering both points, built from indexed and imported streets
+func streetGraphFor(fromLat, fromLon, toLat, toLon float64) *StreetGraph {
+	midLat, midLon := (fromLat+toLat)/2, (fromLon+toLon)/2
+	return streetGraphAround(midLat, midLon, haversineMeters(fromLat, fromLon, toLat, toLon)/2+graphMargin)
+}
+
+// streetGraphAround returns a graph covering a circle
+func streetGraphAround(midLat, midLon, radius float64) *StreetGraph {
+	// Snap the area so nearby requests share a graph
+	radius = math.Ceil(radius/1000) * 1000
+	key := fmt.Sprintf("%s:%.0f", Geohash(midLat, midLon, 6), radius)
+
+	graphCacheMu.Lock()
+	if g, ok := graphCache[key]; ok && time.Since(g.builtAt) < graphCacheTTL {
+		graphCacheMu.Unlock()
+		return g
+	}
+	if b, ok := graphBuilds[key]; ok {
+		graphCacheMu.Unlock()
+		<-b.done
+		return b.g
+	}
+	b := &graphBuild{done: make(chan struct{})}
+	graphBuilds[key] = b
+	for k, g := range graphCache {
+		if time.Since(g.builtAt) >= graphCacheTTL {
+			delete(graphCache, k)
+		}
+	}
+	graphCacheMu.Unlock()
+
+	b.g = buildStreetGraph(key, midLat, midLon, radius)
+	graphCacheMu.Lock()
+	graphCache[key] = b.g
+	delete(graphBuilds, key)
+	graphCacheMu.Unlock()
+	close(b.done)
+	return b.g
+}
+
+// buildStreetGraph builds the graph for a circle from indexed and imported streets
+func buildStreetGraph(key string, midLat, midLon, radius float64) *StreetGraph {
+	g := NewStreetGraph()
+	streets := Get().Query(midLat, midLon, radius+graphStreetReach, EntityStreet, 20000)
+	for _, s := range streets {
+		sd := s.GetStreetData()
+		if sd == nil || len(sd.Points) < 2 {
+			continue
+		}
+		// Route polylines span many streets, so their single name is only kept for ways
+		name := ""
+		if sd.ToName == "" {
+			name = s.Name
+		}
+		g.AddWay(sd.Points, name, sd.Tags)
+	}
+	imported := importedStreetsNear(midLat, midLon, radius)
+	for _, s := range imported {
+		g.AddWay(s.Points, s.Name, s.Tags)
+	}
+	g.stitch()
+	g.builtAt = time.Now()
+
+	log.Printf("[router] Built graph for %s: %d nodes from %d indexed and %d imported streets",
+		key, g.Size(), len(streets), len(imported))
+	return g
+}
+
+// RouteLocal routes between two points over the local street graph
+// Fails if either end is off the graph or the only path is a long detour,
+// which usually means the graph has gaps rather than the streets do
+func RouteLocal(fromLat, fromLon, toLat, toLon float64, profile *Profile) (*Route, error) {
+	g := streetGraphFor(fromLat, fromLon, toLat, toLon)
+	if g.Size() == 0 {
+		return nil, fmt.Errorf("no streets indexed here")
+	}
+	route, err := g.Route(fromLat, fromLon, toLat, toLon, profile)
+	if err != nil {
+		return nil, err
+	}
+	direct := haversineMeters(fromLat, fromLon, toLat, toLon)
+	if direct > 200 && route.TotalDist > direct*graphMaxDetour {
+		return nil, fmt.Errorf("local route is a %.0fx detour", route.TotalDist/direct)
+	}
+	return route, nil
+}
+
+// ImportedStreet is a street polyline loaded from data/streets
+type ImportedStreet struct {
+	Name                           string
+	Points                         [][]float64       // [lon, lat]
+	Tags                           map[string]string // Access tags: highway, wheelchair, incline...
+	minLat, minLon, maxLat, maxLon float64
+}
+
+var (
+	importedStreets     []ImportedStreet
+	importedStreetsOnce sync.Once
+)
+
+// streetsDir finds data/streets relative to the source file
+func streetsDir() string {
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(filename), "..", "data", "streets")
+}
+
+// loadImportedStreets reads every GeoJSON file in data/streets
+func loadImportedStreets() {
+	dir := streetsDir()
+	if dir == "" {
+		return
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.geojson"))
+	for _, path := range files {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("[router] %s: %v", path, err)
+			continue
+		}
+		streets, err := ParseStreetGeoJSON(b)
+		if err != nil {
+			log.Printf("[router] %s: %v", path, err)
+			continue
+		}
+		importedStreets = append(importedStreets, streets...)
+		log.Printf("[router] Imported %d streets from %s", len(streets), filepath.Base(path))
+	}
+}
+
+// streetAccessTags are the OSM tags kept from imported streets for step-free routing
+var streetAccessTags = []string{"highway", "wheelchair", "incline", "kerb", "ramp", "ramp:wheelchair", "ramp:stroller"}
+
+// ParseStreetGeoJSON reads LineString and MultiLineString features
+// The "name" property becomes the street name; access tags are kept for routing
+func ParseStreetGeoJSON(b []byte) ([]ImportedStreet, error) {
+	var fc struct {
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(b, &fc); err != nil {
+		return nil, err
+	}
+
+	var streets []ImportedStreet
+	for _, f := range fc.Features {
+		name, _ := f.Properties["name"].(string)
+		var tags map[string]string
+		for _, k := range streetAccessTags {
+			if v, ok := f.Properties[k].(string); ok && v != "" {
+				if tags == nil {
+					tags = make(map[string]string)
+				}
+				tags[k] = v
+			}
+		}
+		var lines [][][]float64
+		switch f.Geometry.Type {
+		case "LineString":
+			var line [][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &line); err != nil {
+				continue
+			}
+			lines = append(lines, line)
+		case "MultiLineString":
+			if err := json.Unmarshal(f.Geometry.Coordinates, &lines); err != nil {
+				continue
+			}
+		}
+		for _, line := range lines {
+			if len(line) < 2 {
+				continue
+			}
+			s := ImportedStreet{Name: name, Points: line, Tags: tags, minLat: 90, minLon: 180, maxLat: -90, maxLon: -180}
+			for _, pt := range line {
+				if len(pt) < 2 {
+					continue
+				}
+				s.minLon, s.maxLon = math.Min(s.minLon, pt[0]), math.Max(s.maxLon, pt[0])
+				s.minLat, s.maxLat = math.Min(s.minLat, pt[1]), math.Max(s.maxLat, pt[1])
+			}
+			streets = append(streets, s)
+		}
+	}
+	return streets, nil
+}
+
+// importedStreetsNear returns imported streets whose bounds fall within radius of a point
+func importedStreetsNear(lat, lon, radiusMeters float64) []ImportedStreet {
+	importedStreetsOnce.Do(loadImportedStreets)
+
+	dLat := radiusMeters / 111000
+	dLon := radiusMeters / (111000 * math.Cos(lat*math.Pi/180))
+	var result []ImportedStreet
+	for _, s := range importedStreets {
+		if s.maxLat < lat-dLat || s.minLat > lat+dLat || s.maxLon < lon-dLon || s.minLon > lon+dLon {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
@@ -0,0 +1,264 @@
+package spatial
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// testHighStreetGraph is High Street running north into a right turn onto
+// Church Road, with a user track ending 7 m short of High Street, stitched
+func testHighStreetGraph(t *testing.T) *StreetGraph {
+	streets, err := ParseStreetGeoJSON([]byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"name": "High Street"},
+		 "geometry": {"type": "LineString", "coordinates": [[-0.1, 51.5], [-0.1, 51.5013], [-0.1, 51.5027]]}},
+		{"type": "Feature", "properties": {"name": "Church Road"},
+		 "geometry": {"type": "MultiLineString", "coordinates": [[[-0.1, 51.5027], [-0.0985, 51.5027], [-0.0971, 51.5027]]]}}
+	]}`))
+	if err != nil || len(streets) != 2 {
+		t.Fatalf("ParseStreetGeoJSON = %d streets, %v", len(streets), err)
+	}
+
+	g := NewStreetGraph()
+	for _, s := range streets {
+		g.AddStreet(s.Points, s.Name)
+	}
+	g.AddStreet([][]float64{{-0.0980, 51.5013}, {-0.0999, 51.5013}}, "")
+	g.stitch()
+	return g
+}
+
+// TestStreetGraphRoute checks local routing distance and turn instructions
+func TestStreetGraphRoute(t *testing.T) {
+	route, err := testHighStreetGraph(t).Route(51.5, -0.1, 51.5027, -0.0971, ProfileFoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route.TotalDist < 480 || route.TotalDist > 520 {
+		t.Errorf("distance = %.0f, want ~500", route.TotalDist)
+	}
+	if len(route.Steps) != 2 || route.Steps[0].Instruction != "🚶 Start on High Street" ||
+		route.Steps[1].Instruction != "➡️ Turn right onto Church Road" {
+		t.Errorf("steps = %+v", route.Steps)
+	}
+	if !strings.Contains(route.Summary, "min walk") {
+		t.Errorf("summary = %q", route.Summary)
+	}
+}
+
+// TestStreetGraphStitch checks a track ending short of a street joins the graph
+func TestStreetGraphStitch(t *testing.T) {
+	route, err := testHighStreetGraph(t).Route(51.5013, -0.0980, 51.5, -0.1, ProfileFoot)
+	if err != nil {
+		t.Fatalf("stitched track: %v", err)
+	}
+	if first := route.Steps[0].Instruction; first != "🚶 Start walking" {
+		t.Errorf("first step = %q", first)
+	}
+}
+
+// TestStreetGraphGaps checks ends off the graph are joined by straight
+// connectors, which are measured
+func TestStreetGraphGaps(t *testing.T) {
+	route, err := testHighStreetGraph(t).Route(51.5, -0.1003, 51.5027, -0.0971, ProfileFoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route.StartGap < 15 || route.StartGap > 30 || route.EndGap > 1 {
+		t.Errorf("gaps = %.0fm, %.0fm", route.StartGap, route.EndGap)
+	}
+}
+
+// TestStreetGraphOffGraph checks a start nowhere near a street fails
+func TestStreetGraphOffGraph(t *testing.T) {
+	if _, err := testHighStreetGraph(t).Route(51.51, -0.1, 51.5, -0.1, ProfileFoot); err == nil {
+		t.Error("expected off-graph start to fail")
+	}
+}
+
+// TestStreetGraphShared checks callers for one area share a single build
+func TestStreetGraphShared(t *testing.T) {
+	graphs := make(chan *StreetGraph, 4)
+	for i := 0; i < cap(graphs); i++ {
+		go func() { graphs <- streetGraphAround(48.1, 11.5, 1000) }()
+	}
+	first := <-graphs
+	for i := 1; i < cap(graphs); i++ {
+		if <-graphs != first {
+			t.Error("concurrent callers built separate graphs")
+		}
+	}
+}
+
+// testProfileRoute routes 1.5 km along a single High Street
+func testProfileRoute(t *testing.T, mode string) *Route {
+	profile := ProfileFor(mode)
+	if profile == nil {
+		t.Fatalf("no profile for %q", mode)
+	}
+	g := NewStreetGraph()
+	g.AddStreet([][]float64{{-0.1, 51.5}, {-0.1, 51.5135}}, "High Street")
+	route, err := g.Route(51.5, -0.1, 51.5135, -0.1, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return route
+}
+
+// TestProfileWording checks each travel mode's start step and summary
+func TestProfileWording(t *testing.T) {
+	for _, tc := range []struct{ mode, start, summary string }{
+		{"walk", "🚶 Start on High Street", "🚶 1.5 km · 18 min walk"},
+		{"bike", "🚲 Start on High Street", "🚲 1.5 km · 6 min ride"},
+		{"car", "🚗 Start on High Street", "🚗 1.5 km · 3 min drive"},
+		{"wheelchair", "♿ Start on High Street", "♿ 1.5 km · 25 min by wheelchair · ⚠️ may include steps"},
+	} {
+		route := testProfileRoute(t, tc.mode)
+		if route.Steps[0].Instruction != tc.start || route.Summary != tc.summary {
+			t.Errorf("%s: %q, %q", tc.mode, route.Steps[0].Instruction, route.Summary)
+		}
+	}
+}
+
+// TestProfileMapLink checks the map link opens in the matching travel mode
+func TestProfileMapLink(t *testing.T) {
+	for mode, mapMode := range map[string]string{"walk": "walking", "bike": "bicycling", "car": "driving", "wheelchair": "walking"} {
+		out := FormatDirectionsWithMap(testProfileRoute(t, mode), 51.5, -0.1, 51.5135, -0.1, "")
+		if !strings.Contains(out, "travelmode="+mapMode) {
+			t.Errorf("%s: map link in %q", mode, out)
+		}
+	}
+}
+
+// TestProfileForUnknown checks an unknown mode has no profile
+func TestProfileForUnknown(t *testing.T) {
+	if ProfileFor("hovercraft") != nil {
+		t.Error("unknown mode should have no profile")
+	}
+}
+
+// TestProfileUnnamedDepart checks the start step of an unnamed street
+func TestProfileUnnamedDepart(t *testing.T) {
+	if got := formatInstruction(ProfileCycle, "depart", "", ""); got != "🚲 Start cycling" {
+		t.Errorf("unnamed depart = %q", got)
+	}
+}
+
+// testStationStreets is steps up to the station, a longer ramp beside them
+// and a path on up the hill too steep to roll, as imported GeoJSON
+func testStationStreets(t *testing.T) []ImportedStreet {
+	streets, err := ParseStreetGeoJSON([]byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"name": "Station Steps", "highway": "steps"},
+		 "geometry": {"type": "LineString", "coordinates": [[-0.1, 51.5], [-0.1, 51.501]]}},
+		{"type": "Feature", "properties": {"name": "Ramp Lane", "highway": "footway", "incline": "4%"},
+		 "geometry": {"type": "LineString", "coordinates": [[-0.1, 51.5], [-0.0985, 51.5005], [-0.1, 51.501]]}},
+		{"type": "Feature", "properties": {"name": "Hill Path", "incline": "-15%"},
+		 "geometry": {"type": "LineString", "coordinates": [[-0.1, 51.501], [-0.1, 51.502]]}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return streets
+}
+
+// testStationGraph builds a graph from testStationStreets
+func testStationGraph(t *testing.T) *StreetGraph {
+	g := NewStreetGraph()
+	for _, s := range testStationStreets(t) {
+		g.AddWay(s.Points, s.Name, s.Tags)
+	}
+	return g
+}
+
+// TestStreetGeoJSONTags checks access tags are read from imported GeoJSON
+func TestStreetGeoJSONTags(t *testing.T) {
+	streets := testStationStreets(t)
+	if len(streets) != 3 || streets[0].Tags["highway"] != "steps" || streets[2].Tags["incline"] != "-15%" {
+		t.Fatalf("tags not kept: %+v", streets)
+	}
+}
+
+// TestStepFreeAvoidsSteps checks walking takes the steps and step-free the ramp
+func TestStepFreeAvoidsSteps(t *testing.T) {
+	g := testStationGraph(t)
+	walk, err := g.Route(51.5, -0.1, 51.501, -0.1, ProfileFoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if walk.Steps[0].Name != "Station Steps" {
+		t.Errorf("walk should take the steps: %+v", walk.Steps)
+	}
+	roll, err := g.Route(51.5, -0.1, 51.501, -0.1, ProfileWheelchair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roll.Steps[0].Name != "Ramp Lane" || roll.TotalDist <= walk.TotalDist {
+		t.Errorf("step-free should take the ramp: %+v", roll.Steps)
+	}
+	if strings.Contains(roll.Summary, "may include steps") {
+		t.Errorf("tagged ways flagged: %q", roll.Summary)
+	}
+}
+
+// TestStepFreeUntagged checks a route over untagged ways, like a courier
+// route or track, warns that steps can't be ruled out
+func TestStepFreeUntagged(t *testing.T) {
+	g := testStationGraph(t)
+	g.AddStreet([][]float64{{-0.1, 51.502}, {-0.1, 51.503}}, "")
+	if r, err := g.Route(51.502, -0.1, 51.503, -0.1, ProfileWheelchair); err != nil || !strings.Contains(r.Summary, "⚠️ may include steps") {
+		t.Errorf("untagged route: %v %v", r, err)
+	}
+}
+
+// TestStepFreeTooSteep checks there's no step-free route up a steep way
+// with no way round
+func TestStepFreeTooSteep(t *testing.T) {
+	if _, err := testStationGraph(t).Route(51.5, -0.1, 51.502, -0.1, ProfileWheelchair); err == nil || err.Error() != "no step-free route" {
+		t.Errorf("steep: err = %v", err)
+	}
+}
+
+// TestStepFreeStitchedSteps checks a loose end stitched to the top of steps
+// is no way round them
+func TestStepFreeStitchedSteps(t *testing.T) {
+	g := NewStreetGraph()
+	g.AddStreet([][]float64{{-0.102, 51.51006}, {-0.1, 51.51006}}, "Lower Road")
+	g.AddWay([][]float64{{-0.1, 51.51}, {-0.1, 51.51009}}, "Short Steps", map[string]string{"highway": "steps"})
+	g.AddWay([][]float64{{-0.1, 51.51009}, {-0.1, 51.511}}, "Upper Road", map[string]string{"highway": "footway"})
+	g.stitch()
+	if walk, err := g.Route(51.51006, -0.102, 51.511, -0.1, ProfileFoot); err != nil {
+		t.Errorf("stitched walk: %v %v", walk, err)
+	}
+	if r, err := g.Route(51.51006, -0.102, 51.511, -0.1, ProfileWheelchair); err == nil {
+		t.Errorf("step-free route over stitched steps: %+v", r.Steps)
+	}
+}
+
+// TestStepFreeAccess checks the cost multiplier read from access tags
+func TestStepFreeAccess(t *testing.T) {
+	for tags, want := range map[string]float64{
+		"highway=steps":               math.Inf(1),
+		"highway=steps,ramp=yes":      1.5,
+		"wheelchair=limited":          1.5,
+		"incline=8%":                  2,
+		"incline=up":                  1.5,
+		"incline=3°":                  0,
+		"highway=footway,kerb=raised": 2,
+	} {
+		m := make(map[string]string)
+		for _, kv := range strings.Split(tags, ",") {
+			p := strings.SplitN(kv, "=", 2)
+			m[p[0]] = p[1]
+		}
+		if got := stepFreeAccess(m); got != want {
+			t.Errorf("stepFreeAccess(%s) = %v, want %v", tags, got, want)
+		}
+	}
+}
+
+// TestStepFreeAliases checks the words that ask for a step-free route
+func TestStepFreeAliases(t *testing.T) {
+	if ProfileFor("pram") != ProfileWheelchair || ProfileFor("step-free") != ProfileWheelchair {
+		t.Error("step-free aliases")
+	}
+}
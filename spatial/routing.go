@@ -6,17 +6,33 @@ import (
 	"log"
 	"math"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 )
 
-const osrmBaseURL = "https://router.project-osrm.org"
+// osrmBaseURL is the OSRM server asked when the local street graph can't route
+// Set OSRM_URL to use another server, or to "off" to route fully offline
+var osrmBaseURL = osrmURL()
+
+func osrmURL() string {
+	if u := os.Getenv("OSRM_URL"); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return "https://router.project-osrm.org"
+}
+
+// OSRMEnabled reports whether the OSRM fallback is available
+func OSRMEnabled() bool {
+	return osrmBaseURL != "off"
+}
 
 type RouteStep struct {
 	Instruction string
 	Distance    float64 // meters
 	Duration    float64 // seconds
 	Name        string
+	Lat, Lon    float64 // Where the maneuver is
 }
 
 type Route struct {
@@ -24,12 +40,69 @@ type Route struct {
 	TotalDist float64 // meters
 	TotalTime float64 // seconds
 	Summary   string
+	Geometry  [][]float64 // [lon, lat] pairs along the route
+	Profile   *Profile    // Travel mode, walking if nil
+
+	// Local routes join the points asked for to the street graph with
+	// straight lines, the first and last segments of Geometry. They're
+	// metres of connector, not street, and must never be stored as one
+	StartGap, EndGap float64
+
+	// Journeys with public transport legs (see PlanJourney)
+	Legs      []RouteLeg
+	LeaveBy   time.Time
+	ArriveAt  time.Time
+	Transfers int
+}
+
+// mode returns the route's travel profile, walking for routes without one
+func (r *Route) mode() *Profile {
+	if r.Profile == nil {
+		return ProfileFoot
+	}
+	return r.Profile
 }
 
 // GetWalkingDirections returns turn-by-turn walking directions
 func GetWalkingDirections(fromLat, fromLon, toLat, toLon float64) (*Route, error) {
-	url := fmt.Sprintf("%s/route/v1/foot/%f,%f;%f,%f?overview=false&steps=true",
-		osrmBaseURL, fromLon, fromLat, toLon, toLat)
+	return GetDirections(fromLat, fromLon, toLat, toLon, ProfileFoot)
+}
+
+// GetDirections returns turn-by-turn directions for a travel mode
+// Walking routes over the local street graph, asking OSRM where the graph has
+// gaps; the graph has no cycle or car access, so cycling and driving ask OSRM
+// first and use the graph only when it's unavailable
+func GetDirections(fromLat, fromLon, toLat, toLon float64, profile *Profile) (*Route, error) {
+	if profile.Local {
+		route, err := RouteLocal(fromLat, fromLon, toLat, toLon, profile)
+		if err == nil {
+			return route, nil
+		}
+		if !OSRMEnabled() {
+			return nil, fmt.Errorf("no local route: %v", err)
+		}
+		route, osrmErr := osrmDirections(fromLat, fromLon, toLat, toLon, profile)
+		// OSRM's foot profile knows nothing of steps or slopes
+		if osrmErr == nil && profile.StepFree {
+			route.Summary += " · ⚠️ may include steps"
+		}
+		return route, osrmErr
+	}
+
+	if OSRMEnabled() {
+		route, err := osrmDirections(fromLat, fromLon, toLat, toLon, profile)
+		if err == nil {
+			return route, nil
+		}
+		log.Printf("[routing] OSRM %s failed, trying local graph: %v", profile.OSRM, err)
+	}
+	return RouteLocal(fromLat, fromLon, toLat, toLon, profile)
+}
+
+// osrmDirections asks OSRM for turn-by-turn directions
+func osrmDirections(fromLat, fromLon, toLat, toLon float64, profile *Profile) (*Route, error) {
+	url := fmt.Sprintf("%s/route/v1/%s/%f,%f;%f,%f?overview=full&geometries=geojson&steps=true",
+		osrmBaseURL, profile.OSRM, fromLon, fromLat, toLon, toLat)
 
 	resp, err := OSRMGet(url)
 	if err != nil {
@@ -46,11 +119,15 @@ func GetWalkingDirections(fromLat, fromLon, toLat, toLon float64) (*Route, error
 		Routes []struct {
 			Distance float64 `json:"distance"`
 			Duration float64 `json:"duration"`
-			Legs     []struct {
+			Geometry struct {
+				Coordinates [][]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Legs []struct {
 				Steps []struct {
 					Maneuver struct {
-						Type     string `json:"type"`
-						Modifier string `json:"modifier"`
+						Type     string    `json:"type"`
+						Modifier string    `json:"modifier"`
+						Location []float64 `json:"location"` // [lon, lat]
 					} `json:"maneuver"`
 					Name     string  `json:"name"`
 					Distance float64 `json:"distance"`
@@ -72,6 +149,13 @@ func GetWalkingDirections(fromLat, fromLon, toLat, toLon float64) (*Route, error
 	result := &Route{
 		TotalDist: route.Distance,
 		TotalTime: route.Duration,
+		Geometry:  route.Geometry.Coordinates,
+		Profile:   profile,
+	}
+	// OSRM demo server returns driving-speed times even for foot and bike
+	// profiles, so only trust its times for cars
+	if profile != ProfileDrive {
+		result.TotalTime = route.Distance / profile.Speed
 	}
 
 	// Combine short steps, skip trivial ones
@@ -81,35 +165,39 @@ func GetWalkingDirections(fromLat, fromLon, toLat, toLon float64) (*Route, error
 				continue
 			}
 
-			instruction := formatInstruction(step.Maneuver.Type, step.Maneuver.Modifier, step.Name)
+			instruction := formatInstruction(profile, step.Maneuver.Type, step.Maneuver.Modifier, step.Name)
 			if instruction == "" {
 				continue
 			}
 
-			result.Steps = append(result.Steps, RouteStep{
+			rs := RouteStep{
 				Instruction: instruction,
 				Distance:    step.Distance,
 				Duration:    step.Duration,
 				Name:        step.Name,
-			})
+			}
+			if len(step.Maneuver.Location) == 2 {
+				rs.Lon, rs.Lat = step.Maneuver.Location[0], step.Maneuver.Location[1]
+			}
+			result.Steps = append(result.Steps, rs)
 		}
 	}
 
 	// Build summary
-	result.Summary = formatRouteSummary(result.TotalDist, result.TotalTime)
+	result.Summary = formatRouteSummary(profile, result.TotalDist, result.TotalTime)
 
 	return result, nil
 }
 
-func formatInstruction(maneuverType, modifier, name string) string {
+func formatInstruction(profile *Profile, maneuverType, modifier, name string) string {
 	if maneuverType == "arrive" {
 		return "🏁 Arrive at destination"
 	}
 	if maneuverType == "depart" {
 		if name != "" {
-			return fmt.Sprintf("🚶 Start on %s", name)
+			return fmt.Sprintf("%s Start on %s", profile.Icon, name)
 		}
-		return "🚶 Start walking"
+		return fmt.Sprintf("%s Start %s", profile.Icon, profile.Verb)
 	}
 
 	// Direction emoji
@@ -145,29 +233,30 @@ func formatInstruction(maneuverType, modifier, name string) string {
 	return dir
 }
 
-func formatRouteSummary(distMeters, durSeconds float64) string {
-	var dist string
+// FormatDistance formats meters as "350 m" or "1.2 km"
+func FormatDistance(distMeters float64) string {
 	if distMeters >= 1000 {
-		dist = fmt.Sprintf("%.1f km", distMeters/1000)
-	} else {
-		dist = fmt.Sprintf("%.0f m", distMeters)
+		return fmt.Sprintf("%.1f km", distMeters/1000)
 	}
+	return fmt.Sprintf("%.0f m", distMeters)
+}
+
+func formatRouteSummary(profile *Profile, distMeters, durSeconds float64) string {
+	dist := FormatDistance(distMeters)
 
-	// Calculate walking time at 5 km/h (~83 m/min) instead of trusting OSRM
-	// OSRM demo server returns driving-speed times even for foot profile
-	mins := int(distMeters / 83)
+	mins := int(durSeconds / 60)
 	if mins < 1 {
-		return fmt.Sprintf("🚶 %s · less than 1 min", dist)
+		return fmt.Sprintf("%s %s · less than 1 min", profile.Icon, dist)
 	} else if mins < 60 {
-		return fmt.Sprintf("🚶 %s · %d min walk", dist, mins)
+		return fmt.Sprintf("%s %s · %d min %s", profile.Icon, dist, mins, profile.Noun)
 	} else {
 		hours := mins / 60
 		mins = mins % 60
-		return fmt.Sprintf("🚶 %s · %dh %dm walk", dist, hours, mins)
+		return fmt.Sprintf("%s %s · %dh %dm %s", profile.Icon, dist, hours, mins, profile.Noun)
 	}
 }
 
-// FormatDirections returns a formatted string of walking directions
+// FormatDirections returns a formatted string of directions
 func FormatDirections(route *Route) string {
 	return FormatDirectionsWithMap(route, 0, 0, 0, 0, "")
 }
@@ -178,6 +267,10 @@ func FormatDirectionsWithMap(route *Route, fromLat, fromLon, toLat, toLon float6
 		return "No route found"
 	}
 
+	if len(route.Legs) > 0 {
+		return formatJourney(route, fromLat, fromLon, toLat, toLon)
+	}
+
 	// Very short distance - you're basically there
 	if route.TotalDist < 50 {
 		return fmt.Sprintf("You're already there! (%.0fm away)", route.TotalDist)
@@ -202,8 +295,8 @@ func FormatDirectionsWithMap(route *Route, fromLat, fromLon, toLat, toLon float6
 
 	// Add Google Maps directions link
 	if fromLat != 0 && toLat != 0 {
-		mapURL := fmt.Sprintf("https://www.google.com/maps/dir/?api=1&origin=%.6f,%.6f&destination=%.6f,%.6f&travelmode=walking",
-			fromLat, fromLon, toLat, toLon)
+		mapURL := fmt.Sprintf("https://www.google.com/maps/dir/?api=1&origin=%.6f,%.6f&destination=%.6f,%.6f&travelmode=%s",
+			fromLat, fromLon, toLat, toLon, route.mode().MapMode)
 		lines = append(lines, "")
 		lines = append(lines, "🗺️ "+mapURL)
 	}
@@ -213,6 +306,25 @@ func FormatDirectionsWithMap(route *Route, fromLat, fromLon, toLat, toLon float6
 
 // SearchOSM searches for a place by name near a location
 func SearchOSM(query string, nearLat, nearLon float64) ([]*Entity, error) {
+	// Confident local matches avoid the Nominatim call
+	if local := GeocodeLocal(query, nearLat, nearLon, 5); len(local) > 0 && local[0].Confidence >= GeocodeConfident {
+		var entities []*Entity
+		for _, c := range local {
+			entities = append(entities, &Entity{
+				Type: EntityPlace,
+				Name: c.Name,
+				Lat:  c.Lat,
+				Lon:  c.Lon,
+				Data: map[string]interface{}{
+					"type":       c.Kind,
+					"confidence": c.Confidence,
+					"source":     "local",
+				},
+			})
+		}
+		return entities, nil
+	}
+
 	// Use Nominatim search with viewbox to prefer nearby results
 	url := fmt.Sprintf(
 		"https://nominatim.openstreetmap.org/search?q=%s&format=json&limit=5&viewbox=%f,%f,%f,%f&bounded=0",
@@ -274,7 +386,28 @@ type RouteGeometry struct {
 }
 
 // GetWalkingRoute returns the geometry of a walking route
+// Routes over the local street graph, asking OSRM where the graph has gaps.
+// Local routes only follow streets already known, with straight connectors
+// at the ends, so anything stored as streets comes from OSRMWalkingRoute
 func GetWalkingRoute(fromLat, fromLon, toLat, toLon float64) (*RouteGeometry, error) {
+	if route, err := RouteLocal(fromLat, fromLon, toLat, toLon, ProfileFoot); err == nil {
+		return &RouteGeometry{
+			Coordinates: route.Geometry,
+			Distance:    route.TotalDist,
+			Duration:    route.TotalTime,
+		}, nil
+	} else if !OSRMEnabled() {
+		return nil, fmt.Errorf("no local route: %v", err)
+	}
+	return OSRMWalkingRoute(fromLat, fromLon, toLat, toLon)
+}
+
+// OSRMWalkingRoute asks OSRM for a walking route, never the local graph.
+// Couriers and street indexing use it to learn streets we don't have
+func OSRMWalkingRoute(fromLat, fromLon, toLat, toLon float64) (*RouteGeometry, error) {
+	if !OSRMEnabled() {
+		return nil, fmt.Errorf("OSRM is off")
+	}
 	url := fmt.Sprintf("%s/route/v1/foot/%f,%f;%f,%f?overview=full&geometries=geojson",
 		osrmBaseURL, fromLon, fromLat, toLon, toLat)
 
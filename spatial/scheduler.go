@@ -0,0 +1,513 @@
+package spatial
+
+import (
+	"container/heap"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Agent scheduler. Every agent's periodic work (live refresh, POI index,
+// street index) is a task in one queue, run by a fixed pool of workers, so
+// hundreds of agents don't mean hundreds of goroutines queueing on the
+// external API limiter. Tasks wait in a timer heap until due, then in a ready
+// heap ordered by priority: agents users are near go first, agents nobody
+// has visited go last.
+//
+// Demand is the last time a user pinged inside an agent's area. An agent
+// idle for half an hour drops to a slow live cadence, and after six hours
+// it hibernates with no work queued until the next ping wakes it. Agents
+// with standing instructions never hibernate, they have something to watch.
+
+// Task kinds
+const (
+	TaskLive    = "live"    // Weather, prayer, arrivals and other live data
+	TaskIndex   = "index"   // POIs from Overpass
+	TaskStreets = "streets" // Street geometry
+)
+
+const (
+	SchedulerWorkers  = 4
+	liveInterval      = 30 * time.Second
+	liveJitter        = 5 * time.Second
+	liveStartSpread   = 30 * time.Second // Spread first refreshes after a restart
+	indexInterval     = 24 * time.Hour
+	visitNearbyWindow = 10 * time.Minute // A user this recent counts as nearby
+	visitRecentWindow = 24 * time.Hour
+	slowAfter         = 30 * time.Minute // Idle this long: slow cadence
+	slowInterval      = 5 * time.Minute
+	hibernateAfter    = 6 * time.Hour // Idle this long: no live data at all
+
+	// API calls in one live refresh: weather, prayer times and three TfL stop types
+	liveCallsPerRefresh = 5
+)
+
+// Tasks of these kinds run one at a time, they hammer Overpass or OSRM
+var taskKindLimit = map[string]int{
+	TaskIndex:   1,
+	TaskStreets: 1,
+}
+
+// Base priority by kind: live data goes stale fastest
+var taskKindPriority = map[string]float64{
+	TaskLive:    3,
+	TaskIndex:   2,
+	TaskStreets: 1,
+}
+
+type taskKey struct {
+	agentID string
+	kind    string
+}
+
+// scheduledTask is one piece of an agent's work
+type scheduledTask struct {
+	agent    *Entity
+	kind     string
+	due      time.Time
+	priority float64
+	repeat   bool // Rescheduled after each run
+	running  bool
+	index    int // Position in whichever heap holds it, -1 if none
+}
+
+// pendingQueue orders tasks by when they're due
+type pendingQueue []*scheduledTask
+
+func (q pendingQueue) Len() int           { return len(q) }
+func (q pendingQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+func (q pendingQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i]; q[i].index = i; q[j].index = j }
+func (q *pendingQueue) Push(x interface{}) {
+	t := x.(*scheduledTask)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+func (q *pendingQueue) Pop() interface{} {
+	old := *q
+	t := old[len(old)-1]
+	*q = old[:len(old)-1]
+	t.index = -1
+	return t
+}
+
+// readyQueue orders due tasks by priority, then by how long they've waited
+type readyQueue []*scheduledTask
+
+func (q readyQueue) Len() int { return len(q) }
+func (q readyQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].due.Before(q[j].due)
+}
+func (q readyQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i]; q[i].index = i; q[j].index = j }
+func (q *readyQueue) Push(x interface{}) {
+	t := x.(*scheduledTask)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+func (q *readyQueue) Pop() interface{} {
+	old := *q
+	t := old[len(old)-1]
+	*q = old[:len(old)-1]
+	t.index = -1
+	return t
+}
+
+// Scheduler runs agent tasks through a fixed worker pool
+type Scheduler struct {
+	mu      sync.Mutex
+	tasks   map[taskKey]*scheduledTask
+	pending pendingQueue
+	ready   readyQueue
+	running map[string]int       // Tasks running by kind
+	visits  map[string]time.Time // Agent ID -> last user nearby
+	wake    chan struct{}
+	work    *sync.Cond
+	run     func(agent *Entity, kind string)
+	workers int
+	started bool
+	ran     map[string]int // Tasks completed by kind
+
+	since      time.Time                // Idle time counts from here for agents never visited
+	lastLive   map[string]time.Time     // Agent ID -> last live refresh
+	saved      map[string]float64       // Agent ID -> live refreshes not run while idle
+	hibernated map[string]time.Time     // Agent ID -> when it hibernated
+	hibernate  func(agent *Entity) bool // Moves an idle agent to hibernating, false if it can't. Called with the lock held
+	keepAwake  func(agent *Entity) bool // Idle agents it holds to the slow cadence instead
+}
+
+// NewScheduler returns a scheduler that runs tasks with run, not yet started
+func NewScheduler(workers int, run func(agent *Entity, kind string)) *Scheduler {
+	s := &Scheduler{
+		tasks:   make(map[taskKey]*scheduledTask),
+		running: make(map[string]int),
+		visits:  make(map[string]time.Time),
+		ran:     make(map[string]int),
+		wake:    make(chan struct{}, 1),
+		run:     run,
+		workers: workers,
+
+		since:      time.Now(),
+		lastLive:   make(map[string]time.Time),
+		saved:      make(map[string]float64),
+		hibernated: make(map[string]time.Time),
+	}
+	s.work = sync.NewCond(&s.mu)
+	return s
+}
+
+var (
+	agentScheduler     *Scheduler
+	agentSchedulerOnce sync.Once
+)
+
+// AgentScheduler returns the scheduler for all agents, starting it on first use
+func AgentScheduler() *Scheduler {
+	agentSchedulerOnce.Do(func() {
+		agentScheduler = NewScheduler(SchedulerWorkers, runAgentTask)
+		agentScheduler.hibernate = hibernateAgent
+		agentScheduler.keepAwake = agentWatching
+		agentScheduler.Start()
+	})
+	return agentScheduler
+}
+
+// runAgentTask does one task's work
+func runAgentTask(agent *Entity, kind string) {
+	if !agentRuns(agent) {
+		return
+	}
+	switch kind {
+	case TaskLive:
+		updateLiveData(agent)
+		finishRefresh(agent)
+	case TaskIndex:
+		trackTaskRun(agent, TaskIndex, "osm", func() (int, error) { return IndexAgent(agent) })
+	case TaskStreets:
+		count := trackTaskRun(agent, TaskStreets, "osrm", func() (int, error) { return IndexStreetsAroundAgent(agent, 0) })
+		log.Printf("[streets] Background indexing complete for %s: %d routes", agent.Name, count)
+	}
+}
+
+// Start launches the dispatcher and workers
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go s.dispatch()
+	for i := 0; i < s.workers; i++ {
+		go s.worker()
+	}
+	log.Printf("[scheduler] Started with %d workers", s.workers)
+}
+
+// Schedule queues a task for an agent at a time. A task already queued for
+// the agent is brought forward if this is sooner; one running is left alone
+func (s *Scheduler) Schedule(agent *Entity, kind string, at time.Time, repeat bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := taskKey{agent.ID, kind}
+	if t, ok := s.tasks[key]; ok {
+		t.agent = agent
+		t.repeat = t.repeat || repeat
+		if !t.running && at.Before(t.due) && t.index >= 0 && !s.isReady(t) {
+			t.due = at
+			heap.Fix(&s.pending, t.index)
+			s.signal()
+		}
+		return
+	}
+	t := &scheduledTask{agent: agent, kind: kind, due: at, repeat: repeat, index: -1}
+	s.tasks[key] = t
+	heap.Push(&s.pending, t)
+	s.signal()
+}
+
+// isReady reports whether a task is in the ready heap rather than pending
+func (s *Scheduler) isReady(t *scheduledTask) bool {
+	return t.index >= 0 && t.index < len(s.ready) && s.ready[t.index] == t
+}
+
+// Visit records a user near an agent, which raises its tasks' priority
+// Returns how long the agent had been idle
+func (s *Scheduler) Visit(agent *Entity) time.Duration {
+	if agent == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	idle := s.idle(agent.ID, now)
+	s.visits[agent.ID] = now
+	delete(s.hibernated, agent.ID)
+	return idle
+}
+
+// idle is how long since a user was near an agent. Called with the lock held
+func (s *Scheduler) idle(agentID string, now time.Time) time.Duration {
+	last := s.since
+	if visited, ok := s.visits[agentID]; ok && visited.After(last) {
+		last = visited
+	}
+	return now.Sub(last)
+}
+
+// Idle is how long since a user was near an agent
+func (s *Scheduler) Idle(agentID string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.idle(agentID, time.Now())
+}
+
+// LastVisit is when a user was last near an agent, zero if not since start
+func (s *Scheduler) LastVisit(agentID string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.visits[agentID]
+}
+
+// Hibernating records an agent as hibernating from now, for CallsSaved
+func (s *Scheduler) Hibernating(agentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.hibernated[agentID]; !ok {
+		s.hibernated[agentID] = time.Now()
+	}
+}
+
+// CallsSaved estimates the API calls an agent hasn't made by slowing down or
+// hibernating, against a live refresh every 30s
+func (s *Scheduler) CallsSaved(agentID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	refreshes := s.saved[agentID]
+	if at, ok := s.hibernated[agentID]; ok {
+		// Still asleep: the gap so far isn't counted until the next refresh
+		refreshes += float64(time.Since(at)) / float64(liveInterval)
+	}
+	return int(refreshes * liveCallsPerRefresh)
+}
+
+// countSkipped adds the live refreshes an agent went without since its last
+// one. Called with the lock held
+func (s *Scheduler) countSkipped(agentID string, now time.Time) {
+	if last, ok := s.lastLive[agentID]; ok {
+		if gap := now.Sub(last); gap > liveInterval+liveJitter {
+			s.saved[agentID] += float64(gap)/float64(liveInterval) - 1
+		}
+	}
+	s.lastLive[agentID] = now
+}
+
+// priority ranks a task: base priority by kind, raised by recent users
+func (s *Scheduler) priority(t *scheduledTask, now time.Time) float64 {
+	p := taskKindPriority[t.kind]
+	visited, ok := s.visits[t.agent.ID]
+	switch {
+	case !ok:
+		// Nobody has been here since the server started
+	case now.Sub(visited) < visitNearbyWindow:
+		p += 10
+	case now.Sub(visited) < visitRecentWindow:
+		p += 5
+	}
+	return p
+}
+
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch moves tasks from pending to ready as they fall due
+func (s *Scheduler) dispatch() {
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		moved := false
+		for len(s.pending) > 0 && !s.pending[0].due.After(now) {
+			t := heap.Pop(&s.pending).(*scheduledTask)
+			t.priority = s.priority(t, now)
+			heap.Push(&s.ready, t)
+			moved = true
+		}
+		wait := time.Minute
+		if len(s.pending) > 0 {
+			wait = s.pending[0].due.Sub(now)
+		}
+		if moved {
+			s.work.Broadcast()
+		}
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// next takes the highest priority ready task whose kind has room to run
+// Called with the lock held
+func (s *Scheduler) next() *scheduledTask {
+	var skipped []*scheduledTask
+	defer func() {
+		for _, t := range skipped {
+			heap.Push(&s.ready, t)
+		}
+	}()
+	for len(s.ready) > 0 {
+		t := heap.Pop(&s.ready).(*scheduledTask)
+		if limit, ok := taskKindLimit[t.kind]; ok && s.running[t.kind] >= limit {
+			skipped = append(skipped, t)
+			continue
+		}
+		return t
+	}
+	return nil
+}
+
+func (s *Scheduler) worker() {
+	for {
+		s.mu.Lock()
+		t := s.next()
+		for t == nil {
+			s.work.Wait()
+			t = s.next()
+		}
+		t.running = true
+		s.running[t.kind]++
+		s.mu.Unlock()
+
+		s.runTask(t)
+		// Asked before taking the lock, it reads the agent's instructions
+		awake := s.keepAwake != nil && t.kind == TaskLive && s.keepAwake(t.agent)
+
+		s.mu.Lock()
+		t.running = false
+		s.running[t.kind]--
+		s.ran[t.kind]++
+		key := taskKey{t.agent.ID, t.kind}
+		now := time.Now()
+		if t.kind == TaskLive {
+			s.countSkipped(t.agent.ID, now)
+		}
+		// Decided and done under the lock, so a Visit lands either before,
+		// keeping the agent awake, or after, finding it asleep to wake
+		hibernate := t.kind == TaskLive && t.repeat && s.hibernate != nil && !awake &&
+			s.idle(t.agent.ID, now) >= hibernateAfter && s.hibernate(t.agent)
+		if next, ok := s.nextRun(t, now); ok && !hibernate {
+			t.due = next
+			heap.Push(&s.pending, t)
+			s.signal()
+		} else {
+			delete(s.tasks, key)
+		}
+		if hibernate {
+			s.hibernated[t.agent.ID] = now
+			s.remove(t.agent.ID)
+		}
+		// A kind-limited task finishing may free one that was waiting
+		s.work.Broadcast()
+		s.mu.Unlock()
+	}
+}
+
+// runTask runs a task, surviving a panic so one bad agent can't stop a worker
+func (s *Scheduler) runTask(t *scheduledTask) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[scheduler] %s task for %s panicked: %v", t.kind, t.agent.Name, r)
+		}
+	}()
+	s.run(t.agent, t.kind)
+}
+
+// nextRun is when a finished task runs again, false if it doesn't
+// Called with the lock held
+func (s *Scheduler) nextRun(t *scheduledTask, now time.Time) (time.Time, bool) {
+	if !t.repeat {
+		return time.Time{}, false
+	}
+	switch t.kind {
+	case TaskLive:
+		interval := liveInterval
+		if s.idle(t.agent.ID, now) >= slowAfter {
+			interval = slowInterval
+		}
+		return now.Add(interval + time.Duration(rand.Int63n(int64(liveJitter)))), true
+	case TaskIndex:
+		return now.Add(indexInterval), true
+	}
+	return time.Time{}, false
+}
+
+// Remove drops an agent's queued tasks; one already running finishes first
+func (s *Scheduler) Remove(agentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(agentID)
+}
+
+// remove drops an agent's queued tasks. Called with the lock held
+func (s *Scheduler) remove(agentID string) {
+	for key, t := range s.tasks {
+		if key.agentID != agentID {
+			continue
+		}
+		t.repeat = false
+		if t.running {
+			continue
+		}
+		if s.isReady(t) {
+			heap.Remove(&s.ready, t.index)
+		} else if t.index >= 0 {
+			heap.Remove(&s.pending, t.index)
+		}
+		delete(s.tasks, key)
+	}
+}
+
+// SchedulerStats describes the queue for /debug
+type SchedulerStats struct {
+	Workers int            `json:"workers"`
+	Pending int            `json:"pending"`
+	Ready   int            `json:"ready"`
+	Running map[string]int `json:"running"`
+	Ran     map[string]int `json:"ran"`
+}
+
+// Stats returns queue sizes and task counts
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := SchedulerStats{
+		Workers: s.workers,
+		Pending: len(s.pending),
+		Ready:   len(s.ready),
+		Running: make(map[string]int),
+		Ran:     make(map[string]int),
+	}
+	for k, n := range s.running {
+		stats.Running[k] = n
+	}
+	for k, n := range s.ran {
+		stats.Ran[k] = n
+	}
+	return stats
+}
+
+// liveStartDelay spreads agents' first live refreshes
+func liveStartDelay() time.Duration {
+	return time.Duration(rand.Int63n(int64(liveStartSpread)))
+}
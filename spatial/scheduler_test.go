@@ -0,0 +1,228 @@
+package spatial
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testQuietAgent = &Entity{ID: "agent-quiet", Name: "Quiet"}
+	testBusyAgent  = &Entity{ID: "agent-busy", Name: "Busy"}
+)
+
+// testSchedulerOrder runs one-off live tasks for a quiet agent and a busy
+// one a user is near, returning the scheduler and the tasks in order run
+func testSchedulerOrder(t *testing.T) (*Scheduler, []string) {
+	order := make(chan string, 10)
+	s := NewScheduler(1, func(agent *Entity, kind string) {
+		order <- agent.Name + "/" + kind
+	})
+	past := time.Now().Add(-time.Second)
+	s.Schedule(testQuietAgent, TaskLive, past, false)
+	s.Schedule(testBusyAgent, TaskLive, past, false)
+	s.Visit(testBusyAgent)
+	s.Start()
+
+	var ran []string
+	for len(ran) < 2 {
+		select {
+		case got := <-order:
+			ran = append(ran, got)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out, ran %v", ran)
+		}
+	}
+	return s, ran
+}
+
+// TestSchedulerVisitedFirst checks tasks for agents users are near run first
+func TestSchedulerVisitedFirst(t *testing.T) {
+	if _, ran := testSchedulerOrder(t); ran[0] != "Busy/live" || ran[1] != "Quiet/live" {
+		t.Errorf("ran %v", ran)
+	}
+}
+
+// TestSchedulerOneOff checks one-off tasks leave the queue once done
+func TestSchedulerOneOff(t *testing.T) {
+	s, _ := testSchedulerOrder(t)
+	time.Sleep(20 * time.Millisecond)
+	if stats := s.Stats(); stats.Pending != 0 || stats.Ready != 0 || stats.Ran[TaskLive] != 2 {
+		t.Errorf("one-off tasks left behind: %+v", stats)
+	}
+}
+
+// TestSchedulerKindLimit checks only one index task runs at a time,
+// however many workers are free
+func TestSchedulerKindLimit(t *testing.T) {
+	var s *Scheduler
+	var most int
+	done := make(chan bool, 2)
+	s = NewScheduler(2, func(agent *Entity, kind string) {
+		s.mu.Lock()
+		if s.running[TaskIndex] > most {
+			most = s.running[TaskIndex]
+		}
+		s.mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		done <- true
+	})
+	past := time.Now().Add(-time.Second)
+	s.Schedule(testQuietAgent, TaskIndex, past, false)
+	s.Schedule(testBusyAgent, TaskIndex, past, false)
+	s.Start()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for index tasks")
+		}
+	}
+	if most != 1 {
+		t.Errorf("%d index tasks ran at once, want 1", most)
+	}
+}
+
+// TestSchedulerDuplicate checks scheduling a queued task again moves it, not
+// queues another
+func TestSchedulerDuplicate(t *testing.T) {
+	s := NewScheduler(1, func(*Entity, string) {})
+	s.Schedule(testQuietAgent, TaskLive, time.Now().Add(time.Hour), true)
+	s.Schedule(testQuietAgent, TaskLive, time.Now().Add(time.Minute), true)
+	if stats := s.Stats(); stats.Pending != 1 {
+		t.Errorf("duplicate schedule queued %d tasks, want 1", stats.Pending)
+	}
+}
+
+// TestSchedulerRemove checks removing an agent drops its queued work
+func TestSchedulerRemove(t *testing.T) {
+	s := NewScheduler(1, func(*Entity, string) {})
+	s.Schedule(testQuietAgent, TaskLive, time.Now().Add(time.Hour), true)
+	s.Schedule(testQuietAgent, TaskIndex, time.Now().Add(time.Hour), true)
+	s.Remove(testQuietAgent.ID)
+	if stats := s.Stats(); stats.Pending != 0 {
+		t.Errorf("removed agent still has %d pending tasks", stats.Pending)
+	}
+}
+
+// testIdleScheduler is a scheduler started 7 hours ago: one agent visited a
+// minute ago, the quiet one an hour ago, the rest never. The watcher has
+// instructions; agents that hibernate are sent on slept
+func testIdleScheduler() (s *Scheduler, slept chan string) {
+	slept = make(chan string, 1)
+	s = NewScheduler(1, func(agent *Entity, kind string) {})
+	s.hibernate = func(agent *Entity) bool { slept <- agent.ID; return true }
+	s.keepAwake = func(agent *Entity) bool { return agent.ID == "agent-watcher" }
+	now := time.Now()
+	s.since = now.Add(-7 * time.Hour)
+	s.visits["agent-fresh"] = now.Add(-time.Minute)
+	s.visits[testQuietAgent.ID] = now.Add(-time.Hour)
+	return s, slept
+}
+
+// TestSchedulerSlowCadence checks agents idle half an hour refresh less often
+func TestSchedulerSlowCadence(t *testing.T) {
+	s, _ := testIdleScheduler()
+	now := time.Now()
+	for _, c := range []struct {
+		agent    *Entity
+		min, max time.Duration
+	}{
+		{&Entity{ID: "agent-fresh", Name: "Fresh"}, liveInterval, liveInterval + liveJitter},
+		{testQuietAgent, slowInterval, slowInterval + liveJitter},
+	} {
+		next, ok := s.nextRun(&scheduledTask{agent: c.agent, kind: TaskLive, repeat: true}, now)
+		if gap := next.Sub(now); !ok || gap < c.min || gap > c.max {
+			t.Errorf("%s next live in %v, want %v-%v", c.agent.Name, gap, c.min, c.max)
+		}
+	}
+}
+
+// TestSchedulerCallsSaved checks skipped refreshes count as API calls saved:
+// ten minutes since the last refresh is 19 skipped, 5 calls each
+func TestSchedulerCallsSaved(t *testing.T) {
+	s, _ := testIdleScheduler()
+	now := time.Now()
+	s.lastLive[testQuietAgent.ID] = now.Add(-10 * time.Minute)
+	s.countSkipped(testQuietAgent.ID, now)
+	if n := s.CallsSaved(testQuietAgent.ID); n != 95 {
+		t.Errorf("calls saved %d, want 95", n)
+	}
+}
+
+// TestSchedulerHibernate checks an agent nobody has been near for hours
+// runs its live task once more, then sleeps, unless it has instructions
+func TestSchedulerHibernate(t *testing.T) {
+	s, slept := testIdleScheduler()
+	past := time.Now().Add(-time.Second)
+	s.Schedule(&Entity{ID: "agent-gone", Name: "Gone"}, TaskLive, past, true)
+	s.Schedule(&Entity{ID: "agent-watcher", Name: "Watcher"}, TaskLive, past, true)
+	s.Start()
+	select {
+	case id := <-slept:
+		if id != "agent-gone" {
+			t.Errorf("hibernated %s, want agent-gone", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle agent never hibernated")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if stats := s.Stats(); stats.Pending != 1 {
+		t.Errorf("%d tasks pending, want the watcher's", stats.Pending)
+	}
+}
+
+// TestSchedulerVisitWakes checks a ping resets demand and stops the agent
+// counting as asleep
+func TestSchedulerVisitWakes(t *testing.T) {
+	s, _ := testIdleScheduler()
+	gone := &Entity{ID: "agent-gone", Name: "Gone"}
+	s.Hibernating(gone.ID)
+	if idle := s.Visit(gone); idle < hibernateAfter {
+		t.Errorf("idle %v before wake, want over %v", idle, hibernateAfter)
+	}
+	if s.Idle(gone.ID) > time.Second {
+		t.Error("visit didn't reset demand")
+	}
+	s.mu.Lock()
+	_, asleep := s.hibernated[gone.ID]
+	s.mu.Unlock()
+	if asleep {
+		t.Error("woken agent still marked hibernating")
+	}
+}
+
+// TestSchedulerHibernateVisitRace checks a ping arriving while an agent
+// goes to sleep finds it asleep, so it can wake it, rather than finding it
+// awake just before it sleeps with nothing queued
+func TestSchedulerHibernateVisitRace(t *testing.T) {
+	s, _ := testIdleScheduler()
+	gone := &Entity{ID: "agent-gone", Name: "Gone"}
+	var mu sync.Mutex
+	state := AgentActive
+	seen := make(chan string, 1)
+	s.hibernate = func(agent *Entity) bool {
+		// The user pings mid-transition
+		go func() {
+			s.Visit(agent)
+			mu.Lock()
+			seen <- state
+			mu.Unlock()
+		}()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		state = AgentHibernating
+		mu.Unlock()
+		return true
+	}
+	s.Schedule(gone, TaskLive, time.Now().Add(-time.Second), true)
+	s.Start()
+	select {
+	case got := <-seen:
+		if got != AgentHibernating {
+			t.Errorf("visit saw the agent %s, then it slept", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle agent never hibernated")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // APIStats tracks statistics for an API endpoint
@@ -25,6 +26,8 @@ type CacheStats struct {
 	mu            sync.RWMutex
 	LocationHits  int64
 	LocationMiss  int64
+	LocationLocal int64 // Misses answered by the offline geocoder
+	LocationNomi  int64 // Nominatim fallbacks
 	WeatherHits   int64
 	WeatherMiss   int64
 	PrayerHits    int64
@@ -54,6 +57,20 @@ func (c *CacheStats) RecordLocationMiss() {
 	c.mu.Unlock()
 }
 
+// RecordLocationLocal records a location answered from indexed data
+func (c *CacheStats) RecordLocationLocal() {
+	c.mu.Lock()
+	c.LocationLocal++
+	c.mu.Unlock()
+}
+
+// RecordLocationNominatim records a location that needed Nominatim
+func (c *CacheStats) RecordLocationNominatim() {
+	c.mu.Lock()
+	c.LocationNomi++
+	c.mu.Unlock()
+}
+
 // RecordPing records a ping timing
 func (c *CacheStats) RecordPing(durationMs int64) {
 	c.mu.Lock()
@@ -72,18 +89,21 @@ func (c *CacheStats) CacheSummary() map[string]interface{} {
 		avgPingMs = float64(c.PingTotalMs) / float64(c.PingCount)
 	}
 	
-	locTotal := c.LocationHits + c.LocationMiss
+	// Offline geocoder answers count as hits - no external call was made
+	locTotal := c.LocationHits + c.LocationLocal + c.LocationMiss
 	locHitRate := float64(0)
 	if locTotal > 0 {
-		locHitRate = float64(c.LocationHits) / float64(locTotal) * 100
+		locHitRate = float64(c.LocationHits+c.LocationLocal) / float64(locTotal) * 100
 	}
 	
 	return map[string]interface{}{
-		"ping_count":       c.PingCount,
-		"ping_avg_ms":      avgPingMs,
-		"location_hits":    c.LocationHits,
-		"location_misses":  c.LocationMiss,
-		"location_hit_pct": locHitRate,
+		"ping_count":         c.PingCount,
+		"ping_avg_ms":        avgPingMs,
+		"location_hits":      c.LocationHits,
+		"location_misses":    c.LocationMiss,
+		"location_local":     c.LocationLocal,
+		"location_nominatim": c.LocationNomi,
+		"location_hit_pct":   locHitRate,
 	}
 }
 
@@ -234,11 +254,12 @@ func formatTimeAgo(t time.Time) string {
 	return t.Format("Jan 2 15:04")
 }
 
+// truncate cuts s to n characters, never splitting one
 func truncate(s string, n int) string {
-	if len(s) <= n {
+	if utf8.RuneCountInString(s) <= n {
 		return s
 	}
-	return s[:n] + "..."
+	return string([]rune(s)[:n]) + "..."
 }
 
 func formatDuration(d time.Duration) string {
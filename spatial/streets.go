@@ -40,7 +40,13 @@ func (s *Street) PointsToInterface() []interface{} {
 
 // FetchStreetGeometry fetches the street geometry between two points from OSRM
 // Returns the decoded polyline as a series of [lon, lat] coordinates
+// Only OSRM is asked: the local graph would just hand back streets already
+// known, joined to the points by straight lines that aren't streets at all
 func FetchStreetGeometry(fromLat, fromLon, toLat, toLon float64) (*Street, error) {
+	if !OSRMEnabled() {
+		return nil, fmt.Errorf("street indexing needs OSRM")
+	}
+
 	// Use OSRM with full geometry
 	url := fmt.Sprintf("%s/route/v1/foot/%f,%f;%f,%f?overview=full&geometries=geojson",
 		osrmBaseURL, fromLon, fromLat, toLon, toLat)
@@ -109,14 +115,18 @@ func FetchStreetGeometry(fromLat, fromLon, toLat, toLon float64) (*Street, error
 // IndexStreetsAroundAgent fetches street geometry in the agent's area
 // by querying routes between the agent center and nearby POIs
 // maxRoutes limits how many routes to fetch (0 = all)
-func IndexStreetsAroundAgent(agent *Entity, maxRoutes int) int {
+// Returns the routes stored and the last route that failed
+func IndexStreetsAroundAgent(agent *Entity, maxRoutes int) (int, error) {
+	if !OSRMEnabled() {
+		return 0, nil
+	}
 	db := Get()
 	radius := 2000.0 // 2km
 
 	// Get nearby places to use as route endpoints
 	places := db.Query(agent.Lat, agent.Lon, radius, EntityPlace, 50)
 	if len(places) < 2 {
-		return 0
+		return 0, nil
 	}
 
 	// Get existing streets to avoid duplicates
@@ -132,6 +142,7 @@ func IndexStreetsAroundAgent(agent *Entity, maxRoutes int) int {
 	}
 
 	var count int
+	var lastErr error
 
 	// Fetch routes from agent center to each nearby place
 	for _, place := range places {
@@ -152,6 +163,7 @@ func IndexStreetsAroundAgent(agent *Entity, maxRoutes int) int {
 		street, err := FetchStreetGeometry(agent.Lat, agent.Lon, place.Lat, place.Lon)
 		if err != nil {
 			log.Printf("[streets] Failed to fetch route to %s: %v", place.Name, err)
+			lastErr = err
 			continue
 		}
 
@@ -186,13 +198,10 @@ func IndexStreetsAroundAgent(agent *Entity, maxRoutes int) int {
 		log.Printf("[streets] Indexed route to %s (%d points, %.0fm)", place.Name, len(street.Points), street.Length)
 	}
 
-	return count
+	return count, lastErr
 }
 
-// IndexStreetsAsync starts background street indexing for an agent
+// IndexStreetsAsync queues background street indexing for an agent
 func IndexStreetsAsync(agent *Entity) {
-	go func() {
-		count := IndexStreetsAroundAgent(agent, 0) // No limit in background
-		log.Printf("[streets] Background indexing complete for %s: %d routes", agent.Name, count)
-	}()
+	AgentScheduler().Schedule(agent, TaskStreets, time.Now(), false)
 }
@@ -108,6 +108,10 @@ func BackfillStreetsForAllPlaces(centerLat, centerLon float64, maxRadius float64
 
 // getStreetNameAt reverse geocodes to get the street name at a location
 func getStreetNameAt(lat, lon float64) string {
+	if addr := ReverseGeocodeLocal(lat, lon); addr != nil && addr.Street != "" {
+		return addr.Street
+	}
+
 	apiURL := fmt.Sprintf("https://nominatim.openstreetmap.org/reverse?lat=%f&lon=%f&format=json&zoom=18", lat, lon)
 	
 	resp, err := LocationGet(apiURL)
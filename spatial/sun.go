@@ -0,0 +1,90 @@
+package spatial
+
+import (
+	"math"
+	"time"
+)
+
+// Solar event angles below the horizon
+const (
+	sunriseZenith = 90.833 // Accounts for refraction and the sun's radius
+	civilZenith   = 96.0   // Civil dawn and dusk
+)
+
+// sunEventMinutes returns minutes after local midnight for a solar event on the
+// given day, using the NOAA sunrise equation. rising selects sunrise/dawn over
+// sunset/dusk. ok is false when the sun doesn't cross the zenith (polar day/night).
+func sunEventMinutes(day time.Time, lat, lon, zenith float64, rising bool) (int, bool) {
+	rad := math.Pi / 180
+
+	n := float64(day.YearDay())
+	lngHour := lon / 15
+
+	t := n + (18-lngHour)/24
+	if rising {
+		t = n + (6-lngHour)/24
+	}
+
+	// Sun's mean anomaly and true longitude
+	m := 0.9856*t - 3.289
+	l := m + 1.916*math.Sin(m*rad) + 0.020*math.Sin(2*m*rad) + 282.634
+	l = math.Mod(l+360, 360)
+
+	// Right ascension, in the same quadrant as l
+	ra := math.Atan(0.91764*math.Tan(l*rad)) / rad
+	ra = math.Mod(ra+360, 360)
+	ra += math.Floor(l/90)*90 - math.Floor(ra/90)*90
+	ra /= 15
+
+	sinDec := 0.39782 * math.Sin(l*rad)
+	cosDec := math.Cos(math.Asin(sinDec))
+
+	cosH := (math.Cos(zenith*rad) - sinDec*math.Sin(lat*rad)) / (cosDec * math.Cos(lat*rad))
+	if cosH > 1 || cosH < -1 {
+		return 0, false
+	}
+
+	h := math.Acos(cosH) / rad
+	if rising {
+		h = 360 - h
+	}
+	h /= 15
+
+	localMean := h + ra - 0.06571*t - 6.622
+	utcHours := math.Mod(localMean-lngHour+48, 24)
+
+	// Convert UTC hours to local minutes using the zone offset on that day
+	_, offset := day.Zone()
+	mins := int(math.Round(utcHours*60)) + offset/60
+	return (mins + 1440) % 1440, true
+}
+
+// sunEvent returns the local minutes for a named opening_hours sun event
+// Falls back to fixed times when the sun doesn't rise or set
+func sunEvent(event string, day time.Time, lat, lon float64) int {
+	var mins int
+	var ok bool
+	switch event {
+	case "sunrise":
+		mins, ok = sunEventMinutes(day, lat, lon, sunriseZenith, true)
+		if !ok {
+			mins = 6 * 60
+		}
+	case "sunset":
+		mins, ok = sunEventMinutes(day, lat, lon, sunriseZenith, false)
+		if !ok {
+			mins = 18 * 60
+		}
+	case "dawn":
+		mins, ok = sunEventMinutes(day, lat, lon, civilZenith, true)
+		if !ok {
+			mins = 5*60 + 30
+		}
+	case "dusk":
+		mins, ok = sunEventMinutes(day, lat, lon, civilZenith, false)
+		if !ok {
+			mins = 18*60 + 30
+		}
+	}
+	return mins
+}
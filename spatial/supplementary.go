@@ -2,88 +2,411 @@ package spatial
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-type SupplementaryCinema struct {
-	Name    string  `json:"name"`
-	Lat     float64 `json:"lat"`
-	Lon     float64 `json:"lon"`
-	Address string  `json:"address"`
-	Website string  `json:"website"`
+// Supplementary datasets fill gaps in OSM with curated lists (cinema chains,
+// mosques, food banks, late-night pharmacies). Each dataset lives in its own
+// directory under data/supplementary with a manifest.json describing how to
+// turn records into entities. They're merged into category searches
+// (QueryPlaces) only, so counts and "already indexed" checks see just the
+// DB. See data/supplementary/README.md.
+
+// Refresh policies for supplementary datasets
+const (
+	RefreshStatic = "static" // Load once at startup
+	RefreshReload = "reload" // Re-read the file when it changes
+	RefreshFetch  = "fetch"  // Download from a URL into the cache dir
+)
+
+const (
+	supplementaryCheckInterval = time.Minute
+	defaultRefreshInterval     = 24 * time.Hour
+)
+
+// DatasetManifest describes a supplementary dataset
+type DatasetManifest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Source      string            `json:"source"`
+	EntityType  EntityType        `json:"entity_type"`
+	Category    string            `json:"category"`
+	File        string            `json:"file"`
+	Records     string            `json:"records"` // Dot path to the record array, empty for a root array
+	Fields      map[string]string `json:"fields"`  // Entity field or tag -> record key
+	Tags        map[string]string `json:"tags"`    // Fixed tags for every record
+	Refresh     struct {
+		Policy   string `json:"policy"`
+		Interval string `json:"interval"`
+		URL      string `json:"url"`
+	} `json:"refresh"`
+}
+
+// interval returns how often the dataset should be refreshed
+func (m *DatasetManifest) interval() time.Duration {
+	if d, err := time.ParseDuration(m.Refresh.Interval); err == nil && d > 0 {
+		return d
+	}
+	return defaultRefreshInterval
 }
 
-type SupplementaryData struct {
-	Source  string                `json:"source"`
-	Updated string                `json:"updated"`
-	Cinemas []SupplementaryCinema `json:"cinemas"`
+// dataset is a loaded supplementary dataset
+type dataset struct {
+	manifest  DatasetManifest
+	dir       string
+	entities  []*Entity
+	modTime   time.Time
+	checkedAt time.Time
 }
 
-var supplementaryCinemas []SupplementaryCinema
+var (
+	datasets   = make(map[string]*dataset)
+	datasetsMu sync.RWMutex
+)
 
 func init() {
-	loadSupplementaryCinemas()
+	loadSupplementaryDatasets()
+	go refreshSupplementaryLoop()
 }
 
-func loadSupplementaryCinemas() {
-	// Find data directory relative to source file
+// supplementaryDir finds data/supplementary relative to the source file
+func supplementaryDir() string {
 	_, filename, _, ok := runtime.Caller(0)
 	if !ok {
-		return
+		return ""
 	}
-	dataDir := filepath.Join(filepath.Dir(filename), "..", "data")
-	cinemaFile := filepath.Join(dataDir, "cinemas.json")
+	return filepath.Join(filepath.Dir(filename), "..", "data", "supplementary")
+}
 
-	data, err := os.ReadFile(cinemaFile)
+// supplementaryCacheDir is where fetched datasets are downloaded, outside
+// the source tree
+func supplementaryCacheDir() string {
+	dir, err := os.UserCacheDir()
 	if err != nil {
-		log.Printf("[supplementary] Could not load cinemas.json: %v", err)
-		return
+		dir = os.TempDir()
 	}
+	return filepath.Join(dir, "malten", "supplementary")
+}
 
-	var sd SupplementaryData
-	if err := json.Unmarshal(data, &sd); err != nil {
-		log.Printf("[supplementary] Could not parse cinemas.json: %v", err)
+// loadSupplementaryDatasets reads every manifest in the supplementary directory
+func loadSupplementaryDatasets() {
+	dir := supplementaryDir()
+	if dir == "" {
+		return
+	}
+	manifests, err := filepath.Glob(filepath.Join(dir, "*", "manifest.json"))
+	if err != nil || len(manifests) == 0 {
+		log.Printf("[supplementary] No datasets in %s", dir)
 		return
 	}
 
-	supplementaryCinemas = sd.Cinemas
-	log.Printf("[supplementary] Loaded %d cinemas from %s (updated %s)", len(sd.Cinemas), sd.Source, sd.Updated)
+	for _, path := range manifests {
+		ds, err := loadDataset(path)
+		if err != nil {
+			log.Printf("[supplementary] %s: %v", path, err)
+			continue
+		}
+		datasetsMu.Lock()
+		datasets[ds.manifest.Name] = ds
+		datasetsMu.Unlock()
+	}
 }
 
-// GetSupplementaryCinemas returns cinemas within radius of location
-func GetSupplementaryCinemas(lat, lon, radiusMeters float64) []*Entity {
-	var results []*Entity
-	log.Printf("[supplementary] Searching cinemas within %.0fm of %.4f,%.4f", radiusMeters, lat, lon)
-
-	radiusKm := radiusMeters / 1000.0
-	for _, c := range supplementaryCinemas {
-		distKm := haversine(lat, lon, c.Lat, c.Lon)
-		if distKm <= radiusKm {
-			log.Printf("[supplementary] %s is %.1fkm away (within %.1fkm radius)", c.Name, distKm, radiusKm)
-			data := map[string]interface{}{
-				"category": "cinema",
-				"address":  c.Address,
-				"source":   "supplementary",
-				"distance": distKm * 1000, // convert to meters
+// loadDataset reads a manifest and its data file
+func loadDataset(manifestPath string) (*dataset, error) {
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var m DatasetManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("bad manifest: %v", err)
+	}
+	dir := filepath.Dir(manifestPath)
+	if m.Name == "" {
+		m.Name = filepath.Base(dir)
+	}
+	if m.EntityType == "" {
+		m.EntityType = EntityPlace
+	}
+	if m.Refresh.Policy == "" {
+		m.Refresh.Policy = RefreshStatic
+	}
+	if m.File == "" {
+		return nil, fmt.Errorf("manifest has no file")
+	}
+	if m.Fields["name"] == "" || m.Fields["lat"] == "" || m.Fields["lon"] == "" {
+		return nil, fmt.Errorf("manifest must map name, lat and lon")
+	}
+
+	ds := &dataset{manifest: m, dir: dir, checkedAt: time.Now()}
+	if err := ds.reload(); err != nil {
+		// A fetch dataset may not have been downloaded yet
+		if m.Refresh.Policy != RefreshFetch {
+			return nil, err
+		}
+		ds.checkedAt = time.Time{}
+		log.Printf("[supplementary] %s: %v, will fetch", m.Name, err)
+	}
+	return ds, nil
+}
+
+// cachePath is where a fetch dataset's downloads are written
+func (ds *dataset) cachePath() string {
+	return filepath.Join(supplementaryCacheDir(), ds.manifest.Name, ds.manifest.File)
+}
+
+// path is the data file to read: the latest download for a fetch dataset,
+// else the file next to the manifest, which seeds it until the first fetch
+func (ds *dataset) path() string {
+	if ds.manifest.Refresh.Policy == RefreshFetch {
+		if _, err := os.Stat(ds.cachePath()); err == nil {
+			return ds.cachePath()
+		}
+	}
+	return filepath.Join(ds.dir, ds.manifest.File)
+}
+
+// reload re-reads the dataset's data file
+func (ds *dataset) reload() error {
+	path := ds.path()
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	entities, err := parseDataset(&ds.manifest, b)
+	if err != nil {
+		return err
+	}
+
+	datasetsMu.Lock()
+	ds.entities = entities
+	ds.modTime = info.ModTime()
+	datasetsMu.Unlock()
+
+	log.Printf("[supplementary] Loaded %d %s from %s (%s)", len(entities), ds.manifest.Category, ds.manifest.Name, ds.manifest.Source)
+	return nil
+}
+
+// refreshSupplementaryLoop applies each dataset's refresh policy
+func refreshSupplementaryLoop() {
+	for {
+		time.Sleep(supplementaryCheckInterval)
+
+		datasetsMu.RLock()
+		var due []*dataset
+		for _, ds := range datasets {
+			if ds.manifest.Refresh.Policy != RefreshStatic && time.Since(ds.checkedAt) >= ds.manifest.interval() {
+				due = append(due, ds)
+			}
+		}
+		datasetsMu.RUnlock()
+
+		for _, ds := range due {
+			ds.refresh()
+		}
+	}
+}
+
+// refresh reloads a changed file or downloads a fresh copy
+func (ds *dataset) refresh() {
+	ds.checkedAt = time.Now()
+
+	switch ds.manifest.Refresh.Policy {
+	case RefreshReload:
+		info, err := os.Stat(ds.path())
+		if err != nil || !info.ModTime().After(ds.modTime) {
+			return
+		}
+	case RefreshFetch:
+		if ds.manifest.Refresh.URL == "" {
+			return
+		}
+		body, err := External.GetJSON("supplementary", ds.manifest.Refresh.URL)
+		if err != nil {
+			log.Printf("[supplementary] %s fetch error: %v", ds.manifest.Name, err)
+			return
+		}
+		// Validate before replacing the file so a bad download keeps the old data
+		if _, err := parseDataset(&ds.manifest, body); err != nil {
+			log.Printf("[supplementary] %s fetched bad data: %v", ds.manifest.Name, err)
+			return
+		}
+		path := ds.cachePath()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Printf("[supplementary] %s cache dir error: %v", ds.manifest.Name, err)
+			return
+		}
+		if err := os.WriteFile(path, body, 0644); err != nil {
+			log.Printf("[supplementary] %s write error: %v", ds.manifest.Name, err)
+			return
+		}
+	}
+
+	if err := ds.reload(); err != nil {
+		log.Printf("[supplementary] %s reload error: %v", ds.manifest.Name, err)
+	}
+}
+
+// parseDataset maps a data file's records to entities using the manifest
+func parseDataset(m *DatasetManifest, b []byte) ([]*Entity, error) {
+	var root interface{}
+	if err := json.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+	if m.Records != "" {
+		for _, key := range strings.Split(m.Records, ".") {
+			obj, ok := root.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("records path %q not found", m.Records)
 			}
-			if c.Website != "" {
-				data["website"] = c.Website
+			root = obj[key]
+		}
+	}
+	records, ok := root.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("records %q is not an array", m.Records)
+	}
+
+	var entities []*Entity
+	for _, r := range records {
+		rec, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := recordString(rec, m.Fields["name"])
+		lat, latOK := recordFloat(rec, m.Fields["lat"])
+		lon, lonOK := recordFloat(rec, m.Fields["lon"])
+		if name == "" || !latOK || !lonOK {
+			continue
+		}
+
+		tags := make(map[string]string)
+		for k, v := range m.Tags {
+			tags[k] = v
+		}
+		for field, key := range m.Fields {
+			if field == "name" || field == "lat" || field == "lon" {
+				continue
 			}
-			entity := &Entity{
-				ID:   GenerateID(EntityPlace, c.Lat, c.Lon, c.Name),
-				Type: EntityPlace,
-				Name: c.Name,
-				Lat:  c.Lat,
-				Lon:  c.Lon,
-				Data: data,
+			if v := recordString(rec, key); v != "" {
+				tags[field] = v
 			}
-			results = append(results, entity)
 		}
+		tags["name"] = name
+		tags["source"] = m.Source
+
+		e := &Entity{
+			ID:   GenerateID(m.EntityType, lat, lon, name),
+			Type: m.EntityType,
+			Name: name,
+			Lat:  lat,
+			Lon:  lon,
+		}
+		if m.EntityType == EntityPlace {
+			e.Data = &PlaceData{Category: m.Category, Tags: tags}
+		} else {
+			data := map[string]interface{}{"category": m.Category, "source": m.Source}
+			for k, v := range tags {
+				data[k] = v
+			}
+			e.Data = data
+		}
+		entities = append(entities, e)
+	}
+	return entities, nil
+}
+
+func recordString(rec map[string]interface{}, key string) string {
+	switch v := rec[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func recordFloat(rec map[string]interface{}, key string) (float64, bool) {
+	switch v := rec[key].(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// supplementaryNear returns dataset entities of a type (and category if set) within radius
+func supplementaryNear(lat, lon, radiusMeters float64, entityType EntityType, category string) []*Entity {
+	datasetsMu.RLock()
+	defer datasetsMu.RUnlock()
+
+	var results []*Entity
+	for _, ds := range datasets {
+		if ds.manifest.EntityType != entityType {
+			continue
+		}
+		if category != "" && ds.manifest.Category != category {
+			continue
+		}
+		for _, e := range ds.entities {
+			if haversine(lat, lon, e.Lat, e.Lon)*1000 <= radiusMeters {
+				results = append(results, e)
+			}
+		}
+	}
+	return results
+}
+
+// mergeSupplementary adds dataset entities not already in results (by name),
+// keeping results nearest first and within limit
+func mergeSupplementary(results []*Entity, lat, lon, radiusMeters float64, entityType EntityType, category string, limit int) []*Entity {
+	extra := supplementaryNear(lat, lon, radiusMeters, entityType, category)
+	if len(extra) == 0 {
+		return results
 	}
 
+	seen := make(map[string]bool)
+	for _, e := range results {
+		seen[strings.ToLower(e.Name)] = true
+	}
+	added := false
+	for _, e := range extra {
+		key := strings.ToLower(e.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		results = append(results, e)
+		added = true
+	}
+	if !added {
+		return results
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return haversine(lat, lon, results[i].Lat, results[i].Lon) < haversine(lat, lon, results[j].Lat, results[j].Lon)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
 	return results
 }
 
@@ -0,0 +1,100 @@
+package spatial
+
+import (
+	"strings"
+	"testing"
+)
+
+// testPharmacyManifest maps a nested JSON pharmacy list onto places
+func testPharmacyManifest() *DatasetManifest {
+	return &DatasetManifest{
+		Source:     "test",
+		EntityType: EntityPlace,
+		Category:   "pharmacy",
+		Records:    "data.items",
+		Fields: map[string]string{
+			"name": "title", "lat": "latitude", "lon": "longitude",
+			"opening_hours": "hours", "addr:full": "address",
+		},
+		Tags: map[string]string{"amenity": "pharmacy"},
+	}
+}
+
+const testPharmacyData = `{"data": {"items": [
+	{"title": "Late Chemist", "latitude": 51.45, "longitude": "-0.33", "hours": "Mo-Su 08:00-24:00", "address": "1 High St"},
+	{"title": "No Coordinates"},
+	{"title": "", "latitude": 51.4, "longitude": -0.3}
+]}}`
+
+// TestParseDataset checks manifest field mapping for supplementary datasets,
+// skipping rows without a name or coordinates
+func TestParseDataset(t *testing.T) {
+	entities, err := parseDataset(testPharmacyManifest(), []byte(testPharmacyData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entities) != 1 {
+		t.Fatalf("got %d entities, want 1", len(entities))
+	}
+	e := entities[0]
+	if e.Name != "Late Chemist" || e.Lat != 51.45 || e.Lon != -0.33 {
+		t.Errorf("entity = %s %.2f,%.2f", e.Name, e.Lat, e.Lon)
+	}
+	pd := e.GetPlaceData()
+	if pd == nil || pd.Category != "pharmacy" {
+		t.Fatalf("place data = %+v", pd)
+	}
+	want := map[string]string{"amenity": "pharmacy", "opening_hours": "Mo-Su 08:00-24:00", "addr:full": "1 High St", "source": "test"}
+	for k, v := range want {
+		if pd.Tags[k] != v {
+			t.Errorf("tag %s = %q, want %q", k, pd.Tags[k], v)
+		}
+	}
+}
+
+// TestParseDatasetMissingRecords checks a wrong records path is an error
+func TestParseDatasetMissingRecords(t *testing.T) {
+	m := testPharmacyManifest()
+	m.Records = "missing"
+	if _, err := parseDataset(m, []byte(testPharmacyData)); err == nil {
+		t.Error("expected error for missing records path")
+	}
+}
+
+// TestDatasetQueryPlaces checks datasets are merged into category searches,
+// not plain queries
+func TestDatasetQueryPlaces(t *testing.T) {
+	m := testPharmacyManifest()
+	entities, err := parseDataset(m, []byte(testPharmacyData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := entities[0]
+	datasetsMu.Lock()
+	datasets["test"] = &dataset{manifest: *m, entities: entities}
+	datasetsMu.Unlock()
+	defer func() {
+		datasetsMu.Lock()
+		delete(datasets, "test")
+		datasetsMu.Unlock()
+	}()
+
+	if got := Get().QueryPlaces(e.Lat, e.Lon, 100, "pharmacy", 10); len(got) == 0 || got[0].Name != e.Name {
+		t.Errorf("QueryPlaces didn't merge the dataset: %v", got)
+	}
+	for _, got := range Get().Query(e.Lat, e.Lon, 100, EntityPlace, 10) {
+		if got.Name == e.Name {
+			t.Error("Query returned a dataset row")
+		}
+	}
+}
+
+// TestDatasetCachePath checks fetched files go to the cache dir, not next
+// to the manifest
+func TestDatasetCachePath(t *testing.T) {
+	ds := &dataset{manifest: *testPharmacyManifest(), dir: "testdata"}
+	ds.manifest.Refresh.Policy = RefreshFetch
+	if strings.HasPrefix(ds.cachePath(), "testdata") || !strings.Contains(ds.cachePath(), "supplementary") {
+		t.Errorf("cache path %s", ds.cachePath())
+	}
+}
@@ -0,0 +1,290 @@
+package spatial
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Agent tasks. Each live refresh an agent runs the tasks registered for its
+// region, so adding a data source for a city is a RegisterAgentTask call in
+// its own file rather than another line in updateLiveData.
+
+// AgentTask is one source of live data an agent keeps fresh
+type AgentTask interface {
+	Name() string
+	Interval() time.Duration        // Least time between runs, 0 for every refresh
+	AppliesTo(region *Region) bool  // Region is nil outside known regions
+	Run(agent *Entity) (int, error) // Returns the number of entities stored
+	Produces() []EntityType
+	APIName() string // External API it calls, as counted in stats, for health
+}
+
+// LiveTask is an AgentTask made from a function
+type LiveTask struct {
+	TaskName string
+	Every    time.Duration
+	Regions  []string // Region names, empty for everywhere
+	Fn       func(agent *Entity) (int, error)
+	Types    []EntityType
+	API      string
+}
+
+func (t *LiveTask) Name() string                   { return t.TaskName }
+func (t *LiveTask) Interval() time.Duration        { return t.Every }
+func (t *LiveTask) Produces() []EntityType         { return t.Types }
+func (t *LiveTask) Run(agent *Entity) (int, error) { return t.Fn(agent) }
+func (t *LiveTask) APIName() string                { return t.API }
+
+func (t *LiveTask) AppliesTo(region *Region) bool {
+	if len(t.Regions) == 0 {
+		return true
+	}
+	if region == nil {
+		return false
+	}
+	for _, name := range t.Regions {
+		if name == region.Name {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	agentTasks   []AgentTask
+	agentTasksMu sync.RWMutex
+
+	taskRuns   = make(map[string]time.Time) // "agentID/task" -> last run
+	taskRunsMu sync.Mutex
+)
+
+// RegisterAgentTask adds a task, replacing one with the same name
+func RegisterAgentTask(task AgentTask) {
+	agentTasksMu.Lock()
+	defer agentTasksMu.Unlock()
+	for i, t := range agentTasks {
+		if t.Name() == task.Name() {
+			agentTasks[i] = task
+			return
+		}
+	}
+	agentTasks = append(agentTasks, task)
+}
+
+// regionTasks returns the tasks for an agent's region, in registration order
+func regionTasks(agent *Entity) []AgentTask {
+	region := GetRegion(agent.Lat, agent.Lon)
+	agentTasksMu.RLock()
+	defer agentTasksMu.RUnlock()
+	var tasks []AgentTask
+	for _, t := range agentTasks {
+		if t.AppliesTo(region) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// AgentTasks returns the tasks an agent runs: its own set if it has one,
+// otherwise all of its region's
+func AgentTasks(agent *Entity) []AgentTask {
+	tasks := regionTasks(agent)
+	agentData := agent.GetAgentData()
+	if agentData == nil || len(agentData.Tasks) == 0 {
+		return tasks
+	}
+	chosen := make(map[string]bool)
+	for _, name := range agentData.Tasks {
+		chosen[name] = true
+	}
+	var own []AgentTask
+	for _, t := range tasks {
+		if chosen[t.Name()] {
+			own = append(own, t)
+		}
+	}
+	return own
+}
+
+// SetAgentTasks sets the tasks an agent runs, from those its region has.
+// No names puts it back on the region's full set
+func SetAgentTasks(agent *Entity, names []string) error {
+	available := make(map[string]bool)
+	var all []string
+	for _, t := range regionTasks(agent) {
+		available[t.Name()] = true
+		all = append(all, t.Name())
+	}
+	for _, name := range names {
+		if !available[name] {
+			return fmt.Errorf("no task %q here, want some of %s", name, strings.Join(all, ", "))
+		}
+	}
+
+	// The stored agent, read under the lifecycle lock, is the one changed:
+	// the caller's copy may be stale, and writing it back would undo a move
+	// or instructions since, or bring back a deleted agent
+	agentControlsMu.Lock()
+	defer agentControlsMu.Unlock()
+	current := Get().GetByID(agent.ID)
+	if current == nil {
+		return fmt.Errorf("no agent %s", agent.ID)
+	}
+	c := control(current)
+	if c.state == AgentDeleted {
+		return fmt.Errorf("agent is deleted")
+	}
+	agentData := current.GetAgentData()
+	if agentData == nil {
+		agentData = &AgentEntityData{Radius: AgentRadius, Status: c.state}
+	}
+	agentData.Tasks = names
+	current.Data = agentData
+	Get().Insert(current)
+	return nil
+}
+
+// AgentTaskNames lists the names of an agent's tasks
+func AgentTaskNames(agent *Entity) []string {
+	var names []string
+	for _, t := range AgentTasks(agent) {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// taskDue reports whether a task's interval has passed for an agent, and if
+// so marks it run
+func taskDue(agent *Entity, task AgentTask, now time.Time) bool {
+	key := agent.ID + "/" + task.Name()
+	taskRunsMu.Lock()
+	defer taskRunsMu.Unlock()
+	if last, ok := taskRuns[key]; ok && now.Sub(last) < task.Interval() {
+		return false
+	}
+	taskRuns[key] = now
+	return true
+}
+
+// forgetTaskRuns drops a deleted agent's task history
+func forgetTaskRuns(agentID string) {
+	taskRunsMu.Lock()
+	defer taskRunsMu.Unlock()
+	for key := range taskRuns {
+		if strings.HasPrefix(key, agentID+"/") {
+			delete(taskRuns, key)
+		}
+	}
+}
+
+// runLiveTask runs one task, recording it in the agent's health history
+// and surviving a panic so the others still run
+func runLiveTask(agent *Entity, task AgentTask) int {
+	return trackTaskRun(agent, task.Name(), task.APIName(), func() (int, error) { return task.Run(agent) })
+}
+
+// countEntity is 1 for a stored entity, 0 for nil, passing the error on
+func countEntity(e *Entity, err error) (int, error) {
+	if e == nil {
+		return 0, err
+	}
+	return 1, err
+}
+
+// countEntities is the number of stored entities, passing the error on
+func countEntities(entities []*Entity, err error) (int, error) {
+	return len(entities), err
+}
+
+// Built-in tasks. Most fetchers keep their own caches, so running them every
+// refresh is cheap when nothing is due
+func init() {
+	london := []string{"london"}
+	for _, t := range []*LiveTask{
+		{
+			TaskName: "location",
+			Fn:       func(a *Entity) (int, error) { return countEntity(fetchLocation(a.Lat, a.Lon)) },
+			Types:    []EntityType{EntityLocation},
+			API:      "location",
+		},
+		{
+			TaskName: "weather",
+			Fn:       func(a *Entity) (int, error) { return countEntity(fetchWeather(a.Lat, a.Lon)) },
+			Types:    []EntityType{EntityWeather},
+			API:      "weather",
+		},
+		{
+			TaskName: "prayer",
+			Fn:       func(a *Entity) (int, error) { return countEntity(fetchPrayerTimes(a.Lat, a.Lon)) },
+			Types:    []EntityType{EntityPrayer},
+			API:      "prayer",
+		},
+		{
+			TaskName: "tfl_bus",
+			Regions:  london,
+			Fn: func(a *Entity) (int, error) {
+				arrivals, err := fetchTransportArrivals(a.Lat, a.Lon, "NaptanPublicBusCoachTram", "🚌")
+				// Empty means the API gave nothing, keep what we have a while
+				// longer; nil means the cache was fresh enough to skip
+				if arrivals != nil && len(arrivals) == 0 {
+					Get().ExtendArrivalsTTL(a.Lat, a.Lon, 500)
+				}
+				return len(arrivals), err
+			},
+			Types: []EntityType{EntityArrival},
+			API:   "tfl",
+		},
+		{
+			TaskName: "tfl_tube",
+			Regions:  london,
+			Fn: func(a *Entity) (int, error) {
+				return countEntities(fetchTransportArrivals(a.Lat, a.Lon, "NaptanMetroStation", "🚇"))
+			},
+			Types: []EntityType{EntityArrival},
+			API:   "tfl",
+		},
+		{
+			TaskName: "tfl_rail",
+			Regions:  london,
+			Fn: func(a *Entity) (int, error) {
+				return countEntities(fetchTransportArrivals(a.Lat, a.Lon, "NaptanRailStation", "🚆"))
+			},
+			Types: []EntityType{EntityArrival},
+			API:   "tfl",
+		},
+		{
+			// One London-wide fetch shared by all agents
+			TaskName: "tfl_disruptions",
+			Regions:  london,
+			Fn:       func(a *Entity) (int, error) { return countEntities(fetchTrafficDisruptions(a.Lat, a.Lon)) },
+			Types:    []EntityType{EntityDisruption},
+			API:      "tfl",
+		},
+		{
+			// One London-wide fetch shared by all agents
+			TaskName: "tfl_line_status",
+			Regions:  london,
+			Fn:       func(a *Entity) (int, error) { return countEntities(fetchLineStatus(a.Lat, a.Lon)) },
+			Types:    []EntityType{EntityLineStatus},
+			API:      "tfl",
+		},
+		{
+			// Each feed fetched at most once per newsTTL
+			TaskName: "news",
+			Fn:       func(a *Entity) (int, error) { return fetchNewsFeeds(a.Lat, a.Lon) },
+			Types:    []EntityType{EntityNews},
+			API:      "news",
+		},
+		{
+			// Each network fetched at most once per bikeFetchInterval
+			TaskName: "bikes",
+			Fn:       func(a *Entity) (int, error) { return countEntities(fetchBikeDocks(a.Lat, a.Lon)) },
+			Types:    []EntityType{EntityBikeDock},
+			API:      "gbfs",
+		},
+	} {
+		RegisterAgentTask(t)
+	}
+}
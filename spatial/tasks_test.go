@@ -0,0 +1,129 @@
+package spatial
+
+import (
+	"testing"
+	"time"
+)
+
+// hasTask reports whether a list of task names has one
+func hasTask(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// testOwnTasksAgent stores an agent in London, removed after the test
+func testOwnTasksAgent(t *testing.T) *Entity {
+	own := &Entity{ID: "agent-tasks-own", Type: EntityAgent, Lat: 51.5007, Lon: -0.1246,
+		Data: &AgentEntityData{Radius: AgentRadius, Status: AgentActive}}
+	Get().Insert(own)
+	t.Cleanup(func() { Get().Delete(own.ID) })
+	return own
+}
+
+// testDublinTask registers an hourly Dublin task for the test
+func testDublinTask(t *testing.T) *LiveTask {
+	task := &LiveTask{TaskName: "test_dublin", Every: time.Hour, Regions: []string{"dublin"},
+		Fn: func(*Entity) (int, error) { return 1, nil }}
+	RegisterAgentTask(task)
+	t.Cleanup(func() {
+		agentTasksMu.Lock()
+		agentTasks = agentTasks[:len(agentTasks)-1]
+		agentTasksMu.Unlock()
+	})
+	return task
+}
+
+// TestRegionTasks checks each region gets its own task set
+func TestRegionTasks(t *testing.T) {
+	for _, c := range []struct {
+		agent     *Entity
+		want, not string
+	}{
+		{&Entity{ID: "agent-tasks-london", Lat: 51.5007, Lon: -0.1246}, "tfl_bus", "irish_rail"},
+		{&Entity{ID: "agent-tasks-dublin", Lat: 53.349, Lon: -6.260}, "irish_rail", "tfl_bus"},
+		{&Entity{ID: "agent-tasks-manchester", Lat: 53.4808, Lon: -2.2426}, "metrolink", "irish_rail"},
+	} {
+		names := AgentTaskNames(c.agent)
+		if !hasTask(names, "weather") || !hasTask(names, c.want) || hasTask(names, c.not) {
+			t.Errorf("tasks at %.2f,%.2f: %v", c.agent.Lat, c.agent.Lon, names)
+		}
+	}
+}
+
+// TestSetAgentTasks checks an agent can keep to some of its region's tasks
+func TestSetAgentTasks(t *testing.T) {
+	own := testOwnTasksAgent(t)
+	if err := SetAgentTasks(own, []string{"weather", "tfl_tube"}); err != nil {
+		t.Fatal(err)
+	}
+	if names := AgentTaskNames(own); len(names) != 2 || names[0] != "weather" || names[1] != "tfl_tube" {
+		t.Errorf("own tasks: %v", names)
+	}
+}
+
+// TestSetAgentTasksStale checks setting tasks through a stale copy of an
+// agent changes only the stored agent's tasks
+func TestSetAgentTasksStale(t *testing.T) {
+	own := testOwnTasksAgent(t)
+	stale := &Entity{ID: own.ID, Type: EntityAgent, Lat: own.Lat, Lon: own.Lon,
+		Data: &AgentEntityData{Radius: AgentRadius, Status: AgentActive}}
+	own.Data.(*AgentEntityData).Instructions = []AgentInstruction{{ID: "weather", Prompt: "weather"}}
+	Get().Insert(own)
+
+	if err := SetAgentTasks(stale, []string{"weather"}); err != nil {
+		t.Fatal(err)
+	}
+	ad := Get().GetByID(own.ID).GetAgentData()
+	if len(ad.Instructions) != 1 || len(ad.Tasks) != 1 {
+		t.Errorf("stored agent: %d instructions, tasks %v", len(ad.Instructions), ad.Tasks)
+	}
+}
+
+// TestSetAgentTasksOtherRegion checks another region's task is refused
+func TestSetAgentTasksOtherRegion(t *testing.T) {
+	if err := SetAgentTasks(testOwnTasksAgent(t), []string{"irish_rail"}); err == nil {
+		t.Error("Dublin task accepted in London")
+	}
+}
+
+// TestSetAgentTasksReset checks no names puts an agent back on all its region's tasks
+func TestSetAgentTasksReset(t *testing.T) {
+	own := testOwnTasksAgent(t)
+	SetAgentTasks(own, []string{"weather"})
+	SetAgentTasks(own, nil)
+	if names := AgentTaskNames(own); !hasTask(names, "tfl_bus") {
+		t.Errorf("tasks after reset: %v", names)
+	}
+}
+
+// TestStoredAgentTasks checks an agent's own tasks are read back from storage
+func TestStoredAgentTasks(t *testing.T) {
+	if ad := agentDataFromMap(map[string]interface{}{"tasks": []interface{}{"weather"}}); len(ad.Tasks) != 1 {
+		t.Errorf("stored tasks: %v", ad.Tasks)
+	}
+}
+
+// TestRegisterAgentTask checks a task registered elsewhere joins its region's set only
+func TestRegisterAgentTask(t *testing.T) {
+	testDublinTask(t)
+	dublin := &Entity{ID: "agent-tasks-dublin", Lat: 53.349, Lon: -6.260}
+	london := &Entity{ID: "agent-tasks-london", Lat: 51.5007, Lon: -0.1246}
+	if !hasTask(AgentTaskNames(dublin), "test_dublin") || hasTask(AgentTaskNames(london), "test_dublin") {
+		t.Error("custom task not limited to its region")
+	}
+}
+
+// TestTaskDue checks a task's interval is honoured
+func TestTaskDue(t *testing.T) {
+	task := testDublinTask(t)
+	dublin := &Entity{ID: "agent-tasks-due", Lat: 53.349, Lon: -6.260}
+	t.Cleanup(func() { forgetTaskRuns(dublin.ID) })
+	now := time.Now()
+	if !taskDue(dublin, task, now) || taskDue(dublin, task, now.Add(time.Minute)) || !taskDue(dublin, task, now.Add(2*time.Hour)) {
+		t.Error("task interval not honoured")
+	}
+}
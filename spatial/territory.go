@@ -0,0 +1,481 @@
+package spatial
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Agent territories. Each agent owns one geohash tile and no two tiles
+// overlap, so every point is covered by exactly one agent once someone has
+// been there. New agents get a precision 5 tile (about 4.9 x 3km in
+// London), the area agents covered before tiling. A periodic rebalance
+// splits busy tiles into their occupied children and folds quiet siblings
+// into their parent, then hands each place (its agent_id) to whichever
+// agent now owns it.
+//
+// Place counts come from IndexAgent, which keeps at most indexCategoryLimit
+// places per category from a circle reaching the tile's corners, a quarter
+// or so of them inside the tile at UK latitudes. So a full city tile holds
+// some 400 places, never thousands, and the thresholds are set in those terms.
+
+const (
+	tileMinPrecision = 4              // ~39 x 20km, the most a quiet rural agent covers
+	tilePrecision    = 5              // ~4.9 x 4.9km at the equator for new agents
+	tileMaxPrecision = 7              // ~150m, the least a busy agent shrinks to
+	tileSettle       = 24 * time.Hour // Agents younger than this aren't merged, their index may be due
+)
+
+var (
+	// A tile splits when it holds more than a fifth of a full index
+	tileSplitPlaces = len(indexCategories) * indexCategoryLimit / 5
+	// Siblings merge when they hold fewer places between them than two full categories
+	tileMergePlaces = 2 * indexCategoryLimit
+)
+
+var (
+	territories   map[string]string // Tile -> agent ID, nil until loaded
+	territoriesMu sync.Mutex
+)
+
+// tileStat is the places under a tile and the sum of their coordinates
+type tileStat struct {
+	places   int
+	lat, lon float64
+}
+
+func (s *tileStat) count() int {
+	if s == nil {
+		return 0
+	}
+	return s.places
+}
+
+// centroid is where a tile's places are, or its middle when it has none
+func (s *tileStat) centroid(tile string) (float64, float64) {
+	if s.count() == 0 {
+		return GeohashCenter(tile)
+	}
+	return s.lat / float64(s.places), s.lon / float64(s.places)
+}
+
+// territoryChange is one split or merge planned by a rebalance
+type territoryChange struct {
+	Split bool
+	Tile  string   // The tile split, or the parent merged into
+	Keep  string   // Agent that stays: takes the first child, or the parent
+	Tiles []string // Split: occupied children, busiest first
+	Drop  []string // Merge: agents folded into Keep
+}
+
+// AgentTile returns the tile an agent owns
+func AgentTile(agent *Entity) string {
+	if ad := agent.GetAgentData(); ad != nil {
+		return ad.Tile
+	}
+	return ""
+}
+
+// tileFree reports whether a tile overlaps none already owned
+func tileFree(tiles map[string]string, tile string) bool {
+	for t := range tiles {
+		if strings.HasPrefix(tile, t) || strings.HasPrefix(t, tile) {
+			return false
+		}
+	}
+	return true
+}
+
+// tileOwner returns the tile covering a point and the agent owning it
+func tileOwner(tiles map[string]string, lat, lon float64) (string, string) {
+	hash := Geohash(lat, lon, tileMaxPrecision)
+	for p := tileMinPrecision; p <= tileMaxPrecision; p++ {
+		if id, ok := tiles[hash[:p]]; ok {
+			return hash[:p], id
+		}
+	}
+	return "", ""
+}
+
+// newTile picks the tile for a new agent at an uncovered point: precision
+// 5, or smaller where part of that cell is already owned after a split
+func newTile(tiles map[string]string, lat, lon float64) string {
+	hash := Geohash(lat, lon, tileMaxPrecision)
+	for p := tilePrecision; p <= tileMaxPrecision; p++ {
+		if tileFree(tiles, hash[:p]) {
+			return hash[:p]
+		}
+	}
+	return ""
+}
+
+// tileReach is the radius from a point that takes in the whole tile
+func tileReach(tile string, lat, lon float64) float64 {
+	minLat, maxLat, minLon, maxLon := GeohashBounds(tile)
+	reach := 0.0
+	for _, c := range [][2]float64{{minLat, minLon}, {minLat, maxLon}, {maxLat, minLon}, {maxLat, maxLon}} {
+		reach = math.Max(reach, haversineMeters(lat, lon, c[0], c[1]))
+	}
+	return math.Ceil(reach/100) * 100
+}
+
+// claimTile gives an agent a tile, dropping any it had, and sizes its
+// radius to reach the whole tile from lat, lon. Called with territoriesMu held
+func claimTile(agent *Entity, tile string, lat, lon float64) {
+	for t, id := range territories {
+		if id == agent.ID {
+			delete(territories, t)
+		}
+	}
+	territories[tile] = agent.ID
+
+	agentData := agent.GetAgentData()
+	if agentData == nil {
+		agentData = &AgentEntityData{Status: AgentActive}
+	}
+	agentData.Tile = tile
+	agentData.Radius = tileReach(tile, lat, lon)
+	agent.Data = agentData
+	Get().Insert(agent)
+}
+
+// loadTerritoriesLocked builds the tile index from stored agents. Agents from
+// before tiling then claim the tile at their spot if it is free; the rest
+// overlap another agent and are folded into it by the next rebalance.
+// Called with territoriesMu held
+func (d *DB) loadTerritoriesLocked() {
+	if territories != nil {
+		return
+	}
+	territories = make(map[string]string)
+	agents := d.ListAgents()
+	sort.Slice(agents, func(i, j int) bool { return agents[i].CreatedAt.Before(agents[j].CreatedAt) })
+	for _, a := range agents {
+		if tile := AgentTile(a); tile != "" && tileFree(territories, tile) {
+			territories[tile] = a.ID
+		}
+	}
+	for _, a := range agents {
+		if AgentTile(a) != "" {
+			continue
+		}
+		if tile := newTile(territories, a.Lat, a.Lon); tile != "" {
+			claimTile(a, tile, a.Lat, a.Lon)
+		}
+	}
+	log.Printf("[territory] %d agents own %d tiles", len(agents), len(territories))
+}
+
+// TerritoryAgent returns the agent whose tile covers a location
+func (d *DB) TerritoryAgent(lat, lon float64) *Entity {
+	territoriesMu.Lock()
+	defer territoriesMu.Unlock()
+	d.loadTerritoriesLocked()
+	return d.territoryAgentLocked(lat, lon)
+}
+
+func (d *DB) territoryAgentLocked(lat, lon float64) *Entity {
+	tile, id := tileOwner(territories, lat, lon)
+	if id == "" {
+		return nil
+	}
+	agent := d.GetByID(id)
+	if agent == nil {
+		delete(territories, tile) // Deleted behind our back
+	}
+	return agent
+}
+
+// releaseTerritory frees a deleted agent's tile
+func releaseTerritory(agentID string) {
+	territoriesMu.Lock()
+	defer territoriesMu.Unlock()
+	for t, id := range territories {
+		if id == agentID {
+			delete(territories, t)
+		}
+	}
+}
+
+// retile keeps a moving agent's territory right: within its own tile it
+// just resizes, elsewhere it takes a free tile at the new spot
+func retile(agent *Entity, lat, lon float64) error {
+	territoriesMu.Lock()
+	defer territoriesMu.Unlock()
+	Get().loadTerritoriesLocked()
+
+	tile, owner := tileOwner(territories, lat, lon)
+	switch {
+	case owner == agent.ID:
+		claimTile(agent, tile, lat, lon)
+	case owner != "":
+		return fmt.Errorf("tile %s already has an agent", tile)
+	default:
+		// Leaving its tile frees it, so the new one can be the full size
+		for t, id := range territories {
+			if id == agent.ID {
+				delete(territories, t)
+			}
+		}
+		claimTile(agent, newTile(territories, lat, lon), lat, lon)
+	}
+	return nil
+}
+
+// TerritoryStats counts owned tiles by geohash precision
+func TerritoryStats() map[int]int {
+	territoriesMu.Lock()
+	defer territoriesMu.Unlock()
+	Get().loadTerritoriesLocked()
+	stats := make(map[int]int)
+	for t := range territories {
+		stats[len(t)]++
+	}
+	return stats
+}
+
+// placeStats counts places under every tile from min to max precision
+func (d *DB) placeStats() map[string]*tileStat {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	stats := make(map[string]*tileStat)
+	for _, point := range d.entities {
+		e, ok := point.Data().(*Entity)
+		if !ok || e.Type != EntityPlace {
+			continue
+		}
+		hash := Geohash(e.Lat, e.Lon, tileMaxPrecision)
+		for p := tileMinPrecision; p <= tileMaxPrecision; p++ {
+			s := stats[hash[:p]]
+			if s == nil {
+				s = &tileStat{}
+				stats[hash[:p]] = s
+			}
+			s.places++
+			s.lat += e.Lat
+			s.lon += e.Lon
+		}
+	}
+	return stats
+}
+
+// planTerritories decides which tiles split and which siblings merge.
+// Settled is the agents old enough to merge
+func planTerritories(tiles map[string]string, stats map[string]*tileStat, settled map[string]bool) []territoryChange {
+	sorted := make([]string, 0, len(tiles))
+	for t := range tiles {
+		sorted = append(sorted, t)
+	}
+	sort.Strings(sorted)
+
+	var changes []territoryChange
+	splitting := make(map[string]bool)
+	for _, tile := range sorted {
+		if len(tile) >= tileMaxPrecision || stats[tile].count() <= tileSplitPlaces {
+			continue
+		}
+		var children []string
+		for i := 0; i < len(geohashBase32); i++ {
+			if child := tile + geohashBase32[i:i+1]; stats[child].count() > 0 {
+				children = append(children, child)
+			}
+		}
+		// Empty children stay unowned until someone goes there
+		sort.SliceStable(children, func(i, j int) bool { return stats[children[i]].count() > stats[children[j]].count() })
+		changes = append(changes, territoryChange{Split: true, Tile: tile, Keep: tiles[tile], Tiles: children})
+		splitting[tile] = true
+	}
+
+	// A parent merges when everything owned under it is a direct child,
+	// settled, not splitting, and quiet
+	parents := make(map[string][]string)
+	var order []string
+	for _, tile := range sorted {
+		if len(tile) <= tileMinPrecision {
+			continue
+		}
+		p := tile[:len(tile)-1]
+		if parents[p] == nil {
+			order = append(order, p)
+		}
+		parents[p] = append(parents[p], tile)
+	}
+	for _, p := range order {
+		if stats[p].count() >= tileMergePlaces {
+			continue
+		}
+		ok := true
+		for _, t := range sorted {
+			if strings.HasPrefix(t, p) && (len(t) != len(p)+1 || splitting[t] || !settled[tiles[t]]) {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		children := parents[p]
+		keep := children[0]
+		for _, c := range children[1:] {
+			if stats[c].count() > stats[keep].count() {
+				keep = c
+			}
+		}
+		change := territoryChange{Tile: p, Keep: tiles[keep]}
+		for _, c := range children {
+			if c != keep {
+				change.Drop = append(change.Drop, tiles[c])
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// RebalanceTerritories splits busy tiles, merges quiet ones and folds
+// overlapping agents from before tiling into their neighbour, then moves
+// each place to the agent now covering it
+func (d *DB) RebalanceTerritories() (splits, merges, reassigned int) {
+	stats := d.placeStats()
+
+	territoriesMu.Lock()
+	d.loadTerritoriesLocked()
+	tiles := make(map[string]string, len(territories))
+	for t, id := range territories {
+		tiles[t] = id
+	}
+	territoriesMu.Unlock()
+
+	owned := make(map[string]bool)
+	for _, id := range tiles {
+		owned[id] = true
+	}
+	settled := make(map[string]bool)
+	var orphans []*Entity
+	for _, a := range d.ListAgents() {
+		if !owned[a.ID] {
+			orphans = append(orphans, a)
+		} else if time.Since(a.CreatedAt) >= tileSettle {
+			settled[a.ID] = true
+		}
+	}
+
+	for _, a := range orphans {
+		if owner := d.TerritoryAgent(a.Lat, a.Lon); owner != nil && owner.ID != a.ID {
+			log.Printf("[territory] %s overlaps %s, merging", a.Name, owner.Name)
+			adoptInstructions(owner, a)
+			ControlAgent(a, "delete", 0, 0)
+			merges++
+		} else if err := retile(a, a.Lat, a.Lon); err != nil {
+			log.Printf("[territory] %s: %v", a.Name, err)
+		}
+	}
+
+	for _, c := range planTerritories(tiles, stats, settled) {
+		keeper := d.GetByID(c.Keep)
+		if keeper == nil {
+			continue
+		}
+		if c.Split {
+			log.Printf("[territory] %s (%s, %d places) splits into %d", keeper.Name, c.Tile, stats[c.Tile].count(), len(c.Tiles))
+			d.assignTile(keeper, c.Tiles[0], stats[c.Tiles[0]])
+			for _, tile := range c.Tiles[1:] {
+				lat, lon := stats[tile].centroid(tile)
+				d.spawnAgent(lat, lon, tile)
+			}
+			splits++
+			continue
+		}
+
+		for _, id := range c.Drop {
+			if a := d.GetByID(id); a != nil {
+				log.Printf("[territory] %s merges into %s", a.Name, keeper.Name)
+				adoptInstructions(keeper, a)
+				ControlAgent(a, "delete", 0, 0)
+			}
+		}
+		d.assignTile(keeper, c.Tile, stats[c.Tile])
+		merges++
+	}
+
+	territoriesMu.Lock()
+	tiles = make(map[string]string, len(territories))
+	for t, id := range territories {
+		tiles[t] = id
+	}
+	territoriesMu.Unlock()
+	reassigned = d.ReassignPlaces(func(lat, lon float64) string {
+		_, id := tileOwner(tiles, lat, lon)
+		return id
+	})
+	return splits, merges, reassigned
+}
+
+// assignTile hands a tile to an existing agent and moves it to where the
+// tile's places are
+func (d *DB) assignTile(agent *Entity, tile string, stat *tileStat) {
+	lat, lon := stat.centroid(tile)
+	territoriesMu.Lock()
+	claimTile(agent, tile, lat, lon)
+	territoriesMu.Unlock()
+	if err := ControlAgent(agent, "move", lat, lon); err != nil {
+		log.Printf("[territory] Moving %s: %v", agent.Name, err)
+	}
+}
+
+// spawnAgent starts a new agent owning a tile
+func (d *DB) spawnAgent(lat, lon float64, tile string) *Entity {
+	territoriesMu.Lock()
+	if !tileFree(territories, tile) {
+		territoriesMu.Unlock()
+		return nil
+	}
+	agent := d.CreateAgent(lat, lon, AgentRadius, fmt.Sprintf("Area %.2f,%.2f", lat, lon))
+	claimTile(agent, tile, lat, lon)
+	territoriesMu.Unlock()
+
+	d.nameAgentAsync(agent)
+	StartAgentLoop(agent)
+	return agent
+}
+
+// adoptInstructions moves a merged agent's standing instructions to the
+// agent taking over its area
+func adoptInstructions(to, from *Entity) {
+	instructionsMu.Lock()
+	defer instructionsMu.Unlock()
+	fromData, toData := from.GetAgentData(), to.GetAgentData()
+	if fromData == nil || toData == nil || len(fromData.Instructions) == 0 {
+		return
+	}
+	have := make(map[string]bool)
+	for _, in := range toData.Instructions {
+		have[in.ID] = true
+	}
+	for _, in := range fromData.Instructions {
+		if !have[in.ID] && len(toData.Instructions) < maxInstructions {
+			toData.Instructions = append(toData.Instructions, in)
+		}
+	}
+	to.Data = toData
+	Get().Insert(to)
+}
+
+// StartTerritoryLoop rebalances agent territories periodically
+func (d *DB) StartTerritoryLoop(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			splits, merges, reassigned := d.RebalanceTerritories()
+			if splits+merges+reassigned > 0 {
+				log.Printf("[territory] %d splits, %d merges, %d places reassigned", splits, merges, reassigned)
+			}
+		}
+	}()
+}
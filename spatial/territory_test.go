@@ -0,0 +1,176 @@
+package spatial
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Trafalgar Square, and its geohash at the finest tile precision
+const territoryTestLat, territoryTestLon = 51.5074, -0.1278
+
+var territoryTestHash = Geohash(territoryTestLat, territoryTestLon, tileMaxPrecision)
+
+// TestNewTile checks a new agent gets the standard size tile at its spot
+func TestNewTile(t *testing.T) {
+	if tile := newTile(map[string]string{}, territoryTestLat, territoryTestLon); tile != territoryTestHash[:tilePrecision] {
+		t.Errorf("new tile %s, want %s", tile, territoryTestHash[:tilePrecision])
+	}
+}
+
+// testSplitTiles has the spot's standard tile split, a sibling child
+// of the spot's own child owned by a busy agent
+func testSplitTiles() map[string]string {
+	parent := territoryTestHash[:tilePrecision]
+	sibling := parent + "0"
+	if sibling == territoryTestHash[:tilePrecision+1] {
+		sibling = parent + "1"
+	}
+	return map[string]string{sibling: "busy"}
+}
+
+// TestNewTileBesideSplit checks a new tile in a split cell takes the free child
+func TestNewTileBesideSplit(t *testing.T) {
+	if tile := newTile(testSplitTiles(), territoryTestLat, territoryTestLon); tile != territoryTestHash[:tilePrecision+1] {
+		t.Errorf("tile beside a split: %s, want %s", tile, territoryTestHash[:tilePrecision+1])
+	}
+}
+
+// TestTileFree checks tiles overlapping an owned one aren't free
+func TestTileFree(t *testing.T) {
+	tiles := testSplitTiles()
+	if tileFree(tiles, territoryTestHash[:tilePrecision-1]) || !tileFree(tiles, territoryTestHash[:tilePrecision+1]) {
+		t.Error("overlap not detected")
+	}
+}
+
+// TestTileOwner checks a point is covered by its ancestor tile
+func TestTileOwner(t *testing.T) {
+	if _, id := tileOwner(map[string]string{territoryTestHash[:5]: "a"}, territoryTestLat, territoryTestLon); id != "a" {
+		t.Error("point not covered by its ancestor tile")
+	}
+}
+
+// TestTileReach checks an agent's radius takes in its whole tile
+func TestTileReach(t *testing.T) {
+	if r := tileReach(territoryTestHash[:6], territoryTestLat, territoryTestLon); r < 500 || r > 2000 {
+		t.Errorf("precision 6 reach %.0f", r)
+	}
+}
+
+// testTerritoryPlan plans a rebalance of a dense tile, two settled quiet
+// siblings and a new agent's quiet tile
+func testTerritoryPlan(t *testing.T) []territoryChange {
+	stats := map[string]*tileStat{
+		"gcpvj0":  {places: 2000},
+		"gcpvj0c": {places: 1200},
+		"gcpvj0b": {places: 800},
+		"gcpvk":   {places: 30},
+		"gcpvk1":  {places: 20},
+		"gcpvk2":  {places: 10},
+		"gcpvm":   {places: 40},
+		"gcpvm1":  {places: 40},
+	}
+	tiles := map[string]string{"gcpvj0": "dense", "gcpvk1": "quiet1", "gcpvk2": "quiet2", "gcpvm1": "new"}
+	settled := map[string]bool{"dense": true, "quiet1": true, "quiet2": true}
+	changes := planTerritories(tiles, stats, settled)
+	if len(changes) != 2 {
+		t.Fatalf("changes: %+v", changes)
+	}
+	return changes
+}
+
+// TestTerritorySplit checks a busy tile splits into its occupied children, busiest first
+func TestTerritorySplit(t *testing.T) {
+	if c := testTerritoryPlan(t)[0]; !c.Split || c.Keep != "dense" || len(c.Tiles) != 2 || c.Tiles[0] != "gcpvj0c" {
+		t.Errorf("split: %+v", c)
+	}
+}
+
+// TestTerritoryMerge checks settled quiet siblings merge into their parent,
+// and a new agent's tile is left alone
+func TestTerritoryMerge(t *testing.T) {
+	if c := testTerritoryPlan(t)[1]; c.Split || c.Tile != "gcpvk" || c.Keep != "quiet1" || len(c.Drop) != 1 || c.Drop[0] != "quiet2" {
+		t.Errorf("merge: %+v", c)
+	}
+}
+
+// TestReassignPlaces checks places move to the agent covering them and
+// others keep theirs
+func TestReassignPlaces(t *testing.T) {
+	db := Get()
+	inside := &Entity{ID: "territory-test-inside", Type: EntityPlace, Name: "Inside", Lat: territoryTestLat, Lon: territoryTestLon,
+		Data: map[string]interface{}{"category": "cafe", "agent_id": "old"}}
+	outside := &Entity{ID: "territory-test-outside", Type: EntityPlace, Name: "Outside", Lat: 53.48, Lon: -2.24,
+		Data: &PlaceData{Category: "cafe", AgentID: "old"}}
+	db.Insert(inside)
+	db.Insert(outside)
+	t.Cleanup(func() {
+		db.Delete(inside.ID)
+		db.Delete(outside.ID)
+	})
+	owned := map[string]string{territoryTestHash[:6]: "territory-test-owner"}
+	db.ReassignPlaces(func(lat, lon float64) string {
+		_, id := tileOwner(owned, lat, lon)
+		return id
+	})
+	if db.CountByAgentID("territory-test-owner", EntityPlace) != 1 || db.GetByID(outside.ID).GetPlaceData().AgentID != "old" {
+		t.Error("places not reassigned to their tile's agent")
+	}
+}
+
+// testIndexAgent stores what a full IndexAgent run keeps for an agent,
+// every category at its limit, spread evenly over the agent's radius
+func testIndexAgent(t *testing.T, agent *Entity) {
+	reach := agentReach(agent)
+	n := len(indexCategories) * indexCategoryLimit
+	k := 0
+	for _, category := range indexCategories {
+		elements := make([]overpassElement, indexCategoryLimit)
+		for i := range elements {
+			r := reach * math.Sqrt((float64(k)+0.5)/float64(n))
+			theta := float64(k) * 2.39996 // Golden angle
+			elements[i] = overpassElement{
+				Type: "node",
+				ID:   int64(k),
+				Lat:  agent.Lat + r*math.Cos(theta)/111320,
+				Lon:  agent.Lon + r*math.Sin(theta)/(111320*math.Cos(agent.Lat*math.Pi/180)),
+				Tags: map[string]string{"name": fmt.Sprintf("Indexed %d", k)},
+			}
+			k++
+		}
+		storeIndexed(agent, category, elements)
+	}
+	t.Cleanup(func() {
+		for _, place := range Get().Query(agent.Lat, agent.Lon, reach*2, EntityPlace, n*2) {
+			if pd := place.GetPlaceData(); pd != nil && pd.AgentID == agent.ID {
+				Get().Delete(place.ID)
+			}
+		}
+	})
+}
+
+// TestIndexedTileSplits checks a new agent's tile filled by a full index
+// holds enough places to split
+func TestIndexedTileSplits(t *testing.T) {
+	agent := testTerritoryAgent(t, "test-agent-indexed-split", 53.8, -1.55)
+	testIndexAgent(t, agent)
+	tile := AgentTile(agent)
+	changes := planTerritories(map[string]string{tile: agent.ID}, Get().placeStats(), map[string]bool{agent.ID: true})
+	if len(changes) != 1 || !changes[0].Split || changes[0].Tile != tile {
+		t.Errorf("indexed tile %s: %+v", tile, changes)
+	}
+}
+
+// TestFindAgentSameName checks a place named differently by its region
+// is served by the agent already reaching it
+func TestFindAgentSameName(t *testing.T) {
+	agent := testTerritoryAgent(t, "test-agent-same-name", 54.2, -2.9)
+	agent.Name = "Samefold, Cumbria"
+	Get().Insert(agent)
+	// Just outside the agent's tile, still within its reach
+	minLat, _, _, _ := GeohashBounds(AgentTile(agent))
+	if found := Get().FindOrCreateAgentNamed(minLat-0.001, -2.9, "Samefold, Westmorland"); found == nil || found.ID != agent.ID {
+		t.Errorf("same name nearby: %+v", found)
+	}
+}
@@ -0,0 +1,103 @@
+package spatial
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+	_ "time/tzdata" // Zones load on hosts without zoneinfo
+)
+
+// Timezone lookup from coordinates. timezones.json is a hand-drawn table of
+// approximate zone areas - boxes or coarse polygons, not real boundaries.
+// Areas overlap near borders and the first match wins, so smaller areas are
+// listed first. Good enough for cities; within tens of km of a border it can
+// pick the neighbour.
+
+//go:embed timezones.json
+var timezonesJSON []byte
+
+// tzZone is one IANA zone's area
+type tzZone struct {
+	TZ      string      `json:"tz"`
+	Box     []float64   `json:"box"`     // [minLon, minLat, maxLon, maxLat]
+	Polygon [][]float64 `json:"polygon"` // [lon, lat] ring
+}
+
+func (z *tzZone) contains(lat, lon float64) bool {
+	if len(z.Polygon) > 0 {
+		return pointInPolygon(lat, lon, z.Polygon)
+	}
+	return len(z.Box) == 4 && lon >= z.Box[0] && lat >= z.Box[1] && lon <= z.Box[2] && lat <= z.Box[3]
+}
+
+var (
+	tzZones     []tzZone
+	tzZonesOnce sync.Once
+
+	tzLocations   = make(map[string]*time.Location)
+	tzLocationsMu sync.Mutex
+)
+
+func loadTimezones() {
+	var file struct {
+		Zones []tzZone `json:"zones"`
+	}
+	if err := json.Unmarshal(timezonesJSON, &file); err != nil {
+		log.Printf("[timezone] Bad timezones.json: %v", err)
+		return
+	}
+	tzZones = file.Zones
+}
+
+// TimezoneName returns the IANA zone for a location
+// Region zones win, then the boundary table, then a nautical Etc/GMT zone
+func TimezoneName(lat, lon float64) string {
+	if r := GetRegion(lat, lon); r != nil && r.Timezone != "" {
+		return r.Timezone
+	}
+	tzZonesOnce.Do(loadTimezones)
+	for i := range tzZones {
+		if tzZones[i].contains(lat, lon) {
+			return tzZones[i].TZ
+		}
+	}
+	// Etc zones have inverted signs: Etc/GMT+5 is UTC-5
+	offset := int(math.Round(lon / 15))
+	switch {
+	case offset == 0:
+		return "Etc/GMT"
+	case offset > 0:
+		return fmt.Sprintf("Etc/GMT-%d", offset)
+	default:
+		return fmt.Sprintf("Etc/GMT+%d", -offset)
+	}
+}
+
+// TimezoneAt returns the time.Location for a location
+// Falls back to a fixed offset from longitude if the zone can't be loaded
+func TimezoneAt(lat, lon float64) *time.Location {
+	name := TimezoneName(lat, lon)
+
+	tzLocationsMu.Lock()
+	defer tzLocationsMu.Unlock()
+	if loc, ok := tzLocations[name]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("[timezone] %s: %v", name, err)
+		offset := int(math.Round(lon/15)) * 3600
+		return time.FixedZone(fmt.Sprintf("UTC%+d", offset/3600), offset)
+	}
+	tzLocations[name] = loc
+	return loc
+}
+
+// LocalTime returns t in the timezone of a location
+func LocalTime(t time.Time, lat, lon float64) time.Time {
+	return t.In(TimezoneAt(lat, lon))
+}
@@ -0,0 +1,61 @@
+package spatial
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTimezoneName checks zone lookup for cities near borders and at sea
+func TestTimezoneName(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     string
+	}{
+		{"london", 51.5074, -0.1278, "Europe/London"},
+		{"belfast", 54.597, -5.930, "Europe/London"},
+		{"dublin", 53.349, -6.260, "Europe/Dublin"},
+		{"boulogne", 50.726, 1.614, "Europe/Paris"},
+		{"lisbon", 38.722, -9.139, "Europe/Lisbon"},
+		{"badajoz", 38.879, -6.970, "Europe/Madrid"},
+		{"istanbul", 41.008, 28.978, "Europe/Istanbul"},
+		{"mecca", 21.389, 39.858, "Asia/Riyadh"},
+		{"tehran", 35.689, 51.389, "Asia/Tehran"},
+		{"karachi", 24.861, 67.010, "Asia/Karachi"},
+		{"delhi", 28.614, 77.209, "Asia/Kolkata"},
+		{"dhaka", 23.810, 90.413, "Asia/Dhaka"},
+		{"new york", 40.713, -74.006, "America/New_York"},
+		{"toronto", 43.653, -79.383, "America/Toronto"},
+		{"chicago", 41.878, -87.630, "America/Chicago"},
+		{"phoenix", 33.448, -112.074, "America/Phoenix"},
+		{"sydney", -33.869, 151.209, "Australia/Sydney"},
+		{"mid atlantic", 30.0, -40.0, "Etc/GMT+3"},
+	}
+	for _, tt := range tests {
+		if got := TimezoneName(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("%s: TimezoneName = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestTimezoneTable checks every zone in the table exists in the tz database
+func TestTimezoneTable(t *testing.T) {
+	tzZonesOnce.Do(loadTimezones)
+	if len(tzZones) == 0 {
+		t.Fatal("no zones loaded")
+	}
+	for _, z := range tzZones {
+		if _, err := time.LoadLocation(z.TZ); err != nil {
+			t.Errorf("zone %s: %v", z.TZ, err)
+		}
+	}
+}
+
+// TestLocalTime checks summer time is applied, so 06:30 UTC in July is
+// 07:30 in London, not the 06:30 a longitude guess gives
+func TestLocalTime(t *testing.T) {
+	summer := time.Date(2026, 7, 1, 6, 30, 0, 0, time.UTC)
+	if h := LocalTime(summer, 51.5074, -0.1278).Hour(); h != 7 {
+		t.Errorf("London summer hour = %d, want 7", h)
+	}
+}
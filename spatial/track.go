@@ -19,6 +19,7 @@ type UserTrack struct {
 	Points    []TrackPoint
 	LastSave  time.Time
 	SessionID string
+	Segments  [][]TrackPoint // Finished stretches, kept for the user to export
 }
 
 var (
@@ -31,6 +32,7 @@ const (
 	minStreetLength   = 100.0           // meters - don't save streets shorter than this
 	stationaryTimeout = 5 * time.Minute // save track after this much stillness
 	maxTrackPoints    = 500             // max points before auto-save
+	trackHistory      = 24 * time.Hour  // how long finished segments are kept for export
 )
 
 // RecordLocation adds a GPS point to the user's current track
@@ -59,6 +61,7 @@ func RecordLocation(sessionID string, lat, lon float64) {
 			// But check if we've been stationary long enough to save
 			if now.Sub(last.Timestamp) > stationaryTimeout && len(track.Points) > 1 {
 				// Save the track and start fresh
+				track.archive(now)
 				go saveTrackAsStreet(track)
 				track.Points = []TrackPoint{{Lat: lat, Lon: lon, Timestamp: now}}
 				track.LastSave = now
@@ -76,6 +79,7 @@ func RecordLocation(sessionID string, lat, lon float64) {
 
 	// Auto-save if track is getting long
 	if len(track.Points) >= maxTrackPoints {
+		track.archive(now)
 		go saveTrackAsStreet(track)
 		// Keep last point as start of new track
 		lastPoint := track.Points[len(track.Points)-1]
@@ -84,6 +88,48 @@ func RecordLocation(sessionID string, lat, lon float64) {
 	}
 }
 
+// archive keeps the current points as a finished segment, dropping old ones
+func (t *UserTrack) archive(now time.Time) {
+	if len(t.Points) > 1 {
+		t.Segments = append(t.Segments, append([]TrackPoint(nil), t.Points...))
+	}
+	t.prune(now)
+}
+
+// prune drops finished segments older than the history kept
+func (t *UserTrack) prune(now time.Time) {
+	for len(t.Segments) > 0 {
+		seg := t.Segments[0]
+		if now.Sub(seg[len(seg)-1].Timestamp) < trackHistory {
+			break
+		}
+		t.Segments = t.Segments[1:]
+	}
+}
+
+// SessionTrack returns a session's recorded segments, oldest first, the
+// current one last. epsilon > 0 simplifies each to that many metres
+func SessionTrack(sessionID string, epsilon float64) [][]TrackPoint {
+	userTracksMu.Lock()
+	defer userTracksMu.Unlock()
+	track, ok := userTracks[sessionID]
+	if !ok {
+		return nil
+	}
+	track.prune(time.Now())
+
+	segments := append([][]TrackPoint(nil), track.Segments...)
+	if len(track.Points) > 1 {
+		segments = append(segments, append([]TrackPoint(nil), track.Points...))
+	}
+	if epsilon > 0 {
+		for i, seg := range segments {
+			segments[i] = simplifyTrack(seg, epsilon)
+		}
+	}
+	return segments
+}
+
 // saveTrackAsStreet converts a user track to a street entity
 func saveTrackAsStreet(track *UserTrack) {
 	if len(track.Points) < 2 {
@@ -244,12 +290,19 @@ func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
 }
 
 // FlushTrack forces saving of a user's current track (e.g., on app close)
+// Finished segments stay available for export until they age out
 func FlushTrack(sessionID string) {
 	userTracksMu.Lock()
 	track, exists := userTracks[sessionID]
 	if exists && len(track.Points) > 1 {
-		go saveTrackAsStreet(track)
-		delete(userTracks, sessionID)
+		now := time.Now()
+		track.archive(now)
+		go saveTrackAsStreet(&UserTrack{Points: track.Points, SessionID: sessionID})
+		track.Points = nil
+		track.LastSave = now
+		if len(track.Segments) == 0 {
+			delete(userTracks, sessionID)
+		}
 	}
 	userTracksMu.Unlock()
 }